@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/md5"
 	"flag"
 	"fmt"
@@ -103,8 +104,7 @@ func build(binaryName, pkg string, tags []string) {
 	if !isDev {
 		rmr(binary, binary+".md5")
 	}
-	//args := []string{"build", "-ldflags", ldflags()}
-	args := []string{"build"}
+	args := []string{"build", "-ldflags", ldflags()}
 	if len(tags) > 0 {
 		args = append(args, "-tags", strings.Join(tags, ","))
 	}
@@ -132,6 +132,24 @@ func build(binaryName, pkg string, tags []string) {
 	}
 }
 
+func ldflags() string {
+	var b bytes.Buffer
+	b.WriteString("-w")
+	b.WriteString(fmt.Sprintf(" -X main.version=%s", gitOutput("describe", "--tags", "--always", "--dirty")))
+	b.WriteString(fmt.Sprintf(" -X main.commit=%s", gitOutput("rev-parse", "--short", "HEAD")))
+	b.WriteString(fmt.Sprintf(" -X main.buildBranch=%s", gitOutput("rev-parse", "--abbrev-ref", "HEAD")))
+	return b.String()
+}
+
+// gitOutput trimmed output of the git command, unknown if it fails, e.g. outside of a git repository
+func gitOutput(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(out))
+}
+
 func rmr(paths ...string) {
 	for _, path := range paths {
 		log.Println("rm -r", path)
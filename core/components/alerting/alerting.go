@@ -1,6 +1,10 @@
 package alerting
 
-import "jasonzhu.com/coin_labor/core/components/log"
+import (
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/setting"
+	"time"
+)
 
 /**
 
@@ -10,21 +14,58 @@ import "jasonzhu.com/coin_labor/core/components/log"
 
 var lg = log.New("alerting")
 
+var defaultDeduper = NewDeduper(time.Minute, 10, time.Hour)
+
+func init() {
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		for now := range ticker.C {
+			for _, alert := range defaultDeduper.Sweep(now) {
+				deliver(alert)
+			}
+		}
+	}()
+}
+
+// Notify raises an error alert if err is not nil, an info alert otherwise, deduplicated by msg
 func Notify(err error, msg string, params ...interface{}) {
 	go func() {
-		notify(err, msg, params...)
+		raise(newAlert(err, msg, params...), false)
 	}()
 }
 
 func Info(msg string, params ...interface{}) {
 	go func() {
-		notify(nil, msg, params...)
+		raise(newAlert(nil, msg, params...), false)
 	}()
 }
 
 func NotifyRightNow(err error, msg string, params ...interface{}) {
 	go func() {
-		notify(err, msg, params...)
+		raise(newAlert(err, msg, params...), true)
+	}()
+}
+
+// Raise alerts of the same key are deduplicated and rate limited, key defaults to msg.
+// Critical alerts are flushed right away.
+func Raise(severity Severity, key string, msg string, params ...interface{}) {
+	go func() {
+		raise(Alert{Severity: severity, Key: key, Msg: msg, Params: params}, false)
+	}()
+}
+
+func RaiseRightNow(severity Severity, key string, msg string, params ...interface{}) {
+	go func() {
+		raise(Alert{Severity: severity, Key: key, Msg: msg, Params: params}, true)
+	}()
+}
+
+// Resolve sends "resolved after" notice if the key was raised and not forgotten yet
+func Resolve(key string) {
+	go func() {
+		for _, alert := range defaultDeduper.Resolve(key, time.Now()) {
+			deliver(alert)
+		}
 		flush()
 	}()
 }
@@ -35,17 +76,50 @@ func Flush() {
 	}()
 }
 
-func notify(err error, msg string, params ...interface{}) {
+func newAlert(err error, msg string, params ...interface{}) Alert {
 	if err != nil {
-		params = append(params, "err", err)
-		lg.Error(msg, params...)
-		_ = defaultTelegramNotifier.ErrorNotify(msg, params...)
-	} else {
-		lg.Info(msg, params...)
-		_ = defaultTelegramNotifier.InfoNotify(msg, params...)
+		return Alert{Severity: SeverityError, Key: msg, Msg: msg, Params: append(params, "err", err)}
+	}
+	return Alert{Severity: SeverityInfo, Key: msg, Msg: msg, Params: params}
+}
+
+// raise logs the alert, and sends it to the backends routed when alerting is enabled
+func raise(alert Alert, rightNow bool) {
+	if alert.Key == "" {
+		alert.Key = alert.Msg
+	}
+	switch {
+	case alert.Severity >= SeverityError:
+		lg.Error(alert.Msg, alert.Params...)
+	case alert.Severity == SeverityWarning:
+		lg.Warn(alert.Msg, alert.Params...)
+	default:
+		lg.Info(alert.Msg, alert.Params...)
+	}
+	if !setting.AlertingEnabled {
+		return
+	}
+	if alert, ok := defaultDeduper.Raise(alert, time.Now()); ok {
+		deliver(alert)
+	}
+	if rightNow {
+		flush()
+	}
+}
+
+func deliver(alert Alert) {
+	for _, r := range getRoutes() {
+		if alert.Severity >= r.minSeverity {
+			_ = r.notifier.Notify(alert.Severity, alert.Msg, alert.Params...)
+		}
+	}
+	if alert.Severity >= SeverityCritical {
+		flush()
 	}
 }
 
 func flush() {
-	defaultTelegramNotifier.Flush()
+	for _, r := range getRoutes() {
+		r.notifier.Flush()
+	}
 }
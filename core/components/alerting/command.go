@@ -0,0 +1,113 @@
+package alerting
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// CommandReply of an operator command, rendered as a title, a table and a note
+type CommandReply struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+	Note   string
+}
+
+func (r *CommandReply) AddRow(cells ...string) {
+	r.Rows = append(r.Rows, cells)
+}
+
+// Text plain text with the table aligned by columns, for monospace rendering
+func (r *CommandReply) Text() string {
+	var b strings.Builder
+	if r.Title != "" {
+		b.WriteString(r.Title)
+		b.WriteString("\n")
+	}
+	if len(r.Header) > 0 || len(r.Rows) > 0 {
+		b.WriteString(FormatTable(r.Header, r.Rows))
+	}
+	if r.Note != "" {
+		b.WriteString(r.Note)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// FormatTable pads every column to its widest cell, the header is underlined
+func FormatTable(header []string, rows [][]string) string {
+	var widths []int
+	measure := func(cells []string) {
+		for i, cell := range cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	measure(header)
+	for _, row := range rows {
+		measure(row)
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			b.WriteString(cell)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		b.WriteString("\n")
+	}
+	if len(header) > 0 {
+		writeRow(header)
+		underline := make([]string, len(header))
+		for i := range header {
+			underline[i] = strings.Repeat("-", widths[i])
+		}
+		writeRow(underline)
+	}
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// Operator commands are dispatched on bus to the service owning the data, the handler fills Reply.
+
+type StatusCommand struct {
+	Reply CommandReply
+}
+
+type BalancesCommand struct {
+	Reply CommandReply
+}
+
+// OrdersCommand open orders tracked
+type OrdersCommand struct {
+	Reply CommandReply
+}
+
+type PauseCommand struct {
+	Reason string
+	Reply  CommandReply
+}
+
+type ResumeCommand struct {
+	Reply CommandReply
+}
+
+// CancelAllCommand cancels open orders of every watched symbol of the exchange
+type CancelAllCommand struct {
+	Exchange string
+	Reply    CommandReply
+}
+
+// SpreadCommand current spreads of the symbol between every pair of exchanges
+type SpreadCommand struct {
+	Symbol string
+	Reply  CommandReply
+}
@@ -0,0 +1,18 @@
+package alerting
+
+import "testing"
+
+func TestFormatTable(t *testing.T) {
+	reply := CommandReply{Title: "Balances", Header: []string{"EXCHANGE", "ASSET", "FREE"}, Note: "2 assets"}
+	reply.AddRow("binance", "USDT", "100.5")
+	reply.AddRow("MEXC", "INJ", "3")
+	expected := "Balances\n" +
+		"EXCHANGE  ASSET  FREE\n" +
+		"--------  -----  -----\n" +
+		"binance   USDT   100.5\n" +
+		"MEXC      INJ    3\n" +
+		"2 assets\n"
+	if text := reply.Text(); text != expected {
+		t.Fatalf("unexpected text:\n%s", text)
+	}
+}
@@ -0,0 +1,135 @@
+package alerting
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dedupeStateTTL keys never resolved are forgotten once not raised for it, resolved keys are deleted by Resolve
+const dedupeStateTTL = 7 * 24 * time.Hour
+
+// Alert raised by components, alerts of the same Key are deduplicated
+type Alert struct {
+	Severity Severity
+	Key      string
+	Msg      string
+	Params   []interface{}
+}
+
+type dedupeState struct {
+	last        Alert
+	firstAt     time.Time
+	lastAt      time.Time
+	windowStart time.Time
+	repeats     int         // raised since the last message sent
+	sent        []time.Time // messages sent within rate period
+}
+
+// Deduper sends the first alert of a key right away, repeats within window are grouped into one
+// "N occurrences" message, and at most rateLimit messages per key are sent each ratePeriod.
+// States are kept until Resolve, so the recovery notice of a long incident is still sent.
+type Deduper struct {
+	mu         sync.Mutex
+	window     time.Duration
+	rateLimit  int
+	ratePeriod time.Duration
+	states     map[string]*dedupeState
+}
+
+func NewDeduper(window time.Duration, rateLimit int, ratePeriod time.Duration) *Deduper {
+	return &Deduper{
+		window:     window,
+		rateLimit:  rateLimit,
+		ratePeriod: ratePeriod,
+		states:     make(map[string]*dedupeState),
+	}
+}
+
+// Configure keeps the states of keys
+func (d *Deduper) Configure(window time.Duration, rateLimit int, ratePeriod time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.window, d.rateLimit, d.ratePeriod = window, rateLimit, ratePeriod
+}
+
+// allow rateLimit <= 0 is unlimited
+func (d *Deduper) allow(state *dedupeState, now time.Time) bool {
+	kept := state.sent[:0]
+	for _, t := range state.sent {
+		if now.Sub(t) < d.ratePeriod {
+			kept = append(kept, t)
+		}
+	}
+	state.sent = kept
+	return d.rateLimit <= 0 || len(state.sent) < d.rateLimit
+}
+
+// Raise returns the alert to send now, if any
+func (d *Deduper) Raise(alert Alert, now time.Time) (Alert, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state, ok := d.states[alert.Key]
+	if !ok {
+		state = &dedupeState{firstAt: now, windowStart: now}
+		d.states[alert.Key] = state
+	}
+	state.last, state.lastAt = alert, now
+	// repeats within the window, or over the rate limit, are grouped by Sweep
+	if ok && (state.repeats > 0 || now.Sub(state.windowStart) < d.window || !d.allow(state, now)) {
+		state.repeats++
+		return Alert{}, false
+	}
+	state.windowStart = now
+	state.sent = append(state.sent, now)
+	return alert, true
+}
+
+// Sweep returns grouped messages of keys whose window has passed
+func (d *Deduper) Sweep(now time.Time) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var res []Alert
+	for key, state := range d.states {
+		if state.repeats == 0 {
+			if now.Sub(state.lastAt) >= dedupeStateTTL {
+				delete(d.states, key)
+			}
+			continue
+		}
+		if now.Sub(state.windowStart) < d.window || !d.allow(state, now) {
+			continue
+		}
+		res = append(res, d.grouped(state))
+		state.repeats = 0
+		state.windowStart = now
+		state.sent = append(state.sent, now)
+	}
+	return res
+}
+
+func (d *Deduper) grouped(state *dedupeState) Alert {
+	alert := state.last
+	alert.Msg = fmt.Sprintf("%s (%d occurrences)", alert.Msg, state.repeats)
+	return alert
+}
+
+// Resolve returns the grouped repeats not sent yet and a recovery notice, if the key is active
+func (d *Deduper) Resolve(key string, now time.Time) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state, ok := d.states[key]
+	if !ok {
+		return nil
+	}
+	delete(d.states, key)
+	var res []Alert
+	if state.repeats > 0 {
+		res = append(res, d.grouped(state))
+	}
+	return append(res, Alert{
+		Severity: SeverityInfo,
+		Key:      key,
+		Msg:      fmt.Sprintf("%s resolved after %s", state.last.Msg, now.Sub(state.firstAt).Round(time.Second)),
+	})
+}
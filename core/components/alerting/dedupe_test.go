@@ -0,0 +1,77 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeduperGroupsRepeats(t *testing.T) {
+	d := NewDeduper(time.Minute, 0, time.Hour)
+	start := time.Now()
+	alert := Alert{Severity: SeverityError, Key: "ws", Msg: "websocket closed"}
+
+	if _, ok := d.Raise(alert, start); !ok {
+		t.Fatal("first alert should be sent")
+	}
+	for i := 1; i <= 5; i++ {
+		if _, ok := d.Raise(alert, start.Add(time.Duration(i)*time.Second)); ok {
+			t.Fatal("repeats within window should be grouped")
+		}
+	}
+	if res := d.Sweep(start.Add(30 * time.Second)); len(res) != 0 {
+		t.Fatalf("window has not passed: %v", res)
+	}
+	res := d.Sweep(start.Add(time.Minute))
+	if len(res) != 1 || res[0].Msg != "websocket closed (5 occurrences)" || res[0].Severity != SeverityError {
+		t.Fatalf("unexpected grouped alerts: %v", res)
+	}
+	if res := d.Sweep(start.Add(2 * time.Minute)); len(res) != 0 {
+		t.Fatalf("nothing repeated: %v", res)
+	}
+
+	// quiet for a window, sent right away again
+	if _, ok := d.Raise(alert, start.Add(3*time.Minute)); !ok {
+		t.Fatal("alert after a quiet window should be sent")
+	}
+	_, _ = d.Raise(alert, start.Add(3*time.Minute+time.Second))
+	res = d.Resolve("ws", start.Add(3*time.Minute+2*time.Second))
+	if len(res) != 2 || res[0].Msg != "websocket closed (1 occurrences)" ||
+		res[1].Msg != "websocket closed resolved after 3m2s" || res[1].Severity != SeverityInfo {
+		t.Fatalf("unexpected resolved alerts: %v", res)
+	}
+	if res := d.Resolve("ws", start.Add(4*time.Minute)); res != nil {
+		t.Fatalf("resolved already: %v", res)
+	}
+}
+
+func TestDeduperRateLimit(t *testing.T) {
+	d := NewDeduper(time.Second, 2, time.Hour)
+	start := time.Now()
+	alert := Alert{Severity: SeverityWarning, Key: "depth", Msg: "depth is stale"}
+
+	sent := 0
+	for i := 0; i < 10; i++ {
+		now := start.Add(time.Duration(i) * 2 * time.Second)
+		if _, ok := d.Raise(alert, now); ok {
+			sent++
+		}
+		sent += len(d.Sweep(now))
+	}
+	if sent != 2 {
+		t.Fatalf("expected 2 messages within rate period, got %d", sent)
+	}
+	res := d.Sweep(start.Add(time.Hour))
+	if len(res) != 1 || res[0].Msg != "depth is stale (8 occurrences)" {
+		t.Fatalf("suppressed repeats should be grouped once the rate period passes: %v", res)
+	}
+	if res := d.Sweep(start.Add(3 * time.Hour)); len(res) != 0 || len(d.states) != 1 {
+		t.Fatalf("unresolved key should be kept: %v", res)
+	}
+	if res := d.Resolve("depth", start.Add(3*time.Hour)); len(res) != 1 || res[0].Msg != "depth is stale resolved after 3h0m0s" {
+		t.Fatalf("long incident should be resolved: %v", res)
+	}
+	d.Raise(alert, start)
+	if res := d.Sweep(start.Add(dedupeStateTTL)); len(res) != 0 || len(d.states) != 0 {
+		t.Fatalf("idle key should be forgotten: %v", res)
+	}
+}
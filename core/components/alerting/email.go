@@ -0,0 +1,81 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/ini.v1"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier("email", newEmailNotifier)
+}
+
+// EmailNotifier sends one mail per batch by SMTP, batched longer than chats to avoid flooding inboxes
+type EmailNotifier struct {
+	lg            log.Logger
+	addr          string
+	auth          smtp.Auth
+	from          string
+	to            []string
+	subjectPrefix string
+	*batcher
+}
+
+func newEmailNotifier(sec *ini.Section) (Notifier, error) {
+	host := sec.Key("host").String()
+	from := sec.Key("from").String()
+	var to []string
+	for _, addr := range sec.Key("to").Strings(",") {
+		if addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if host == "" || from == "" || len(to) == 0 {
+		return nil, errors.New("host, from and to are required")
+	}
+	n := &EmailNotifier{
+		lg:            log.New("alerting.notifier.email"),
+		addr:          net.JoinHostPort(host, sec.Key("port").MustString("587")),
+		from:          from,
+		to:            to,
+		subjectPrefix: sec.Key("subject_prefix").MustString("[coin_labor]"),
+	}
+	if user := sec.Key("user").String(); user != "" {
+		n.auth = smtp.PlainAuth("", user, sec.Key("password").String(), host)
+	}
+	n.batcher = newBatcher(n.lg, sec.Key("flush_interval").MustDuration(time.Minute), n.send)
+	return n, nil
+}
+
+func (n *EmailNotifier) Notify(severity Severity, msg string, params ...interface{}) error {
+	return n.add(severity, msg, params...)
+}
+
+func (n *EmailNotifier) Flush() {
+	n.flush()
+}
+
+func (n *EmailNotifier) Close() {
+	n.close()
+}
+
+func (n *EmailNotifier) message(severity Severity, msgs []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s %d %s alert(s)\r\n", n.subjectPrefix, len(msgs), severity)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body := strings.Join(msgs, "\n--------------------------------\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func (n *EmailNotifier) send(severity Severity, msgs []string) error {
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, n.message(severity, msgs))
+}
@@ -14,17 +14,14 @@ func buildContent(msg string, params ...interface{}) (string, error) {
 	}
 	slice := []string{"Title", msg}
 	for _, value := range params {
-		valStr := fmt.Sprintf("%v", value)
-		valStr = strings.ReplaceAll(valStr, "\"", "'")
-		valStr = strings.ReplaceAll(valStr, "\n", "\\n")
-		slice = append(slice, valStr)
+		slice = append(slice, fmt.Sprintf("%v", value))
 	}
 	//slice = append(slice, "Now", util.UnixToSimpleStr(time.Now().Unix()))
 	slice = append(slice, "SinceStart", time.Since(START_TIME).String())
 
 	var b strings.Builder
 	for i := 0; i < len(slice); i += 2 {
-		b.WriteString(fmt.Sprintf("%s: %s\\n", slice[i], slice[i+1]))
+		b.WriteString(fmt.Sprintf("%s: %s\n", slice[i], slice[i+1]))
 	}
 	return b.String(), nil
 }
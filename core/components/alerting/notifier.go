@@ -0,0 +1,178 @@
+package alerting
+
+import (
+	"fmt"
+	"gopkg.in/ini.v1"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Notifier an alerting backend, messages may be batched until Flush
+type Notifier interface {
+	Notify(severity Severity, msg string, params ...interface{}) error
+	Flush()
+	// Close flushes and stops the notifier
+	Close()
+}
+
+// NotifierFactory creates the notifier from its [alerting.<name>] section
+type NotifierFactory func(sec *ini.Section) (Notifier, error)
+
+var (
+	factories = make(map[string]NotifierFactory)
+
+	routesM sync.RWMutex
+	routes  []*route
+)
+
+// route alerts of minSeverity and above to the notifier, e.g. only critical alerts to paging channels
+type route struct {
+	name        string
+	notifier    Notifier
+	minSeverity Severity
+}
+
+// RegisterNotifier registers a backend, enabled by key enabled of section [alerting.<name>]
+func RegisterNotifier(name string, factory NotifierFactory) {
+	factories[name] = factory
+}
+
+// ReadAlertingConfig replaces the notifiers by those enabled in cfg, previous notifiers are closed.
+// None is created when [alerting] is disabled. Dedupe settings are read from [alerting],
+// min_severity of a backend falls back to [alerting] as well.
+func ReadAlertingConfig(cfg *ini.File) error {
+	sec := cfg.Section("alerting")
+	defaultDeduper.Configure(sec.Key("dedupe_window").MustDuration(time.Minute), sec.Key("rate_limit").MustInt(10),
+		sec.Key("rate_period").MustDuration(time.Hour))
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var created []*route
+	closeAll := func(routes []*route) {
+		for _, r := range routes {
+			r.notifier.Close()
+		}
+	}
+	enabled := sec.Key("enabled").MustBool(true)
+	for _, name := range names {
+		sec := cfg.Section("alerting." + name)
+		if !enabled || !isBackendEnabled(name, sec) {
+			continue
+		}
+		minSeverity, err := ParseSeverity(sec.Key("min_severity").MustString(SeverityInfo.String()))
+		if err != nil {
+			closeAll(created)
+			return fmt.Errorf("invalid alerting backend %s: %w", name, err)
+		}
+		notifier, err := factories[name](sec)
+		if err != nil {
+			closeAll(created)
+			return fmt.Errorf("invalid alerting backend %s: %w", name, err)
+		}
+		created = append(created, &route{name: name, notifier: notifier, minSeverity: minSeverity})
+		lg.Info("Alerting backend enabled", "backend", name, "minSeverity", minSeverity)
+	}
+
+	routesM.Lock()
+	previous := routes
+	routes = created
+	routesM.Unlock()
+	closeAll(previous)
+	return nil
+}
+
+// isBackendEnabled ignores enabled inherited from [alerting], only telegram is enabled by default
+func isBackendEnabled(name string, sec *ini.Section) bool {
+	for _, key := range sec.KeyStrings() {
+		if key == "enabled" {
+			return sec.Key(key).MustBool(false)
+		}
+	}
+	return name == "telegram"
+}
+
+func getRoutes() []*route {
+	routesM.RLock()
+	defer routesM.RUnlock()
+	return routes
+}
+
+// batcher caches messages of a notifier and sends them grouped by type every interval or on flush
+type batcher struct {
+	lg       log.Logger
+	interval time.Duration
+	send     func(severity Severity, msgs []string) error
+
+	msgMu    sync.Mutex
+	msgCache []*Msg
+	stopC    chan struct{}
+	stopOnce sync.Once
+}
+
+func newBatcher(lg log.Logger, interval time.Duration, send func(severity Severity, msgs []string) error) *batcher {
+	b := &batcher{
+		lg:       lg,
+		interval: interval,
+		send:     send,
+		stopC:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *batcher) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.stopC:
+			return
+		}
+	}
+}
+
+func (b *batcher) add(severity Severity, msg string, params ...interface{}) error {
+	message, err := buildContent(msg, params...)
+	if err != nil {
+		b.lg.Error("buildContent error", "msg", msg, "params", params)
+		message = fmt.Sprintf("buildContent error, msg: %s, severity: %s", msg, severity)
+	}
+	b.msgMu.Lock()
+	defer b.msgMu.Unlock()
+	b.msgCache = append(b.msgCache, &Msg{severity: severity, data: message})
+	return err
+}
+
+func (b *batcher) flush() {
+	b.msgMu.Lock()
+	defer b.msgMu.Unlock()
+
+	grouped := make(map[Severity][]string)
+	for _, msg := range b.msgCache {
+		grouped[msg.severity] = append(grouped[msg.severity], msg.data)
+	}
+	for _, severity := range severities {
+		if len(grouped[severity]) == 0 {
+			continue
+		}
+		if err := b.send(severity, grouped[severity]); err != nil {
+			b.lg.Error("Failed to send alerts", "severity", severity, "count", len(grouped[severity]), "err", err)
+		}
+	}
+	b.msgCache = b.msgCache[0:0]
+}
+
+func (b *batcher) close() {
+	b.stopOnce.Do(func() {
+		close(b.stopC)
+		b.flush()
+	})
+}
@@ -0,0 +1,112 @@
+package alerting
+
+import (
+	"encoding/json"
+	"gopkg.in/ini.v1"
+	"io/ioutil"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatcherGroupsByType(t *testing.T) {
+	sent := make(map[Severity][]string)
+	b := newBatcher(log.New("test"), time.Hour, func(severity Severity, msgs []string) error {
+		sent[severity] = append(sent[severity], msgs...)
+		return nil
+	})
+	defer b.close()
+	_ = b.add(SeverityInfo, "started")
+	_ = b.add(SeverityError, "order failed", "symbol", "INJUSDT")
+	_ = b.add(SeverityInfo, "filled")
+	if len(sent) != 0 {
+		t.Fatalf("sent before flush: %v", sent)
+	}
+	b.flush()
+	if len(sent[SeverityInfo]) != 2 || len(sent[SeverityError]) != 1 {
+		t.Fatalf("unexpected batches: %v", sent)
+	}
+	if !strings.Contains(sent[SeverityError][0], "symbol: INJUSDT\n") {
+		t.Fatalf("unexpected content: %q", sent[SeverityError][0])
+	}
+	if err := b.add(SeverityInfo, "odd", "key"); err == nil {
+		t.Fatal("odd params should fail")
+	}
+}
+
+func TestReadAlertingConfig(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var v map[string]interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			t.Errorf("invalid body %s: %v", body, err)
+		}
+		bodies = append(bodies, v)
+	}))
+	defer server.Close()
+
+	cfg, err := ini.Load([]byte(`
+[alerting]
+enabled = true
+[alerting.telegram]
+enabled = false
+[alerting.webhook]
+enabled = true
+url = ` + server.URL + `
+template = {"level": {{json .Level}}, "count": {{len .Messages}}}
+flush_interval = 1h
+min_severity = error
+[alerting.slack]
+enabled = true
+min_severity = error
+url = ` + server.URL + `
+channel = alerts
+flush_interval = 1h
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadAlertingConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ReadAlertingConfig(ini.Empty()) }()
+	if n := len(getRoutes()); n != 2 {
+		t.Fatalf("expected webhook and slack, got %d routes", n)
+	}
+
+	deliver(Alert{Severity: SeverityWarning, Msg: "depth is stale"})
+	deliver(Alert{Severity: SeverityError, Msg: "order failed"})
+	deliver(Alert{Severity: SeverityError, Msg: "order failed again"})
+	flush()
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 posts of errors only, got %v", bodies)
+	}
+	for _, body := range bodies {
+		switch {
+		case body["level"] == "error":
+			if body["count"] != float64(2) {
+				t.Fatalf("unexpected webhook body: %v", body)
+			}
+		case body["channel"] == "alerts":
+			if !strings.HasPrefix(body["text"].(string), "*ERROR*\n```\nTitle: order failed\n") {
+				t.Fatalf("unexpected slack body: %v", body)
+			}
+		default:
+			t.Fatalf("unexpected body: %v", body)
+		}
+	}
+
+	cfg.Section("alerting.webhook").Key("template").SetValue("{{json .Missing")
+	if err := ReadAlertingConfig(cfg); err == nil {
+		t.Fatal("invalid template should fail")
+	}
+	cfg.Section("alerting.webhook").Key("template").SetValue("{}")
+	cfg.Section("alerting.slack").Key("min_severity").SetValue("fatal")
+	if err := ReadAlertingConfig(cfg); err == nil {
+		t.Fatal("invalid severity should fail")
+	}
+}
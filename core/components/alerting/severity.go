@@ -0,0 +1,47 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity of an alert, backends are routed by min_severity
+type Severity int
+
+const (
+	SeverityInfo     Severity = 1
+	SeverityWarning  Severity = 2
+	SeverityError    Severity = 3
+	SeverityCritical Severity = 4
+)
+
+var severities = []Severity{SeverityInfo, SeverityWarning, SeverityError, SeverityCritical}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseSeverity(s string) (Severity, error) {
+	for _, severity := range severities {
+		if strings.EqualFold(s, severity.String()) {
+			return severity, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q", s)
+}
+
+type Msg struct {
+	severity Severity
+	data     string
+}
@@ -0,0 +1,76 @@
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/ini.v1"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/util/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier("slack", newSlackNotifier)
+}
+
+// SlackNotifier posts to a Slack-compatible incoming webhook, like Slack, Mattermost or Rocket.Chat
+type SlackNotifier struct {
+	lg       log.Logger
+	url      string
+	channel  string
+	username string
+	*batcher
+}
+
+type slackPayload struct {
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+	Text     string `json:"text"`
+}
+
+func newSlackNotifier(sec *ini.Section) (Notifier, error) {
+	url := sec.Key("url").String()
+	if url == "" {
+		return nil, errors.New("url is required")
+	}
+	n := &SlackNotifier{
+		lg:       log.New("alerting.notifier.slack"),
+		url:      url,
+		channel:  sec.Key("channel").String(),
+		username: sec.Key("username").String(),
+	}
+	n.batcher = newBatcher(n.lg, sec.Key("flush_interval").MustDuration(3*time.Second), n.send)
+	return n, nil
+}
+
+func (n *SlackNotifier) Notify(severity Severity, msg string, params ...interface{}) error {
+	return n.add(severity, msg, params...)
+}
+
+func (n *SlackNotifier) Flush() {
+	n.flush()
+}
+
+func (n *SlackNotifier) Close() {
+	n.close()
+}
+
+// slackText the level in bold, messages in a code block
+func slackText(severity Severity, msgs []string) string {
+	return fmt.Sprintf("*%s*\n```\n%s```", strings.ToUpper(severity.String()), strings.Join(msgs, "\n"))
+}
+
+func (n *SlackNotifier) send(severity Severity, msgs []string) error {
+	body, err := json.Marshal(&slackPayload{
+		Channel:  n.channel,
+		Username: n.username,
+		Text:     slackText(severity, msgs),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = http.PostJson(n.url, string(body))
+	return err
+}
@@ -0,0 +1,258 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tgPollTimeout     = 30 * time.Second
+	tgRetryInterval   = 5 * time.Second
+	tgMaxMessageRunes = 4000
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         "TelegramBotService",
+		Instance:     &TelegramBotService{},
+		InitPriority: registry.Low,
+	})
+}
+
+// TelegramBotService long-polls getUpdates and serves commands of allow-listed chats,
+// commands are dispatched on bus to the services owning the data.
+type TelegramBotService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	client  *http.Client
+	allowed map[int64]bool
+	offset  int64
+	// commands sent before the bot started are not executed, they may be stale by hours
+	started time.Time
+}
+
+type tgUpdate struct {
+	UpdateID int64      `json:"update_id"`
+	Message  *tgMessage `json:"message"`
+}
+
+type tgMessage struct {
+	Date int64  `json:"date"`
+	Text string `json:"text"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	From struct {
+		Username string `json:"username"`
+	} `json:"from"`
+}
+
+type tgUpdatesResponse struct {
+	Ok          bool       `json:"ok"`
+	Description string     `json:"description"`
+	Result      []tgUpdate `json:"result"`
+}
+
+func (s *TelegramBotService) Init() error {
+	s.lg = log.New("alerting.bot.tg")
+	s.client = &http.Client{Timeout: tgPollTimeout + 10*time.Second}
+	s.allowed = make(map[int64]bool)
+	for _, chat := range setting.TgAllowedChats {
+		id, err := strconv.ParseInt(strings.TrimSpace(chat), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid telegram chat id %q: %w", chat, err)
+		}
+		s.allowed[id] = true
+	}
+	if len(s.allowed) == 0 {
+		s.lg.Warn("no telegram chat is allowed, every command is ignored")
+	}
+	return nil
+}
+
+func (s *TelegramBotService) IsDisabled() bool {
+	return !setting.TgCommandsEnabled || setting.TgToken == ""
+}
+
+func (s *TelegramBotService) Run(ctx context.Context) error {
+	s.started = time.Now()
+	s.dropBacklog(ctx)
+	for {
+		updates, err := s.getUpdates(ctx, tgPollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				s.lg.Info("Stopped")
+				return nil
+			}
+			s.lg.Error("failed to get telegram updates", "err", err)
+			select {
+			case <-ctx.Done():
+				s.lg.Info("Stopped")
+				return nil
+			case <-time.After(tgRetryInterval):
+			}
+			continue
+		}
+		for _, update := range updates {
+			s.offset = update.UpdateID + 1
+			if update.Message != nil {
+				s.handle(update.Message)
+			}
+		}
+	}
+}
+
+// dropBacklog confirms updates received while the bot was down, offset -1 returns the last one only.
+// Old messages are still ignored by date if this fails.
+func (s *TelegramBotService) dropBacklog(ctx context.Context) {
+	s.offset = -1
+	updates, err := s.getUpdates(ctx, 0)
+	s.offset = 0
+	if err != nil {
+		s.lg.Warn("failed to drop telegram backlog", "err", err)
+		return
+	}
+	if len(updates) > 0 {
+		s.offset = updates[len(updates)-1].UpdateID + 1
+		s.lg.Info("telegram backlog dropped", "offset", s.offset)
+	}
+}
+
+func (s *TelegramBotService) getUpdates(ctx context.Context, timeout time.Duration) ([]tgUpdate, error) {
+	url := fmt.Sprintf("%s/bot%s/getUpdates?timeout=%d&offset=%d", tgDomain, setting.TgToken,
+		int(timeout.Seconds()), s.offset)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var res tgUpdatesResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	if !res.Ok {
+		return nil, fmt.Errorf("getUpdates failed: %s", res.Description)
+	}
+	return res.Result, nil
+}
+
+func (s *TelegramBotService) handle(msg *tgMessage) {
+	if !s.allowed[msg.Chat.ID] {
+		s.lg.Warn("command from chat not allowed is ignored", "chat", msg.Chat.ID, "from", msg.From.Username)
+		return
+	}
+	fields := strings.Fields(msg.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return
+	}
+	if msg.Date < s.started.Unix() {
+		s.lg.Warn("command sent before start is ignored", "chat", msg.Chat.ID, "from", msg.From.Username,
+			"command", msg.Text, "sent", time.Unix(msg.Date, 0))
+		return
+	}
+	// commands in groups are suffixed by the bot name, like /status@bot
+	name := strings.SplitN(strings.TrimPrefix(fields[0], "/"), "@", 2)[0]
+	s.lg.Info("telegram command received", "chat", msg.Chat.ID, "from", msg.From.Username, "command", msg.Text)
+
+	text := s.execute(name, fields[1:], msg.From.Username)
+	if err := s.sendReply(msg.Chat.ID, text); err != nil {
+		s.lg.Error("failed to reply telegram command", "command", name, "err", err)
+	}
+}
+
+// execute dispatches the command on bus, and returns the reply text
+func (s *TelegramBotService) execute(name string, args []string, from string) string {
+	var cmd interface{}
+	var reply *CommandReply
+	switch name {
+	case "status":
+		c := &StatusCommand{}
+		cmd, reply = c, &c.Reply
+	case "balances":
+		c := &BalancesCommand{}
+		cmd, reply = c, &c.Reply
+	case "orders":
+		c := &OrdersCommand{}
+		cmd, reply = c, &c.Reply
+	case "pause":
+		reason := strings.Join(args, " ")
+		if reason == "" {
+			reason = "paused by telegram"
+		}
+		c := &PauseCommand{Reason: fmt.Sprintf("%s (by %s)", reason, from)}
+		cmd, reply = c, &c.Reply
+	case "resume":
+		c := &ResumeCommand{}
+		cmd, reply = c, &c.Reply
+	case "cancelall":
+		if len(args) != 1 {
+			return "usage: /cancelall <exchange>"
+		}
+		c := &CancelAllCommand{Exchange: args[0]}
+		cmd, reply = c, &c.Reply
+	case "spread":
+		if len(args) != 1 {
+			return "usage: /spread <symbol>"
+		}
+		c := &SpreadCommand{Symbol: args[0]}
+		cmd, reply = c, &c.Reply
+	default:
+		return "commands: /status /balances /orders /pause [reason] /resume /cancelall <exchange> /spread <symbol>"
+	}
+
+	if err := s.Bus.Dispatch(cmd); err != nil {
+		if errors.Is(err, bus.ErrHandlerNotFound) {
+			return fmt.Sprintf("/%s is not available, its service is disabled", name)
+		}
+		return fmt.Sprintf("/%s failed: %v", name, err)
+	}
+	return reply.Text()
+}
+
+// sendReply the text is sent preformatted to keep tables aligned, and truncated to the limit of telegram
+func (s *TelegramBotService) sendReply(chatID int64, text string) error {
+	if runes := []rune(text); len(runes) > tgMaxMessageRunes {
+		text = string(runes[:tgMaxMessageRunes]) + "\n..."
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":    chatID,
+		"text":       "<pre>" + html.EscapeString(text) + "</pre>",
+		"parse_mode": "HTML",
+	})
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/bot%s/sendMessage", tgDomain, setting.TgToken)
+	resp, err := s.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		res, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("sendMessage failed: %s", res)
+	}
+	return nil
+}
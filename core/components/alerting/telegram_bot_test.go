@@ -0,0 +1,15 @@
+package alerting
+
+import (
+	"jasonzhu.com/coin_labor/core/components/log"
+	"testing"
+	"time"
+)
+
+func TestTelegramBotIgnoresCommandsBeforeStart(t *testing.T) {
+	s := &TelegramBotService{lg: log.New("test"), allowed: map[int64]bool{1: true}, started: time.Now()}
+	msg := &tgMessage{Date: s.started.Add(-time.Hour).Unix(), Text: "/pause"}
+	msg.Chat.ID = 1
+	// executing it would dispatch on the nil bus and reply over the network
+	s.handle(msg)
+}
@@ -0,0 +1,103 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/ini.v1"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/setting"
+	"jasonzhu.com/coin_labor/core/util/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const defaultWebhookTemplate = `{"app": {{json .App}}, "level": {{json .Level}}, "time": {{json .Time}}, "text": {{json .Text}}}`
+
+func init() {
+	RegisterNotifier("webhook", newWebhookNotifier)
+}
+
+// WebhookData is rendered by the template of WebhookNotifier, one per batch of the same level
+type WebhookData struct {
+	App      string
+	Level    string
+	Time     time.Time
+	Messages []string
+	// Text messages joined by blank lines
+	Text string
+}
+
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// WebhookNotifier posts every batch as JSON rendered by a text/template, use {{json .Field}} to quote values
+type WebhookNotifier struct {
+	lg  log.Logger
+	url string
+	tpl *template.Template
+	*batcher
+}
+
+func newWebhookNotifier(sec *ini.Section) (Notifier, error) {
+	url := sec.Key("url").String()
+	if url == "" {
+		return nil, errors.New("url is required")
+	}
+	tpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(sec.Key("template").MustString(defaultWebhookTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	n := &WebhookNotifier{lg: log.New("alerting.notifier.webhook"), url: url, tpl: tpl}
+	n.batcher = newBatcher(n.lg, sec.Key("flush_interval").MustDuration(3*time.Second), n.send)
+	return n, nil
+}
+
+func (n *WebhookNotifier) Notify(severity Severity, msg string, params ...interface{}) error {
+	return n.add(severity, msg, params...)
+}
+
+func (n *WebhookNotifier) Flush() {
+	n.flush()
+}
+
+func (n *WebhookNotifier) Close() {
+	n.close()
+}
+
+func newWebhookData(severity Severity, msgs []string) *WebhookData {
+	return &WebhookData{
+		App:      setting.ApplicationName,
+		Level:    severity.String(),
+		Time:     time.Now(),
+		Messages: msgs,
+		Text:     strings.Join(msgs, "\n"),
+	}
+}
+
+// render the body must be valid JSON
+func (n *WebhookNotifier) render(data *WebhookData) ([]byte, error) {
+	var b bytes.Buffer
+	if err := n.tpl.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	if !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("template rendered invalid JSON: %s", b.String())
+	}
+	return b.Bytes(), nil
+}
+
+func (n *WebhookNotifier) send(severity Severity, msgs []string) error {
+	body, err := n.render(newWebhookData(severity, msgs))
+	if err != nil {
+		return err
+	}
+	_, err = http.PostJson(n.url, string(body))
+	return err
+}
@@ -0,0 +1,171 @@
+package bus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"jasonzhu.com/coin_labor/core/components/metrics"
+	"sync"
+	"sync/atomic"
+)
+
+// Policy is what Publish does when the queue of a subscriber is full
+type Policy int
+
+const (
+	// PolicyDrop drops the oldest queued message, for market data where only the latest matters
+	PolicyDrop Policy = iota
+	// PolicyBlock waits until the subscriber takes a message, slow subscribers slow down the publisher
+	PolicyBlock
+)
+
+func (p Policy) String() string {
+	if p == PolicyBlock {
+		return "block"
+	}
+	return "drop"
+}
+
+// Topic typed messages delivered to every subscriber through its own bounded queue, without reflection.
+// Unlike Publish of Bus, a slow subscriber of PolicyDrop never blocks the publisher.
+type Topic[T any] struct {
+	name string
+	rwM  sync.RWMutex
+	subs []*Subscription[T]
+}
+
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+// Subscribe with a queue of size messages, name labels the lag metrics of the subscriber
+func (t *Topic[T]) Subscribe(name string, size int, policy Policy) *Subscription[T] {
+	if size < 1 {
+		size = 1
+	}
+	s := &Subscription[T]{
+		topic:     t,
+		name:      name,
+		policy:    policy,
+		c:         make(chan T, size),
+		closed:    make(chan struct{}),
+		queued:    metrics.M_Coin_Bus_Queue_Length.WithLabelValues(t.name, name),
+		delivered: metrics.M_Coin_Bus_Delivered_Total.WithLabelValues(t.name, name),
+		dropped:   metrics.M_Coin_Bus_Dropped_Total.WithLabelValues(t.name, name),
+	}
+	t.rwM.Lock()
+	t.subs = append(t.subs, s)
+	t.rwM.Unlock()
+	return s
+}
+
+// Publish to every subscriber, it returns after the message is queued or dropped for each of them
+func (t *Topic[T]) Publish(msg T) {
+	t.rwM.RLock()
+	defer t.rwM.RUnlock()
+	for _, s := range t.subs {
+		s.send(msg)
+	}
+}
+
+func (t *Topic[T]) unsubscribe(s *Subscription[T]) {
+	t.rwM.Lock()
+	defer t.rwM.Unlock()
+	for i, sub := range t.subs {
+		if sub == s {
+			t.subs = append(t.subs[:i:i], t.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+type Subscription[T any] struct {
+	topic  *Topic[T]
+	name   string
+	policy Policy
+	c      chan T
+
+	// sendM serializes dropping and queueing of concurrent publishers
+	sendM     sync.Mutex
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	queued       prometheus.Gauge
+	delivered    prometheus.Counter
+	dropped      prometheus.Counter
+	droppedCount int64
+}
+
+// C is closed after Close
+func (s *Subscription[T]) C() <-chan T {
+	return s.c
+}
+
+// Len messages queued, the lag of the subscriber
+func (s *Subscription[T]) Len() int {
+	return len(s.c)
+}
+
+// Dropped messages since Subscribe, always 0 for PolicyBlock
+func (s *Subscription[T]) Dropped() int64 {
+	return atomic.LoadInt64(&s.droppedCount)
+}
+
+func (s *Subscription[T]) send(msg T) {
+	defer func() { s.queued.Set(float64(len(s.c))) }()
+	if s.policy == PolicyBlock {
+		select {
+		case s.c <- msg:
+			s.delivered.Inc()
+		case <-s.closed:
+		}
+		return
+	}
+
+	s.sendM.Lock()
+	defer s.sendM.Unlock()
+	for {
+		select {
+		case s.c <- msg:
+			s.delivered.Inc()
+			return
+		case <-s.closed:
+			return
+		default:
+		}
+		select {
+		case <-s.c:
+			s.dropped.Inc()
+			atomic.AddInt64(&s.droppedCount, 1)
+		default:
+		}
+	}
+}
+
+// Listen calls handler for every message until ctx is done or the subscription is closed
+func (s *Subscription[T]) Listen(ctx context.Context, handler func(T)) {
+	for {
+		select {
+		case msg, ok := <-s.c:
+			if !ok {
+				return
+			}
+			handler(msg)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close unsubscribes, publishers blocked by the subscription are released
+func (s *Subscription[T]) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.topic.unsubscribe(s)
+		close(s.c)
+		s.queued.Set(0)
+	})
+}
@@ -0,0 +1,90 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTopicDrop(t *testing.T) {
+	topic := NewTopic[int]("test_drop")
+	slow := topic.Subscribe("slow", 2, PolicyDrop)
+	fast := topic.Subscribe("fast", 10, PolicyDrop)
+	for i := 1; i <= 5; i++ {
+		topic.Publish(i)
+	}
+	// the oldest are dropped, the latest are kept
+	if slow.Len() != 2 || <-slow.C() != 4 || <-slow.C() != 5 {
+		t.Fatal("unexpected messages of the slow subscriber")
+	}
+	if fast.Len() != 5 {
+		t.Fatalf("unexpected lag of the fast subscriber: %d", fast.Len())
+	}
+	if slow.Dropped() != 3 || fast.Dropped() != 0 {
+		t.Fatalf("unexpected dropped: %d, %d", slow.Dropped(), fast.Dropped())
+	}
+
+	slow.Close()
+	slow.Close()
+	if _, ok := <-slow.C(); ok {
+		t.Fatal("queue should be closed")
+	}
+	topic.Publish(6)
+	if fast.Len() != 6 {
+		t.Fatal("subscribers left should still receive")
+	}
+}
+
+func TestTopicBlock(t *testing.T) {
+	topic := NewTopic[string]("test_block")
+	sub := topic.Subscribe("block", 1, PolicyBlock)
+	topic.Publish("a")
+
+	published := make(chan struct{})
+	go func() {
+		topic.Publish("b")
+		close(published)
+	}()
+	select {
+	case <-published:
+		t.Fatal("publish should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	var received []string
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sub.Listen(ctx, func(msg string) {
+			received = append(received, msg)
+			if len(received) == 2 {
+				cancel()
+			}
+		})
+		close(done)
+	}()
+	<-published
+	<-done
+	if len(received) != 2 || received[0] != "a" || received[1] != "b" {
+		t.Fatalf("unexpected messages: %v", received)
+	}
+}
+
+func TestTopicCloseReleasesPublisher(t *testing.T) {
+	topic := NewTopic[int]("test_close")
+	sub := topic.Subscribe("block", 1, PolicyBlock)
+	topic.Publish(1)
+
+	published := make(chan struct{})
+	go func() {
+		topic.Publish(2)
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sub.Close()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publisher is not released by close")
+	}
+}
@@ -0,0 +1,257 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentPrefix = "journal-"
+	segmentSuffix = ".jsonl"
+
+	DefaultSegmentSize = 64 << 20
+)
+
+var (
+	ErrCorrupted = errors.New("journal is corrupted")
+	ErrClosed    = errors.New("journal is closed")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// Record one line of a segment, Seq increases by 1 from 1 across segments
+type Record struct {
+	Seq  uint64          `json:"seq"`
+	Time int64           `json:"time"` // unix nano
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+	CRC  uint32          `json:"crc"`
+}
+
+func (r *Record) checksum() uint32 {
+	crc := crc32.Checksum([]byte(fmt.Sprintf("%d %d %s ", r.Seq, r.Time, r.Type)), crcTable)
+	return crc32.Update(crc, crcTable, r.Data)
+}
+
+func (r *Record) At() time.Time {
+	return time.Unix(0, r.Time)
+}
+
+// Decode the data into the event type of the record
+func (r *Record) Decode(v interface{}) error {
+	return json.Unmarshal(r.Data, v)
+}
+
+type Options struct {
+	// SegmentSize a new segment is started when the current one reaches it
+	SegmentSize int64
+	// Sync fsyncs every record, otherwise segments are synced when rotated and closed
+	Sync bool
+}
+
+// Journal appends records to segments in dir named by the seq of their first record,
+// e.g. journal-00000000000000000001.jsonl. Segments are never modified once rotated.
+type Journal struct {
+	lg   log.Logger
+	dir  string
+	opts Options
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	seq  uint64
+	err  error // a failed write leaves a torn line, appending stops until reopened
+}
+
+// Open recovers the last seq, a torn record at the tail of the last segment left by a crash is truncated
+func Open(dir string, opts Options) (*Journal, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	j := &Journal{lg: log.New("journal"), dir: dir, opts: opts}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return j, nil
+	}
+
+	last := segments[len(segments)-1]
+	lastSeq, offset, err := scanSegment(last, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(last.path, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil {
+		f.Close()
+		return nil, err
+	} else if info.Size() > offset {
+		j.lg.Warn("torn record truncated", "segment", last.path, "size", info.Size(), "offset", offset)
+		if err := f.Truncate(offset); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if _, err := f.Seek(offset, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	j.f, j.size, j.seq = f, offset, lastSeq
+	return j, nil
+}
+
+// Seq of the last record appended
+func (j *Journal) Seq() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.seq
+}
+
+// Append the event as json data of a new record, returns its seq
+func (j *Journal) Append(typ string, event interface{}) (uint64, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	return j.AppendRaw(typ, data, time.Now())
+}
+
+func (j *Journal) AppendRaw(typ string, data json.RawMessage, t time.Time) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err != nil {
+		return 0, j.err
+	}
+	if j.f == nil || j.size >= j.opts.SegmentSize {
+		if err := j.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	record := &Record{Seq: j.seq + 1, Time: t.UnixNano(), Type: typ, Data: data}
+	record.CRC = record.checksum()
+	line, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	n, err := j.f.Write(append(line, '\n'))
+	j.size += int64(n)
+	if err != nil {
+		j.err = fmt.Errorf("journal write failed: %w", err)
+		return 0, j.err
+	}
+	if j.opts.Sync {
+		if err := j.f.Sync(); err != nil {
+			j.err = fmt.Errorf("journal sync failed: %w", err)
+			return 0, j.err
+		}
+	}
+	j.seq = record.Seq
+	return record.Seq, nil
+}
+
+func (j *Journal) rotate() error {
+	if j.f != nil {
+		if err := j.f.Sync(); err != nil {
+			return err
+		}
+		if err := j.f.Close(); err != nil {
+			return err
+		}
+		j.f = nil
+	}
+	f, err := os.OpenFile(segmentPath(j.dir, j.seq+1), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	j.f, j.size = f, 0
+	return nil
+}
+
+// Close syncs the current segment
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err == ErrClosed {
+		return nil
+	}
+	j.err = ErrClosed
+	if j.f == nil {
+		return nil
+	}
+	if err := j.f.Sync(); err != nil {
+		j.f.Close()
+		return err
+	}
+	return j.f.Close()
+}
+
+type segment struct {
+	path     string
+	firstSeq uint64
+}
+
+func segmentPath(dir string, firstSeq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%020d%s", segmentPrefix, firstSeq, segmentSuffix))
+}
+
+// listSegments sorted by seq
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		firstSeq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64)
+		if err != nil || firstSeq == 0 {
+			return nil, fmt.Errorf("%w: unexpected segment %s", ErrCorrupted, name)
+		}
+		segments = append(segments, segment{path: filepath.Join(dir, name), firstSeq: firstSeq})
+	}
+	sort.Slice(segments, func(i, k int) bool { return segments[i].firstSeq < segments[k].firstSeq })
+	return segments, nil
+}
+
+// scanSegment returns the seq of the last valid record and the offset after it.
+// With tornTail, an invalid last line is not an error, it is left by a crash while writing.
+func scanSegment(seg segment, tornTail bool) (uint64, int64, error) {
+	r, err := openSegment(seg, seg.firstSeq-1, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer r.close()
+	for {
+		_, err := r.next()
+		if err == nil {
+			continue
+		}
+		if err == errEndOfSegment {
+			return r.lastSeq, r.offset, nil
+		}
+		if tornTail && errors.Is(err, ErrCorrupted) && r.atEnd() {
+			return r.lastSeq, r.offset, nil
+		}
+		return 0, 0, err
+	}
+}
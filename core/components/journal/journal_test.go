@@ -0,0 +1,164 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	N int `json:"n"`
+}
+
+func readAll(t *testing.T, dir string, filter Filter) []*Record {
+	t.Helper()
+	r, err := NewReader(dir, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var records []*Record
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, Options{SegmentSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 10; i++ {
+		typ := "Even"
+		if i%2 == 1 {
+			typ = "Odd"
+		}
+		if seq, err := j.Append(typ, &testEvent{N: i}); err != nil || seq != uint64(i) {
+			t.Fatalf("unexpected seq %d, %v", seq, err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Append("Odd", &testEvent{}); err != ErrClosed {
+		t.Fatalf("append after close: %v", err)
+	}
+	segments, _ := listSegments(dir)
+	if len(segments) < 3 {
+		t.Fatalf("segments are not rotated: %d", len(segments))
+	}
+
+	records := readAll(t, dir, Filter{})
+	if len(records) != 10 {
+		t.Fatalf("unexpected records: %d", len(records))
+	}
+	var event testEvent
+	if err := records[6].Decode(&event); err != nil || event.N != 7 || records[6].Type != "Odd" {
+		t.Fatalf("unexpected record: %+v", records[6])
+	}
+
+	records = readAll(t, dir, Filter{FromSeq: 4, ToSeq: 8, Types: []string{"Even"}})
+	if len(records) != 3 || records[0].Seq != 4 || records[2].Seq != 8 {
+		t.Fatalf("unexpected filtered records: %d", len(records))
+	}
+	if records := readAll(t, dir, Filter{Since: time.Now().Add(time.Minute)}); len(records) != 0 {
+		t.Fatal("records of the future")
+	}
+
+	// seq continues after reopen
+	j, err = Open(dir, Options{SegmentSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if seq, err := j.Append("Odd", &testEvent{N: 11}); err != nil || seq != 11 {
+		t.Fatalf("unexpected seq %d after reopen, %v", seq, err)
+	}
+}
+
+func TestJournalTornTail(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := j.Append("Event", &testEvent{N: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	// a crash while writing the 4th record
+	path := segmentPath(dir, 1)
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(`{"seq":4,"time":1,"ty`)
+	f.Close()
+	if records := readAll(t, dir, Filter{}); len(records) != 3 {
+		t.Fatalf("unfinished record should not be read: %d", len(records))
+	}
+
+	j, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq, err := j.Append("Event", &testEvent{N: 4}); err != nil || seq != 4 {
+		t.Fatalf("unexpected seq %d, %v", seq, err)
+	}
+	j.Close()
+	if records := readAll(t, dir, Filter{}); len(records) != 4 {
+		t.Fatalf("unexpected records after recovery: %d", len(records))
+	}
+}
+
+func TestJournalCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := j.Append("Event", &testEvent{N: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	path := segmentPath(dir, 1)
+	b, _ := ioutil.ReadFile(path)
+	if err := ioutil.WriteFile(path, bytes.Replace(b, []byte(`{"n":2}`), []byte(`{"n":9}`), 1), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := NewReader(dir, Filter{})
+	defer r.Close()
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("tampered record is not detected: %v", err)
+	}
+	// only a torn tail is recovered, a corrupted record in the middle is kept for investigation
+	if _, err := Open(dir, Options{}); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("open should refuse a corrupted segment: %v", err)
+	}
+
+	if err := os.Rename(path, filepath.Join(dir, "journal-x.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewReader(dir, Filter{}); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("unexpected segment is not reported: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var errEndOfSegment = errors.New("end of segment")
+
+// Filter zero values are unbounded
+type Filter struct {
+	FromSeq uint64
+	ToSeq   uint64
+	Since   time.Time // inclusive
+	Until   time.Time // exclusive
+	Types   []string
+}
+
+func (f *Filter) Match(r *Record) bool {
+	if r.Seq < f.FromSeq || (f.ToSeq > 0 && r.Seq > f.ToSeq) {
+		return false
+	}
+	if (!f.Since.IsZero() && r.Time < f.Since.UnixNano()) || (!f.Until.IsZero() && r.Time >= f.Until.UnixNano()) {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, typ := range f.Types {
+		if typ == r.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Reader returns records matching the filter in seq order. Checksums and continuity of seq are verified,
+// ErrCorrupted is returned with the segment and line at the first violation.
+type Reader struct {
+	filter   Filter
+	segments []segment
+	i        int
+	cur      *segmentReader
+	lastSeq  uint64
+}
+
+func NewReader(dir string, filter Filter) (*Reader, error) {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{filter: filter, segments: segments}
+	// segments before the one holding FromSeq are skipped
+	for r.i+1 < len(segments) && segments[r.i+1].firstSeq <= filter.FromSeq {
+		r.i++
+	}
+	return r, nil
+}
+
+// Next returns io.EOF after the last record
+func (r *Reader) Next() (*Record, error) {
+	for {
+		if r.cur == nil {
+			if r.i >= len(r.segments) {
+				return nil, io.EOF
+			}
+			seg := r.segments[r.i]
+			if r.lastSeq != 0 && seg.firstSeq != r.lastSeq+1 {
+				return nil, fmt.Errorf("%w: %s starts at seq %d after %d", ErrCorrupted, seg.path, seg.firstSeq, r.lastSeq)
+			}
+			// the last segment may be written now, a line without newline is not finished yet
+			cur, err := openSegment(seg, seg.firstSeq-1, r.i == len(r.segments)-1)
+			if err != nil {
+				return nil, err
+			}
+			r.cur = cur
+		}
+		record, err := r.cur.next()
+		if err == errEndOfSegment {
+			r.lastSeq = r.cur.lastSeq
+			r.cur.close()
+			r.cur = nil
+			r.i++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if r.filter.ToSeq > 0 && record.Seq > r.filter.ToSeq {
+			return nil, io.EOF
+		}
+		if r.filter.Match(record) {
+			return record, nil
+		}
+	}
+}
+
+func (r *Reader) Close() error {
+	if r.cur != nil {
+		r.cur.close()
+		r.cur = nil
+	}
+	r.i = len(r.segments)
+	return nil
+}
+
+type segmentReader struct {
+	seg          segment
+	f            *os.File
+	br           *bufio.Reader
+	partialIsEnd bool
+	line         int
+	pos          int64  // after the last line read
+	offset       int64  // after the last valid record
+	lastSeq      uint64 // of the last valid record
+}
+
+func openSegment(seg segment, prevSeq uint64, partialIsEnd bool) (*segmentReader, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return nil, err
+	}
+	return &segmentReader{seg: seg, f: f, br: bufio.NewReader(f), partialIsEnd: partialIsEnd, lastSeq: prevSeq}, nil
+}
+
+func (r *segmentReader) next() (*Record, error) {
+	b, err := r.br.ReadBytes('\n')
+	if err == io.EOF {
+		if len(b) == 0 || r.partialIsEnd {
+			return nil, errEndOfSegment
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	r.line++
+	r.pos += int64(len(b))
+	if err == io.EOF {
+		return nil, r.corrupted("record is not terminated")
+	}
+
+	var record Record
+	if err := json.Unmarshal(b, &record); err != nil {
+		return nil, r.corrupted(err.Error())
+	}
+	if record.checksum() != record.CRC {
+		return nil, r.corrupted("checksum mismatch")
+	}
+	if record.Seq != r.lastSeq+1 {
+		return nil, r.corrupted(fmt.Sprintf("seq %d after %d", record.Seq, r.lastSeq))
+	}
+	r.lastSeq, r.offset = record.Seq, r.pos
+	return &record, nil
+}
+
+func (r *segmentReader) corrupted(reason string) error {
+	return fmt.Errorf("%w: %s line %d: %s", ErrCorrupted, r.seg.path, r.line, reason)
+}
+
+// atEnd true if nothing follows the line read
+func (r *segmentReader) atEnd() bool {
+	_, err := r.br.Peek(1)
+	return err == io.EOF
+}
+
+func (r *segmentReader) close() {
+	r.f.Close()
+}
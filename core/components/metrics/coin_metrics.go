@@ -16,6 +16,16 @@ var (
 	M_Coin_Market_Depth_Total       *CounterVec
 	M_Coin_Market_Latency_Summary   *SummaryVec
 	M_Coin_Market_Latency_Histogram *HistogramVec
+
+	M_Coin_Spread_Gross_Bps               *GaugeVec
+	M_Coin_Spread_Net_Bps                 *GaugeVec
+	M_Coin_Spread_Net_Bps_Histogram       *HistogramVec
+	M_Coin_Spread_Executable_Size         *GaugeVec
+	M_Coin_Opportunity_Duration_Histogram *HistogramVec
+
+	M_Coin_Bus_Queue_Length    *GaugeVec
+	M_Coin_Bus_Delivered_Total *CounterVec
+	M_Coin_Bus_Dropped_Total   *CounterVec
 )
 
 func init() {
@@ -126,4 +136,71 @@ func init() {
 		},
 		[]string{"exchange", "symbol", "type", "status"},
 	)
+
+	M_Coin_Spread_Gross_Bps = NewGaugeVec(
+		GaugeOpts{
+			Name: "coin_spread_gross_bps",
+			Help: "top bid of sell exchange over top ask of buy exchange, in bps",
+		},
+		[]string{"symbol", "buy_exchange", "sell_exchange"},
+	)
+
+	M_Coin_Spread_Net_Bps = NewGaugeVec(
+		GaugeOpts{
+			Name: "coin_spread_net_bps",
+			Help: "gross spread after taker fees of both legs, in bps",
+		},
+		[]string{"symbol", "buy_exchange", "sell_exchange"},
+	)
+
+	M_Coin_Spread_Net_Bps_Histogram = NewHistogramVec(
+		HistogramOpts{
+			Name: "coin_spread_net_bps_histogram",
+			Buckets: []float64{
+				-50, -20, -10, -5, 0, 5, 10, 20, 50, 100,
+			},
+		},
+		[]string{"symbol", "buy_exchange", "sell_exchange"},
+	)
+
+	M_Coin_Spread_Executable_Size = NewGaugeVec(
+		GaugeOpts{
+			Name: "coin_spread_executable_size",
+			Help: "base quantity crossing with net profit within the top levels",
+		},
+		[]string{"symbol", "buy_exchange", "sell_exchange"},
+	)
+
+	M_Coin_Opportunity_Duration_Histogram = NewHistogramVec(
+		HistogramOpts{
+			Name: "coin_opportunity_duration_second_histogram",
+			Buckets: []float64{
+				0.1, 0.5, 1, 3, 10, 30, 60, 300,
+			},
+		},
+		[]string{"symbol", "buy_exchange", "sell_exchange"},
+	)
+
+	M_Coin_Bus_Queue_Length = NewGaugeVec(
+		GaugeOpts{
+			Name: "coin_bus_queue_length",
+			Help: "messages queued for the subscriber of a topic, its lag behind the publishers",
+		},
+		[]string{"topic", "subscriber"},
+	)
+
+	M_Coin_Bus_Delivered_Total = NewCounterVec(
+		CounterOpts{
+			Name: "coin_bus_delivered_total",
+		},
+		[]string{"topic", "subscriber"},
+	)
+
+	M_Coin_Bus_Dropped_Total = NewCounterVec(
+		CounterOpts{
+			Name: "coin_bus_dropped_total",
+			Help: "messages dropped because the queue of the subscriber is full",
+		},
+		[]string{"topic", "subscriber"},
+	)
 }
@@ -102,6 +102,14 @@ func initAppMetricVars() {
 		M_Coin_Market_Depth_Total,
 		M_Coin_Market_Latency_Summary,
 		M_Coin_Market_Latency_Histogram,
+		M_Coin_Spread_Gross_Bps,
+		M_Coin_Spread_Net_Bps,
+		M_Coin_Spread_Net_Bps_Histogram,
+		M_Coin_Spread_Executable_Size,
+		M_Coin_Opportunity_Duration_Histogram,
+		M_Coin_Bus_Queue_Length,
+		M_Coin_Bus_Delivered_Total,
+		M_Coin_Bus_Dropped_Total,
 	)
 }
 
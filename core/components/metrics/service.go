@@ -2,25 +2,74 @@ package metrics
 
 import (
 	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"jasonzhu.com/coin_labor/core/components/log"
 	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	"net/http"
+	"runtime"
+	"time"
 )
 
 func init() {
 	registry.RegisterService(&InternalMetricsService{})
 	initMetricVars()
 	initAppMetricVars()
+	initDDBMetricVars()
 }
 
+// InternalMetricsService serves the default registry, go runtime and process collectors are registered by prometheus
 type InternalMetricsService struct {
+	lg     log.Logger
+	server *http.Server
 }
 
 func (s *InternalMetricsService) Init() error {
+	s.lg = log.New("service.metrics")
+	M_Pipe_Build_Version.WithLabelValues(setting.BuildVersion, setting.BuildCommit, setting.BuildBranch,
+		runtime.Version(), string(setting.Env)).Set(1)
+
+	if setting.MetricsEnabled {
+		mux := http.NewServeMux()
+		mux.Handle(setting.MetricsPath, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+			promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{ErrorLog: &promErrorLogger{s.lg}})))
+		s.server = &http.Server{
+			Addr:              setting.MetricsHttpAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
 	return nil
 }
 
 func (s *InternalMetricsService) Run(ctx context.Context) error {
 	M_Instance_Start.Inc()
 
+	if s.server != nil {
+		go func() {
+			s.lg.Info("metrics server listening", "addr", s.server.Addr, "path", setting.MetricsPath)
+			if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.lg.Error("metrics server stopped", "err", err)
+			}
+		}()
+	}
+
 	<-ctx.Done()
+	if s.server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.server.Shutdown(shutdownCtx)
+	}
 	return ctx.Err()
 }
+
+// promErrorLogger logs errors of gathering metrics
+type promErrorLogger struct {
+	lg log.Logger
+}
+
+func (l *promErrorLogger) Println(v ...interface{}) {
+	l.lg.Error("failed to serve metrics", "err", fmt.Sprint(v...))
+}
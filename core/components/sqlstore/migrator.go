@@ -0,0 +1,69 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Migration is applied once, in its own transaction, and recorded in migration_log by ID.
+// Applied migrations must never be changed, add a new one instead.
+type Migration struct {
+	ID  string
+	SQL []string
+}
+
+const createMigrationLog = `CREATE TABLE IF NOT EXISTS migration_log (
+	id         TEXT PRIMARY KEY,
+	applied_at DATETIME NOT NULL
+)`
+
+// Migrate applies migrations not applied yet in order, it stops at the first failure
+func (ss *SQLStore) Migrate(migrations []Migration) error {
+	ctx := context.Background()
+	if _, err := ss.db.ExecContext(ctx, createMigrationLog); err != nil {
+		return err
+	}
+	applied, err := ss.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if applied[m.ID] {
+			continue
+		}
+		err := ss.InTransaction(ctx, func(ctx context.Context) error {
+			return ss.WithDbSession(ctx, func(sess Session) error {
+				for _, statement := range m.SQL {
+					if _, err := sess.ExecContext(ctx, statement); err != nil {
+						return err
+					}
+				}
+				_, err := sess.ExecContext(ctx, "INSERT INTO migration_log (id, applied_at) VALUES (?, ?)", m.ID, time.Now())
+				return err
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("migration %q failed: %w", m.ID, err)
+		}
+		ss.lg.Info("migration applied", "id", m.ID)
+	}
+	return nil
+}
+
+func (ss *SQLStore) appliedMigrations(ctx context.Context) (map[string]bool, error) {
+	rows, err := ss.db.QueryContext(ctx, "SELECT id FROM migration_log")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		applied[id] = true
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,90 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	_ "github.com/mattn/go-sqlite3"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"os"
+	"path/filepath"
+	"xorm.io/core"
+)
+
+// Session is either the db or the transaction in ctx, see WithDbSession
+type Session interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	// ExecStructContext replaces ?Field of query by the field of st
+	ExecStructContext(ctx context.Context, query string, st interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*core.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *core.Row
+}
+
+type txKey struct{}
+
+// SQLStore implements bus.TransactionManager, handlers of DispatchCtx called within InTransaction
+// write through WithDbSession in the same transaction.
+type SQLStore struct {
+	lg log.Logger
+	db *core.DB
+}
+
+// Open the database of the database/sql driver, the driver must be linked in
+func Open(driver string, dsn string) (*SQLStore, error) {
+	db, err := core.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLStore{lg: log.New("sqlstore"), db: db}, nil
+}
+
+// OpenSQLite creates the file if missing. Transactions take the write lock when they begin,
+// and wait for the one holding it instead of failing with database is locked.
+func OpenSQLite(path string) (*SQLStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	return Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate&_foreign_keys=on", path))
+}
+
+func (ss *SQLStore) Close() error {
+	return ss.db.Close()
+}
+
+// InTransaction runs fn with the transaction stored in ctx, it is rolled back if fn returns an error or panics.
+// Calls nested in fn join the transaction of ctx.
+func (ss *SQLStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*core.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				ss.lg.Error("failed to rollback", "err", rbErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(context.WithValue(ctx, txKey{}, tx))
+}
+
+// WithDbSession runs fn with the transaction of ctx if any, otherwise with the db
+func (ss *SQLStore) WithDbSession(ctx context.Context, fn func(sess Session) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*core.Tx); ok {
+		return fn(tx)
+	}
+	return fn(ss.db)
+}
@@ -0,0 +1,125 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+var testMigrations = []Migration{
+	{ID: "create item table", SQL: []string{"CREATE TABLE item (name TEXT PRIMARY KEY, size INTEGER NOT NULL)"}},
+	{ID: "add item color", SQL: []string{"ALTER TABLE item ADD COLUMN color TEXT NOT NULL DEFAULT ''"}},
+}
+
+type item struct {
+	Name string
+	Size int
+}
+
+func openTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+	ss, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+	if err := ss.Migrate(testMigrations); err != nil {
+		t.Fatal(err)
+	}
+	return ss
+}
+
+func insertItem(ctx context.Context, ss *SQLStore, it *item) error {
+	return ss.WithDbSession(ctx, func(sess Session) error {
+		_, err := sess.ExecStructContext(ctx, "INSERT INTO item (name, size) VALUES (?Name, ?Size)", it)
+		return err
+	})
+}
+
+func countItems(t *testing.T, ss *SQLStore) int {
+	t.Helper()
+	var count int
+	err := ss.WithDbSession(context.Background(), func(sess Session) error {
+		return sess.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM item").Scan(&count)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestMigrate(t *testing.T) {
+	ss := openTestStore(t)
+	// applied ones are skipped
+	if err := ss.Migrate(testMigrations); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := ss.appliedMigrations(context.Background())
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("unexpected migrations applied: %v, %v", applied, err)
+	}
+
+	broken := append(testMigrations, Migration{ID: "broken", SQL: []string{
+		"CREATE TABLE other (id INTEGER)",
+		"CREATE TABLE item (name TEXT)",
+	}})
+	if err := ss.Migrate(broken); err == nil {
+		t.Fatal("broken migration should fail")
+	}
+	// the failed migration is rolled back as a whole
+	if err := ss.Migrate([]Migration{{ID: "other", SQL: []string{"CREATE TABLE other (id INTEGER)"}}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInTransaction(t *testing.T) {
+	ss := openTestStore(t)
+	ctx := context.Background()
+
+	err := ss.InTransaction(ctx, func(ctx context.Context) error {
+		if err := insertItem(ctx, ss, &item{Name: "a", Size: 1}); err != nil {
+			return err
+		}
+		// nested calls join the transaction
+		return ss.InTransaction(ctx, func(ctx context.Context) error {
+			return insertItem(ctx, ss, &item{Name: "b", Size: 2})
+		})
+	})
+	if err != nil || countItems(t, ss) != 2 {
+		t.Fatalf("transaction is not committed: %v", err)
+	}
+
+	failed := errors.New("failed")
+	err = ss.InTransaction(ctx, func(ctx context.Context) error {
+		if err := insertItem(ctx, ss, &item{Name: "c", Size: 3}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if err != failed || countItems(t, ss) != 2 {
+		t.Fatalf("transaction is not rolled back: %v", err)
+	}
+
+	func() {
+		defer func() { recover() }()
+		ss.InTransaction(ctx, func(ctx context.Context) error {
+			insertItem(ctx, ss, &item{Name: "d", Size: 4})
+			panic("boom")
+		})
+	}()
+	if countItems(t, ss) != 2 {
+		t.Fatal("transaction is not rolled back by panic")
+	}
+
+	// a duplicated name fails the second insert, the first is rolled back with it
+	err = ss.InTransaction(ctx, func(ctx context.Context) error {
+		if err := insertItem(ctx, ss, &item{Name: "e", Size: 5}); err != nil {
+			return err
+		}
+		return insertItem(ctx, ss, &item{Name: "a", Size: 6})
+	})
+	if err == nil || countItems(t, ss) != 2 {
+		t.Fatalf("transaction is not atomic: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/ini.v1"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigChanged is published on bus after the configuration is reloaded on SIGHUP.
+// Services apply the new values of their sections, see isReloadable. Other settings, like enabled,
+// dry_run and addresses, are read at startup only and need a restart.
+type ConfigChanged struct {
+	Changed []string // reloadable keys as section.key, sorted
+	Restart []string // keys changed but not applied until restart, sorted
+	Time    time.Time
+
+	// File is the reloaded file, Raw keeps the one loaded at startup
+	File *ini.File
+}
+
+// SectionChanged true if any key of the sections is changed
+func (e *ConfigChanged) SectionChanged(sections ...string) bool {
+	for _, changed := range e.Changed {
+		for _, section := range sections {
+			if strings.HasPrefix(changed, section+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reload reads the config files of the last Load again. An invalid configuration is rejected
+// and the loaded one is kept.
+func (cfg *Cfg) Reload() (*ConfigChanged, error) {
+	if cfg.args == nil {
+		return nil, errors.New("configuration is not loaded")
+	}
+	iniFile, err := cfg.loadConfiguration(cfg.args)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfiguration(iniFile); err != nil {
+		return nil, err
+	}
+	values := keyValues(iniFile)
+	event := &ConfigChanged{Time: time.Now(), File: iniFile}
+	for _, key := range changedKeys(cfg.values, values) {
+		if isReloadable(key) {
+			event.Changed = append(event.Changed, key)
+		} else {
+			event.Restart = append(event.Restart, key)
+		}
+	}
+	cfg.values = values
+	// globals read by other goroutines, like paths and the client order id prefix, are never rewritten
+	applyReloadable(iniFile)
+	return event, nil
+}
+
+// isReloadable thresholds, watchlists and limits of [rebalancer], [funding_arb] and [spread],
+// the admin token and alerting backends
+func isReloadable(key string) bool {
+	i := strings.LastIndex(key, ".")
+	section, name := key[:i], key[i+1:]
+	switch section {
+	case "rebalancer":
+		return name != "enabled" && name != "dry_run"
+	case "rebalancer.targets":
+		return true
+	case "funding_arb":
+		return name != "enabled" && name != "dry_run" && name != "spot_exchange" && name != "perp_exchange"
+	case "spread":
+		return name != "enabled" && name != "exchanges"
+	case "admin":
+		return name == "token"
+	case "alerting":
+		// the rest is read by alerting.ReadAlertingConfig from File
+		return name != "enabled" && name != "telegram_token"
+	case "alerting.telegram":
+		// shared with the telegram bot
+		return name != "token" && name != "chat_id" && name != "commands" && name != "allowed_chats"
+	}
+	return strings.HasPrefix(section, "alerting.")
+}
+
+// keyValues of a parsed file, it is taken before applied, because Must* writes defaults back to the file
+func keyValues(file *ini.File) map[string]string {
+	res := make(map[string]string)
+	for _, section := range file.Sections() {
+		// own keys only, child sections inherit keys of the parent
+		for _, name := range section.KeyStrings() {
+			res[section.Name()+"."+name] = section.Key(name).Value()
+		}
+	}
+	return res
+}
+
+func changedKeys(oldValues, newValues map[string]string) []string {
+	var changed []string
+	for key, value := range newValues {
+		if oldValue, ok := oldValues[key]; !ok || oldValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+type valueCheck func(value string) error
+
+func durationAtLeast(min time.Duration) valueCheck {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err == nil && d < min {
+			err = fmt.Errorf("must be %s at least", min)
+		}
+		return err
+	}
+}
+
+func floatAtLeast(min float64) valueCheck {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err == nil && f < min {
+			err = fmt.Errorf("must be %v at least", min)
+		}
+		return err
+	}
+}
+
+func intAtLeast(min int) valueCheck {
+	return func(value string) error {
+		i, err := strconv.Atoi(value)
+		if err == nil && i < min {
+			err = fmt.Errorf("must be %d at least", min)
+		}
+		return err
+	}
+}
+
+// isBool accepts the values of ini Key.Bool
+func isBool(value string) error {
+	switch strings.ToLower(value) {
+	case "1", "t", "true", "y", "yes", "on", "0", "f", "false", "n", "no", "off":
+		return nil
+	}
+	return errors.New("must be a bool")
+}
+
+// configChecks values read by Load with Must*, which falls back to the default silently on typos
+var configChecks = map[string]valueCheck{
+	"alerting.enabled":               isBool,
+	"alerting.dedupe_window":         durationAtLeast(0),
+	"alerting.rate_limit":            intAtLeast(0),
+	"alerting.rate_period":           durationAtLeast(time.Second),
+	"exchange_info.refresh_interval": durationAtLeast(time.Second),
+	"order_tracker.poll_interval":    durationAtLeast(time.Second),
+	"order_tracker.retention":        durationAtLeast(0),
+	"trade_sync.interval":            durationAtLeast(0),
+	"transfer.poll_interval":         durationAtLeast(time.Second),
+	"transfer.stuck_timeout":         durationAtLeast(time.Second),
+	"inventory.refresh_interval":     durationAtLeast(time.Second),
+
+	"rebalancer.enabled":             isBool,
+	"rebalancer.dry_run":             isBool,
+	"rebalancer.interval":            durationAtLeast(time.Second),
+	"rebalancer.tolerance":           floatAtLeast(0),
+	"rebalancer.max_cost_ratio":      floatAtLeast(0),
+	"rebalancer.max_actions_per_day": intAtLeast(0),
+	"rebalancer.taker_fee":           floatAtLeast(0),
+
+	"funding_arb.enabled":      isBool,
+	"funding_arb.dry_run":      isBool,
+	"funding_arb.interval":     durationAtLeast(time.Second),
+	"funding_arb.notional":     floatAtLeast(0),
+	"funding_arb.entry_carry":  floatAtLeast(-1),
+	"funding_arb.exit_carry":   floatAtLeast(-1),
+	"funding_arb.exit_basis":   floatAtLeast(0),
+	"funding_arb.fee_rate":     floatAtLeast(0),
+	"funding_arb.holding_days": intAtLeast(1),
+
+	"spread.enabled":     isBool,
+	"spread.fee_bps":     floatAtLeast(0),
+	"spread.levels":      intAtLeast(1),
+	"spread.stale_after": durationAtLeast(time.Millisecond),
+
+	"metrics.enabled":            isBool,
+	"admin.enabled":              isBool,
+	"admin.recent_opportunities": intAtLeast(1),
+	"reconciliation.enabled":     isBool,
+	"journal.enabled":            isBool,
+	"journal.segment_size_mb":    intAtLeast(1),
+	"journal.sync":               isBool,
+
+	"database.enabled":                   isBool,
+	"database.balance_snapshot_interval": durationAtLeast(time.Minute),
+}
+
+// validateConfiguration empty values are left to the defaults of Load
+func validateConfiguration(file *ini.File) error {
+	var errs []string
+	for name, check := range configChecks {
+		i := strings.LastIndex(name, ".")
+		section, err := file.GetSection(name[:i])
+		if err != nil || !section.HasKey(name[i+1:]) {
+			continue
+		}
+		value := strings.TrimSpace(section.Key(name[i+1:]).Value())
+		if value == "" {
+			continue
+		}
+		if err := check(value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s = %q: %v", name, value, err))
+		}
+	}
+
+	fundingArb := file.Section("funding_arb")
+	if entry, exit := fundingArb.Key("entry_carry").MustFloat64(0.15), fundingArb.Key("exit_carry").MustFloat64(0.03); exit > entry {
+		errs = append(errs, fmt.Sprintf("funding_arb.exit_carry %v is above entry_carry %v", exit, entry))
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package setting
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "custom.ini")
+	write := func(content string) {
+		content = "[paths]\ndata = " + dir + "\nlogs = " + dir + "\n[log]\nmode = console\n" + content
+		if err := ioutil.WriteFile(configFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("[rebalancer]\ntolerance = 0.2\n[spread]\nassets = BTC\n")
+
+	cfg := NewCfg()
+	if err := cfg.Load(&CommandLineArgs{Config: configFile, HomePath: "../.."}); err != nil {
+		t.Fatal(err)
+	}
+	if RebalanceTolerance != 0.2 || !reflect.DeepEqual(SpreadAssets, []string{"BTC"}) {
+		t.Fatalf("unexpected settings: %v, %v", RebalanceTolerance, SpreadAssets)
+	}
+
+	write("[rebalancer]\ntolerance = 0.3\n[spread]\nassets = BTC,ETH\n")
+	changed, err := cfg.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed.Changed, []string{"rebalancer.tolerance", "spread.assets"}) {
+		t.Fatalf("unexpected changed keys: %v", changed.Changed)
+	}
+	if !changed.SectionChanged("funding_arb", "spread") || changed.SectionChanged("funding_arb") {
+		t.Fatal("unexpected changed sections")
+	}
+	if RebalanceTolerance != 0.3 || !reflect.DeepEqual(SpreadAssets, []string{"BTC", "ETH"}) {
+		t.Fatalf("unexpected settings: %v, %v", RebalanceTolerance, SpreadAssets)
+	}
+
+	// typos are rejected instead of falling back to the defaults
+	write("[rebalancer]\ntolerance = 0,4\ninterval = 10\n[spread]\nassets = SOL\n")
+	if _, err := cfg.Reload(); err == nil || !strings.Contains(err.Error(), "rebalancer.tolerance") ||
+		!strings.Contains(err.Error(), "rebalancer.interval") {
+		t.Fatalf("invalid configuration is accepted: %v", err)
+	}
+	if RebalanceTolerance != 0.3 || RebalanceInterval != 10*time.Minute || !reflect.DeepEqual(SpreadAssets, []string{"BTC", "ETH"}) {
+		t.Fatal("configuration is changed by a rejected reload")
+	}
+
+	// settings read at startup only are reported and kept
+	prefix := ClientOrderIDPrefix
+	write("[rebalancer]\ntolerance = 0.3\n[spread]\nassets = BTC,ETH\n[trading]\nclient_order_id_prefix = other\n")
+	changed, err = cfg.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed.Changed) != 0 || !reflect.DeepEqual(changed.Restart, []string{"trading.client_order_id_prefix"}) {
+		t.Fatalf("unexpected changed keys: %v, restart: %v", changed.Changed, changed.Restart)
+	}
+	if ClientOrderIDPrefix != prefix {
+		t.Fatalf("client order id prefix is reloaded: %s", ClientOrderIDPrefix)
+	}
+
+	write("[funding_arb]\nentry_carry = 0.01\nexit_carry = 0.02\n")
+	if _, err := cfg.Reload(); err == nil || !strings.Contains(err.Error(), "exit_carry") {
+		t.Fatalf("exit above entry is accepted: %v", err)
+	}
+}
+
+func TestReloadNotLoaded(t *testing.T) {
+	if _, err := NewCfg().Reload(); err == nil {
+		t.Fatal("reload before load should fail")
+	}
+}
@@ -7,50 +7,106 @@ import (
 	"io/ioutil"
 	"jasonzhu.com/coin_labor/core/components/log"
 	"jasonzhu.com/coin_labor/core/util/homedir"
+	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
 	storePath        = "~/.coin_labor"
 	secretConfigPath = "conf/secrets.conf.yml"
+
+	// secretsEnvPrefix e.g. GF_SECRETS_BINANCE_KEY, GF_SECRETS_OKX_PASSPHRASE_FILE, GF_SECRETS_FILE overrides the path
+	secretsEnvPrefix = "GF_SECRETS_"
 )
 
-var SecretsConf *SecretCfg
+// SecretsConf is empty until LoadSecrets, commands not trading never unlock the secrets
+var SecretsConf = newSecretConfig()
 
-func init() {
+// LoadSecrets reads the secrets file, the master password is prompted if it is encrypted and not set in the environment
+func LoadSecrets() error {
 	config := newSecretConfig()
-	err := config.LoadAppConfiguration()
-	if err != nil {
-		log.Fatal("failed to load secrets configuration: %v", err)
+	if err := config.LoadAppConfiguration(); err != nil {
+		return fmt.Errorf("failed to load secrets configuration: %w", err)
 	}
 	SecretsConf = config
+	return nil
+}
+
+// SecretsPath of the secrets file, GF_SECRETS_FILE or ~/.coin_labor/conf/secrets.conf.yml
+func SecretsPath() (string, error) {
+	if path := os.Getenv(secretsEnvPrefix + "FILE"); path != "" {
+		return path, nil
+	}
+	expandedDir, err := homedir.Expand(storePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(expandedDir, secretConfigPath), nil
 }
 
 func newSecretConfig() *SecretCfg {
-	return &SecretCfg{}
+	return &SecretCfg{Exchanges: make(map[string]*Secret)}
 }
 
+// SecretCfg credentials keyed by exchange at the top level of the file, e.g.
+//
+//	binance:
+//	  key: "..."
+//	  secret_file: /run/secrets/binance_secret
+//	okx:
+//	  key: "..."
+//	  secret: "..."
+//	  passphrase: "..."
 type SecretCfg struct {
 	configFile string
 
-	Binance Secret `yaml:"binance"`
-	MEXC    Secret `yaml:"mexc"`
+	Exchanges map[string]*Secret `yaml:",inline"`
+
+	// AddressBook whitelisted withdrawal addresses, withdrawals to any other address are refused
+	AddressBook []WithdrawAddress `yaml:"address_book"`
 }
 
+type WithdrawAddress struct {
+	Name     string `yaml:"name"`
+	Exchange string `yaml:"exchange"` // owner of the address, e.g. deposit address in another exchange
+	Asset    string `yaml:"asset"`
+	Network  string `yaml:"network"`
+	Address  string `yaml:"address"`
+	Tag      string `yaml:"tag"` // memo
+}
+
+// Secret each field can be read from the file of its *_file field instead
 type Secret struct {
-	Key    string `yaml:"key"`
-	Secret string `yaml:"secret"`
+	Key        string `yaml:"key"`
+	Secret     string `yaml:"secret"`
+	Passphrase string `yaml:"passphrase"`  // required by some exchanges, like OKX
+	SubAccount string `yaml:"sub_account"` // trade as the sub account, if supported by the exchange
+
+	KeyFile        string `yaml:"key_file"`
+	SecretFile     string `yaml:"secret_file"`
+	PassphraseFile string `yaml:"passphrase_file"`
 }
 
+// IsComplete key and secret are both required
+func (s *Secret) IsComplete() bool {
+	return s.Key != "" && s.Secret != ""
+}
+
+// Path of the secrets file, it may be encrypted
+func (cfg *SecretCfg) Path() string {
+	return cfg.configFile
+}
+
+// LoadAppConfiguration a missing file is not an error, credentials may come from environment variables
 func (cfg *SecretCfg) LoadAppConfiguration() error {
-	path := secretConfigPath
-	expandedDir, err := homedir.Expand(storePath)
-	if err != nil {
+	var err error
+	if cfg.configFile, err = SecretsPath(); err != nil {
 		return err
 	}
-	cfg.configFile = filepath.Join(expandedDir, path)
 	if !pathExists(cfg.configFile) {
-		return errors.New("could not found secret config file, path: " + cfg.configFile)
+		log.Warn("secret config file %s not found, only credentials of environment variables are used", cfg.configFile)
+		return nil
 	}
 
 	if err := cfg.loadFromFile(); err != nil {
@@ -64,11 +120,68 @@ func (cfg *SecretCfg) loadFromFile() error {
 	if err != nil {
 		return err
 	}
+	if IsEncryptedSecrets(b) {
+		passphrase, err := UnlockPassphrase()
+		if err != nil {
+			return err
+		}
+		if b, err = DecryptSecrets(b, passphrase); err != nil {
+			return err
+		}
+	}
 
 	err = yaml.Unmarshal(b, cfg)
 	if err != nil {
 		return err
 	}
 
+	// exchanges are matched case-insensitively
+	exchanges := make(map[string]*Secret, len(cfg.Exchanges))
+	for name, secret := range cfg.Exchanges {
+		if secret != nil {
+			exchanges[strings.ToLower(name)] = secret
+		}
+	}
+	cfg.Exchanges = exchanges
 	return nil
 }
+
+// Get returns the credentials of the exchange with overrides applied, an error if key or secret is missing.
+// Environment variables GF_SECRETS_<EXCHANGE>_<FIELD> win over the file, <FIELD>_FILE reads the value from a file.
+func (cfg *SecretCfg) Get(exchange string) (*Secret, error) {
+	secret := Secret{}
+	if s, ok := cfg.Exchanges[strings.ToLower(exchange)]; ok {
+		secret = *s
+	}
+	envPrefix := secretsEnvPrefix + strings.ToUpper(exchange) + "_"
+	fields := []struct {
+		name  string
+		value *string
+		file  string
+	}{
+		{"KEY", &secret.Key, secret.KeyFile},
+		{"SECRET", &secret.Secret, secret.SecretFile},
+		{"PASSPHRASE", &secret.Passphrase, secret.PassphraseFile},
+		{"SUB_ACCOUNT", &secret.SubAccount, ""},
+	}
+	for _, field := range fields {
+		file := field.file
+		if envFile := os.Getenv(envPrefix + field.name + "_FILE"); envFile != "" {
+			file = envFile
+		}
+		if file != "" {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s of %s: %w", strings.ToLower(field.name), exchange, err)
+			}
+			*field.value = strings.TrimSpace(string(b))
+		}
+		if env := os.Getenv(envPrefix + field.name); env != "" {
+			*field.value = env
+		}
+	}
+	if !secret.IsComplete() {
+		return nil, fmt.Errorf("credentials of %s are missing", exchange)
+	}
+	return &secret, nil
+}
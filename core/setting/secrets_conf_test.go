@@ -2,6 +2,8 @@ package setting
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 )
 
@@ -18,3 +20,89 @@ func TestNewSecretConfig(t *testing.T) {
 	}
 	fmt.Println(config)
 }
+
+func TestSecretOverrides(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "okx_secret")
+	if err := ioutil.WriteFile(secretFile, []byte("okx-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(dir, "secrets.conf.yml")
+	content := `
+MEXC:
+  key: mexc-key
+  secret: mexc-secret
+okx:
+  key: okx-key
+  secret_file: ` + secretFile + `
+  passphrase: okx-passphrase
+address_book:
+  - name: mexc-inj
+    exchange: MEXC
+    asset: INJ
+`
+	if err := ioutil.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GF_SECRETS_FILE", configFile)
+	t.Setenv("GF_SECRETS_MEXC_SECRET", "mexc-secret-from-env")
+	t.Setenv("GF_SECRETS_COINBASE_KEY", "coinbase-key")
+
+	config := newSecretConfig()
+	if err := config.LoadAppConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.AddressBook) != 1 || len(config.Exchanges) != 2 {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+
+	mexc, err := config.Get("MEXC")
+	if err != nil || mexc.Key != "mexc-key" || mexc.Secret != "mexc-secret-from-env" {
+		t.Fatalf("unexpected mexc secret: %+v, %v", mexc, err)
+	}
+	okx, err := config.Get("okx")
+	if err != nil || okx.Secret != "okx-secret" || okx.Passphrase != "okx-passphrase" {
+		t.Fatalf("unexpected okx secret: %+v, %v", okx, err)
+	}
+	if _, err := config.Get("coinBase"); err == nil {
+		t.Fatal("secret of coinBase is missing")
+	}
+	t.Setenv("GF_SECRETS_COINBASE_SECRET_FILE", filepath.Join(dir, "missing"))
+	if _, err := config.Get("coinBase"); err == nil {
+		t.Fatal("secret file of coinBase is missing")
+	}
+}
+
+func TestMissingSecretConfigFile(t *testing.T) {
+	t.Setenv("GF_SECRETS_FILE", filepath.Join(t.TempDir(), "missing.yml"))
+	config := newSecretConfig()
+	if err := config.LoadAppConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Get("binance"); err == nil {
+		t.Fatal("credentials should be missing")
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "secrets.conf.yml")
+	if err := ioutil.WriteFile(configFile, []byte("MEXC:\n  key: mexc-key\n  secret: mexc-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GF_SECRETS_FILE", configFile)
+	defer func() { SecretsConf = newSecretConfig() }()
+
+	// nothing is read before LoadSecrets
+	if _, err := SecretsConf.Get("MEXC"); err == nil {
+		t.Fatal("expected no credentials before LoadSecrets")
+	}
+	if path, err := SecretsPath(); err != nil || path != configFile {
+		t.Fatalf("unexpected path %s: %v", path, err)
+	}
+	if err := LoadSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := SecretsConf.Get("MEXC"); err != nil || secret.Key != "mexc-key" {
+		t.Fatalf("unexpected secret %+v: %v", secret, err)
+	}
+}
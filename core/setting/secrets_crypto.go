@@ -0,0 +1,190 @@
+package setting
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	secretsEncryption = "scrypt-aes256gcm"
+
+	// SecretsMasterPasswordEnv unlocks the encrypted secrets file, or the file of SecretsMasterPasswordFileEnv
+	SecretsMasterPasswordEnv     = secretsEnvPrefix + "MASTER_PASSWORD"
+	SecretsMasterPasswordFileEnv = secretsEnvPrefix + "MASTER_PASSWORD_FILE"
+
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptMaxN   = 1 << 20
+	secretKeyLen = 32
+	saltLen      = 16
+)
+
+var ErrSecretsLocked = errors.New("secrets file is encrypted, but no master password is available")
+
+// encryptedSecrets the secrets file encrypted at rest, the plaintext is the yaml of SecretCfg
+type encryptedSecrets struct {
+	Encryption string `yaml:"encryption"`
+	ScryptN    int    `yaml:"scrypt_n"`
+	ScryptR    int    `yaml:"scrypt_r"`
+	ScryptP    int    `yaml:"scrypt_p"`
+	Salt       string `yaml:"salt"`
+	Nonce      string `yaml:"nonce"`
+	Ciphertext string `yaml:"ciphertext"`
+}
+
+func IsEncryptedSecrets(data []byte) bool {
+	var file encryptedSecrets
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return false
+	}
+	return file.Encryption == secretsEncryption && file.Ciphertext != ""
+}
+
+func newSecretsGCM(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, secretKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecrets a fresh salt and nonce every time, so rotating the password re-encrypts everything
+func EncryptSecrets(plaintext, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("master password is empty")
+	}
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newSecretsGCM(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	file := &encryptedSecrets{
+		Encryption: secretsEncryption,
+		ScryptN:    scryptN,
+		ScryptR:    scryptR,
+		ScryptP:    scryptP,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, []byte(secretsEncryption))),
+	}
+	b, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("# encrypted by: coin_labor secrets, edit with: coin_labor secrets edit\n"), b...), nil
+}
+
+func DecryptSecrets(data, passphrase []byte) ([]byte, error) {
+	var file encryptedSecrets
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Encryption != secretsEncryption {
+		return nil, fmt.Errorf("unsupported encryption %q", file.Encryption)
+	}
+	if file.ScryptN <= 1 || file.ScryptN > scryptMaxN {
+		return nil, fmt.Errorf("invalid scrypt_n %d", file.ScryptN)
+	}
+	var salt, nonce, ciphertext []byte
+	for _, field := range []struct {
+		dst   *[]byte
+		value string
+	}{{&salt, file.Salt}, {&nonce, file.Nonce}, {&ciphertext, file.Ciphertext}} {
+		b, err := base64.StdEncoding.DecodeString(field.value)
+		if err != nil {
+			return nil, err
+		}
+		*field.dst = b
+	}
+	gcm, err := newSecretsGCM(passphrase, salt, file.ScryptN, file.ScryptR, file.ScryptP)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(secretsEncryption))
+	if err != nil {
+		return nil, errors.New("wrong master password or corrupted secrets file")
+	}
+	return plaintext, nil
+}
+
+var (
+	passphraseM sync.Mutex
+	passphrase  []byte
+)
+
+// UnlockPassphrase the master password from SecretsMasterPasswordEnv, the file of SecretsMasterPasswordFileEnv,
+// or prompted on terminal. It is asked once per process.
+func UnlockPassphrase() ([]byte, error) {
+	passphraseM.Lock()
+	defer passphraseM.Unlock()
+	if passphrase != nil {
+		return passphrase, nil
+	}
+	switch {
+	case os.Getenv(SecretsMasterPasswordEnv) != "":
+		passphrase = []byte(os.Getenv(SecretsMasterPasswordEnv))
+	case os.Getenv(SecretsMasterPasswordFileEnv) != "":
+		b, err := ioutil.ReadFile(os.Getenv(SecretsMasterPasswordFileEnv))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master password file: %w", err)
+		}
+		passphrase = []byte(strings.TrimSpace(string(b)))
+	default:
+		p, err := PromptPassphrase("Master password of secrets: ")
+		if err != nil {
+			return nil, err
+		}
+		passphrase = p
+	}
+	return passphrase, nil
+}
+
+// PromptPassphrase reads without echo from stdin, which must be a terminal
+func PromptPassphrase(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, ErrSecretsLocked
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+	return term.ReadPassword(fd)
+}
+
+// WriteSecretsFile replaces the file atomically, readable by the owner only
+func WriteSecretsFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
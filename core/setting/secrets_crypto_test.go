@@ -0,0 +1,71 @@
+package setting
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptSecrets(t *testing.T) {
+	plaintext := []byte("binance:\n  key: k\n  secret: s\n")
+	data, err := EncryptSecrets(plaintext, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncryptedSecrets(data) || IsEncryptedSecrets(plaintext) {
+		t.Fatal("encrypted file is not detected")
+	}
+	if bytes.Contains(data, []byte("secret: s")) {
+		t.Fatal("plaintext leaked")
+	}
+	decrypted, err := DecryptSecrets(data, []byte("correct horse"))
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("unexpected plaintext: %s, %v", decrypted, err)
+	}
+	if _, err := DecryptSecrets(data, []byte("wrong horse")); err == nil {
+		t.Fatal("wrong password should fail")
+	}
+
+	again, err := EncryptSecrets(plaintext, []byte("correct horse"))
+	if err != nil || bytes.Equal(again, data) {
+		t.Fatal("salt and nonce should be fresh")
+	}
+	tampered := strings.Replace(string(data), "ciphertext: ", "ciphertext: AAAA", 1)
+	if _, err := DecryptSecrets([]byte(tampered), []byte("correct horse")); err == nil {
+		t.Fatal("tampered ciphertext should fail")
+	}
+	if _, err := EncryptSecrets(plaintext, nil); err == nil {
+		t.Fatal("empty password should fail")
+	}
+}
+
+func TestLoadEncryptedSecrets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf", "secrets.conf.yml")
+	data, err := EncryptSecrets([]byte("mexc:\n  key: mexc-key\n  secret: mexc-secret\n"), []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSecretsFile(path, data); err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(dir, "master")
+	if err := ioutil.WriteFile(passwordFile, []byte("pass\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GF_SECRETS_FILE", path)
+	t.Setenv(SecretsMasterPasswordFileEnv, passwordFile)
+	passphrase = nil
+	defer func() { passphrase = nil }()
+
+	config := newSecretConfig()
+	if err := config.LoadAppConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := config.Get("mexc")
+	if err != nil || secret.Key != "mexc-key" || secret.Secret != "mexc-secret" {
+		t.Fatalf("unexpected secret: %+v, %v", secret, err)
+	}
+}
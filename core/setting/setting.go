@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"jasonzhu.com/coin_labor/core/components/log"
 )
@@ -52,6 +53,9 @@ var (
 	// App settings.
 	Env             = DEV
 	ApplicationName = APP_NAME
+	BuildVersion    = "dev"
+	BuildCommit     = "unknown"
+	BuildBranch     = "unknown"
 
 	// Paths
 	HomePath       string
@@ -60,13 +64,100 @@ var (
 	CustomInitPath = "conf/custom.ini"
 
 	// Http server options
+	MetricsEnabled  bool
+	MetricsHttpAddr string
+	MetricsPath     string
 
 	// Alerting
-	AlertingEnabled bool
-	TgToken         string
+	AlertingEnabled   bool
+	TgToken           string
+	TgChatID          string
+	TgCommandsEnabled bool
+	TgAllowedChats    []string
+
+	// Exchanges
+	ExchangesEnabled []string
+
+	// Exchange Info
+	ExchangeInfoRefreshInterval time.Duration
+	ExchangeInfoQuoteAssets     []string
+
+	// Trading
+	ClientOrderIDPrefix = "cl_"
+
+	// Order Tracker
+	OrderTrackerPollInterval time.Duration
+	OrderTrackerRetention    time.Duration
+
+	// Trade Sync
+	TradeSyncInterval time.Duration
+
+	// Transfer
+	TransferPollInterval time.Duration
+	TransferStuckTimeout time.Duration
+
+	// Inventory
+	InventoryRefreshInterval time.Duration
+
+	// Rebalancer
+	RebalanceEnabled          bool
+	RebalanceDryRun           bool
+	RebalanceInterval         time.Duration
+	RebalanceTolerance        float64
+	RebalanceMaxCostRatio     float64
+	RebalanceMaxActionsPerDay int
+	RebalanceTakerFee         float64
+	RebalanceTradeQuote       string
+	RebalanceTargets          map[string]string
+
+	// Funding rate arbitrage
+	FundingArbEnabled      bool
+	FundingArbDryRun       bool
+	FundingArbInterval     time.Duration
+	FundingArbSpotExchange string
+	FundingArbPerpExchange string
+	FundingArbAssets       []string
+	FundingArbNotional     float64
+	FundingArbEntryCarry   float64
+	FundingArbExitCarry    float64
+	FundingArbExitBasis    float64
+	FundingArbFeeRate      float64
+	FundingArbHoldingDays  int
+
+	// Spread metrics
+	SpreadEnabled    bool
+	SpreadExchanges  []string
+	SpreadAssets     []string
+	SpreadFeeBps     float64
+	SpreadLevels     int
+	SpreadStaleAfter time.Duration
+
+	// Admin HTTP API
+	AdminEnabled             bool
+	AdminHttpAddr            string
+	AdminToken               string
+	AdminRecentOpportunities int
+
+	// Reconciliation
+	ReconcileEnabled       bool
+	ReconcileOwnOrders     string
+	ReconcileForeignOrders string
+
+	// Event journal
+	JournalEnabled       bool
+	JournalSegmentSizeMB int
+	JournalSync          bool
+
+	// Database
+	DatabaseEnabled                 bool
+	DatabasePath                    string
+	DatabaseBalanceSnapshotInterval time.Duration
 )
 
 type Cfg struct {
+	// args of the last Load, the files are read again by Reload
+	args   *CommandLineArgs
+	values map[string]string
 }
 
 type CommandLineArgs struct {
@@ -257,20 +348,17 @@ func (cfg *Cfg) loadConfiguration(args *CommandLineArgs) (*ini.File, error) {
 
 	//load config defaults
 	defaultConfigFile := path.Join(HomePath, DEFAULT_CONFIG_FILE)
-	configFiles = append(configFiles, defaultConfigFile)
+	configFiles = []string{defaultConfigFile}
 
 	// check if config file exists
 	if _, err := os.Stat(defaultConfigFile); os.IsNotExist(err) {
-		fmt.Println("Pipe-server Init Failed: Could not find config defaults, make sure homepath command line parameter is set or working directory is homepath")
-		os.Exit(1)
+		return nil, fmt.Errorf("could not find config defaults %s, make sure homepath command line parameter is set or working directory is homepath", defaultConfigFile)
 	}
 
 	// load defaults
 	parsedFile, err := ini.Load(defaultConfigFile)
 	if err != nil {
-		fmt.Println(fmt.Sprintf("Failed to parse defaults.ini, %v", err))
-		os.Exit(1)
-		return nil, err
+		return nil, fmt.Errorf("failed to parse defaults.ini, %v", err)
 	}
 
 	parsedFile.BlockMode = false
@@ -283,8 +371,7 @@ func (cfg *Cfg) loadConfiguration(args *CommandLineArgs) (*ini.File, error) {
 	// load specified config file
 	err = loadSpecifiedConfigFile(args.Config, parsedFile)
 	if err != nil {
-		cfg.initLogging(parsedFile)
-		log.Fatal(err.Error())
+		return nil, err
 	}
 
 	// apply environment overrides
@@ -299,10 +386,6 @@ func (cfg *Cfg) loadConfiguration(args *CommandLineArgs) (*ini.File, error) {
 	// evaluate config values containing environment variables
 	evalConfigValues(parsedFile)
 
-	// update data path and logging config
-	DataPath = makeAbsolute(parsedFile.Section("paths").Key("data").String(), HomePath)
-	cfg.initLogging(parsedFile)
-
 	return parsedFile, err
 }
 
@@ -346,6 +429,31 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	if err != nil {
 		return err
 	}
+	if err := validateConfiguration(iniFile); err != nil {
+		return err
+	}
+	cfg.args = args
+	cfg.values = keyValues(iniFile)
+	cfg.applyConfiguration(iniFile)
+	return nil
+}
+
+// LoadDataPath resolves paths.data of the config files like Load, nothing is applied and logging is not started.
+// It is for commands reading the data of a running labor.
+func LoadDataPath(args *CommandLineArgs) (string, error) {
+	setHomePath(args)
+	iniFile, err := NewCfg().loadConfiguration(args)
+	if err != nil {
+		return "", err
+	}
+	return makeAbsolute(iniFile.Section("paths").Key("data").String(), HomePath), nil
+}
+
+// applyConfiguration the file must be validated
+func (cfg *Cfg) applyConfiguration(iniFile *ini.File) {
+	// update data path and logging config
+	DataPath = makeAbsolute(iniFile.Section("paths").Key("data").String(), HomePath)
+	cfg.initLogging(iniFile)
 
 	// Temporary keep global, to make refactor in steps
 	Raw = iniFile
@@ -354,9 +462,105 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 
 	alerting := iniFile.Section("alerting")
 	AlertingEnabled = alerting.Key("enabled").MustBool(true)
-	TgToken = alerting.Key("telegram_token").String()
+	// backends are read from [alerting.<name>] by alerting.ReadAlertingConfig, telegram is shared with the bot
+	telegram := iniFile.Section("alerting.telegram")
+	TgToken = telegram.Key("token").MustString(alerting.Key("telegram_token").String())
+	TgChatID = telegram.Key("chat_id").MustString("-828188094")
+	TgCommandsEnabled = telegram.Key("commands").MustBool(false)
+	TgAllowedChats = telegram.Key("allowed_chats").Strings(",")
+
+	ExchangesEnabled = iniFile.Section("exchanges").Key("enabled").Strings(",")
+
+	exchangeInfo := iniFile.Section("exchange_info")
+	ExchangeInfoRefreshInterval = exchangeInfo.Key("refresh_interval").MustDuration(5 * time.Minute)
+	ExchangeInfoQuoteAssets = exchangeInfo.Key("quote_assets").Strings(",")
+
+	trading := iniFile.Section("trading")
+	ClientOrderIDPrefix = trading.Key("client_order_id_prefix").MustString(ClientOrderIDPrefix)
+
+	orderTracker := iniFile.Section("order_tracker")
+	OrderTrackerPollInterval = orderTracker.Key("poll_interval").MustDuration(5 * time.Second)
+	OrderTrackerRetention = orderTracker.Key("retention").MustDuration(time.Hour)
+
+	TradeSyncInterval = iniFile.Section("trade_sync").Key("interval").MustDuration(10 * time.Minute)
+
+	transfer := iniFile.Section("transfer")
+	TransferPollInterval = transfer.Key("poll_interval").MustDuration(30 * time.Second)
+	TransferStuckTimeout = transfer.Key("stuck_timeout").MustDuration(time.Hour)
+
+	InventoryRefreshInterval = iniFile.Section("inventory").Key("refresh_interval").MustDuration(time.Minute)
+
+	rebalancer := iniFile.Section("rebalancer")
+	RebalanceEnabled = rebalancer.Key("enabled").MustBool(false)
+	RebalanceDryRun = rebalancer.Key("dry_run").MustBool(true)
+
+	fundingArb := iniFile.Section("funding_arb")
+	FundingArbEnabled = fundingArb.Key("enabled").MustBool(false)
+	FundingArbDryRun = fundingArb.Key("dry_run").MustBool(true)
+	FundingArbSpotExchange = fundingArb.Key("spot_exchange").MustString("binance")
+	FundingArbPerpExchange = fundingArb.Key("perp_exchange").MustString("binanceFutures")
+
+	spread := iniFile.Section("spread")
+	SpreadEnabled = spread.Key("enabled").MustBool(false)
+	SpreadExchanges = spread.Key("exchanges").Strings(",")
+
+	metrics := iniFile.Section("metrics")
+	MetricsEnabled = metrics.Key("enabled").MustBool(false)
+	MetricsHttpAddr = metrics.Key("http_addr").MustString(":9091")
+	MetricsPath = metrics.Key("path").MustString("/metrics")
+
+	admin := iniFile.Section("admin")
+	AdminEnabled = admin.Key("enabled").MustBool(false)
+	AdminHttpAddr = admin.Key("http_addr").MustString("127.0.0.1:8090")
+	AdminRecentOpportunities = admin.Key("recent_opportunities").MustInt(100)
+
+	reconciliation := iniFile.Section("reconciliation")
+	ReconcileEnabled = reconciliation.Key("enabled").MustBool(true)
+	ReconcileOwnOrders = reconciliation.Key("own_orders").MustString("adopt")
+	ReconcileForeignOrders = reconciliation.Key("foreign_orders").MustString("ignore")
+
+	journal := iniFile.Section("journal")
+	JournalEnabled = journal.Key("enabled").MustBool(true)
+	JournalSegmentSizeMB = journal.Key("segment_size_mb").MustInt(64)
+	JournalSync = journal.Key("sync").MustBool(false)
+
+	database := iniFile.Section("database")
+	DatabaseEnabled = database.Key("enabled").MustBool(true)
+	DatabasePath = makeAbsolute(database.Key("path").MustString("coin_labor.db"), DataPath)
+	DatabaseBalanceSnapshotInterval = database.Key("balance_snapshot_interval").MustDuration(time.Hour)
+
+	applyReloadable(iniFile)
+}
 
-	return nil
+// applyReloadable the settings of reloadableKeys, they are read by services in Init and on ConfigChanged only,
+// both on the goroutine applying them
+func applyReloadable(iniFile *ini.File) {
+	rebalancer := iniFile.Section("rebalancer")
+	RebalanceInterval = rebalancer.Key("interval").MustDuration(10 * time.Minute)
+	RebalanceTolerance = rebalancer.Key("tolerance").MustFloat64(0.1)
+	RebalanceMaxCostRatio = rebalancer.Key("max_cost_ratio").MustFloat64(0.005)
+	RebalanceMaxActionsPerDay = rebalancer.Key("max_actions_per_day").MustInt(4)
+	RebalanceTakerFee = rebalancer.Key("taker_fee").MustFloat64(0.001)
+	RebalanceTradeQuote = rebalancer.Key("trade_quote").MustString("USDT")
+	RebalanceTargets = iniFile.Section("rebalancer.targets").KeysHash()
+
+	fundingArb := iniFile.Section("funding_arb")
+	FundingArbInterval = fundingArb.Key("interval").MustDuration(time.Minute)
+	FundingArbAssets = fundingArb.Key("assets").Strings(",")
+	FundingArbNotional = fundingArb.Key("notional").MustFloat64(100)
+	FundingArbEntryCarry = fundingArb.Key("entry_carry").MustFloat64(0.15)
+	FundingArbExitCarry = fundingArb.Key("exit_carry").MustFloat64(0.03)
+	FundingArbExitBasis = fundingArb.Key("exit_basis").MustFloat64(0.0005)
+	FundingArbFeeRate = fundingArb.Key("fee_rate").MustFloat64(0.002)
+	FundingArbHoldingDays = fundingArb.Key("holding_days").MustInt(7)
+
+	spread := iniFile.Section("spread")
+	SpreadAssets = spread.Key("assets").Strings(",")
+	SpreadFeeBps = spread.Key("fee_bps").MustFloat64(10)
+	SpreadLevels = spread.Key("levels").MustInt(5)
+	SpreadStaleAfter = spread.Key("stale_after").MustDuration(5 * time.Second)
+
+	AdminToken = iniFile.Section("admin").Key("token").String()
 }
 
 func (cfg *Cfg) initLogging(file *ini.File) {
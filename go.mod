@@ -24,14 +24,17 @@ require (
 	github.com/gorilla/websocket v1.5.0
 	github.com/inconshreveable/log15 v2.16.0+incompatible
 	github.com/mattn/go-isatty v0.0.17
+	github.com/mattn/go-sqlite3 v1.14.17
 	github.com/opentracing/opentracing-go v1.2.0
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.14.0
 	github.com/segmentio/go-athena v0.0.0-20181208004937-dfa5f1818930
 	github.com/shopspring/decimal v1.3.1
 	github.com/smartystreets/goconvey v1.7.2
+	golang.org/x/crypto v0.5.0
 	golang.org/x/net v0.8.0
 	golang.org/x/sync v0.1.0
+	golang.org/x/term v0.6.0
 	gopkg.in/ini.v1 v1.67.0
 	gopkg.in/macaron.v1 v1.5.0
 	gopkg.in/yaml.v2 v2.4.0
@@ -94,9 +97,7 @@ require (
 	github.com/satori/go.uuid v1.2.0 // indirect
 	github.com/smartystreets/assertions v1.2.0 // indirect
 	github.com/unknwon/com v0.0.0-20190804042917-757f69c95f3e // indirect
-	golang.org/x/crypto v0.1.0 // indirect
 	golang.org/x/sys v0.6.0 // indirect
-	golang.org/x/term v0.6.0 // indirect
 	golang.org/x/text v0.8.0 // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
 )
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"io"
+	"jasonzhu.com/coin_labor/core/components/journal"
+	"jasonzhu.com/coin_labor/core/setting"
+	"jasonzhu.com/coin_labor/pkg/plugins/general"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const journalUsage = `usage: coin_labor journal <command> [flags]
+
+commands:
+  cat      print records matching the flags, in seq order
+  replay   apply order and balance records matching the flags in seq order, and print the last state
+           of every order and balance, e.g. the state at an incident with -until
+  verify   check checksums and seq of every record
+
+The journal is <paths.data>/journal of the config of -config, unless -dir is set.
+
+flags:
+`
+
+// runJournal reads the journal written by JournalService, it is safe while the service is running
+func runJournal(args []string) int {
+	fs := flag.NewFlagSet("journal", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, journalUsage)
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", "", "journal directory, <paths.data>/journal of the config by default")
+	types := fs.String("type", "", "comma separated record types, e.g. OrderSubmitted,OrderUpdated")
+	fromSeq := fs.Uint64("from-seq", 0, "first seq")
+	toSeq := fs.Uint64("to-seq", 0, "last seq")
+	since := fs.String("since", "", "records at or after, RFC3339 or a duration ago like 2h")
+	until := fs.String("until", "", "records before, RFC3339 or a duration ago like 30m")
+	format := fs.String("format", "json", "output of cat: json or text")
+
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	filter := journal.Filter{FromSeq: *fromSeq, ToSeq: *toSeq}
+	if *types != "" {
+		filter.Types = strings.Split(*types, ",")
+	}
+	var err error
+	if filter.Since, err = parseJournalTime(*since); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -since: %v\n", err)
+		return 2
+	}
+	if filter.Until, err = parseJournalTime(*until); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -until: %v\n", err)
+		return 2
+	}
+
+	if *dir == "" {
+		dataPath, err := setting.LoadDataPath(&setting.CommandLineArgs{Config: *configFile, AppConfig: *appConfigFile})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+			return 1
+		}
+		*dir = filepath.Join(dataPath, "journal")
+	}
+
+	switch command {
+	case "cat":
+		if *format != "json" && *format != "text" {
+			fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+			return 2
+		}
+		err = catJournal(*dir, filter, *format)
+	case "replay":
+		err = replayJournal(*dir, filter)
+	case "verify":
+		err = verifyJournal(*dir)
+	default:
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "journal %s failed: %v\n", command, err)
+		return 1
+	}
+	return 0
+}
+
+// parseJournalTime empty is unbounded
+func parseJournalTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func catJournal(dir string, filter journal.Filter, format string) error {
+	r, err := journal.NewReader(dir, filter)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if format == "text" {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", record.Seq, record.At().Format(time.RFC3339Nano), record.Type, record.Data)
+			continue
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+}
+
+func verifyJournal(dir string) error {
+	r, err := journal.NewReader(dir, journal.Filter{})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	var count, last uint64
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%d records fine before: %w", count, err)
+		}
+		count, last = count+1, record.Seq
+	}
+	fmt.Fprintf(os.Stderr, "journal verified: %d records, last seq %d\n", count, last)
+	return nil
+}
+
+// journalBalance the last balance of an asset, Delta of balanceUpdate is added to Free
+type journalBalance struct {
+	Exchange general.Exchange
+	Asset    general.Asset
+	Free     decimal.Decimal
+	Locked   decimal.Decimal
+	Time     time.Time
+}
+
+// replayJournal orders are keyed by exchange and client order id, like OrderTrackerService
+func replayJournal(dir string, filter journal.Filter) error {
+	filter.Types = []string{"OrderSubmitted", "OrderUpdated", "BalanceUpdated"}
+	r, err := journal.NewReader(dir, filter)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	orders := make(map[string]*general.OrderState)
+	balances := make(map[string]*journalBalance)
+	var count, last uint64
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		count, last = count+1, record.Seq
+		switch record.Type {
+		case "OrderSubmitted":
+			var submitted general.OrderSubmitted
+			if err := record.Decode(&submitted); err != nil {
+				return fmt.Errorf("record %d: %w", record.Seq, err)
+			}
+			key := string(submitted.Exchange) + "/" + submitted.Plan.ClientOrderID
+			if _, ok := orders[key]; ok {
+				continue
+			}
+			// orders with unknown send status stay PRE_NEW until updated by polling
+			status, reason := general.OrderStatusTypePreNew, submitted.Err
+			if submitted.Err != "" && !submitted.SendStatusUnknown {
+				status = general.OrderStatusTypeRejected
+			}
+			orders[key] = &general.OrderState{Exchange: submitted.Exchange, Plan: submitted.Plan, OrderID: submitted.OrderID,
+				Status: status, RejectReason: reason, UpdateTime: submitted.Time}
+		case "OrderUpdated":
+			var updated general.OrderUpdated
+			if err := record.Decode(&updated); err != nil {
+				return fmt.Errorf("record %d: %w", record.Seq, err)
+			}
+			state := updated.State
+			orders[string(state.Exchange)+"/"+state.Plan.ClientOrderID] = &state
+		case "BalanceUpdated":
+			var updated general.BalanceUpdated
+			if err := record.Decode(&updated); err != nil {
+				return fmt.Errorf("record %d: %w", record.Seq, err)
+			}
+			for _, update := range updated.Balances {
+				balances[string(updated.Exchange)+"/"+string(update.Asset)] = &journalBalance{Exchange: updated.Exchange,
+					Asset: update.Asset, Free: update.Free, Locked: update.Locked, Time: updated.Time}
+			}
+			if updated.Delta != nil {
+				key := string(updated.Exchange) + "/" + string(updated.Delta.Asset)
+				balance, ok := balances[key]
+				if !ok {
+					balance = &journalBalance{Exchange: updated.Exchange, Asset: updated.Delta.Asset}
+					balances[key] = balance
+				}
+				balance.Free, balance.Time = balance.Free.Add(updated.Delta.Change), updated.Time
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EXCHANGE\tCLIENT_ORDER_ID\tSYMBOL\tSIDE\tSTATUS\tFILLED\tQUOTE_FILLED\tUPDATED\tREASON")
+	for _, key := range sortedKeys(orders) {
+		o := orders[key]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", o.Exchange, o.Plan.ClientOrderID, o.Plan.Symbol,
+			o.Plan.Side, o.Status, o.FilledQuantity, o.FilledQuoteVolume, o.UpdateTime.Format(time.RFC3339Nano),
+			o.RejectReason)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "EXCHANGE\tASSET\tFREE\tLOCKED\tUPDATED")
+	for _, key := range sortedKeys(balances) {
+		b := balances[key]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", b.Exchange, b.Asset, b.Free, b.Locked, b.Time.Format(time.RFC3339Nano))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "journal replayed: %d records, last seq %d\n", count, last)
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
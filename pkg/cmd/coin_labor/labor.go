@@ -10,6 +10,7 @@ import (
 	"jasonzhu.com/coin_labor/core/components/log"
 	"jasonzhu.com/coin_labor/core/components/registry"
 	"jasonzhu.com/coin_labor/core/setting"
+	"jasonzhu.com/coin_labor/pkg/plugins/general"
 
 	_ "jasonzhu.com/coin_labor/pkg/plugins"
 	_ "jasonzhu.com/coin_labor/pkg/services"
@@ -26,6 +27,7 @@ func NewLaborServer() *LaborServerImpl {
 		childRoutines: childRoutines,
 		log:           log.New("server"),
 		cfg:           setting.NewCfg(),
+		initializedC:  make(chan struct{}),
 	}
 }
 
@@ -37,11 +39,14 @@ type LaborServerImpl struct {
 	cfg                *setting.Cfg
 	shutdownReason     string
 	shutdownInProgress bool
+	// closed once services are initialized, configuration is reloaded after it only
+	initializedC chan struct{}
 }
 
 func (g *LaborServerImpl) Run() (err error) {
 	g.loadConfiguration()
 	g.writePIDFile()
+	general.InitPlugins(setting.ExchangesEnabled)
 
 	serviceGraph := inject.Graph{}
 	err = serviceGraph.Provide(&inject.Object{Value: bus.GetBus()})
@@ -83,6 +88,7 @@ func (g *LaborServerImpl) Run() (err error) {
 		}
 	}
 	g.log.Info("All services Initialized.")
+	close(g.initializedC)
 
 	// Start background services
 	for _, srv := range services {
@@ -5,11 +5,14 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
 	"jasonzhu.com/coin_labor/core/setting"
 	"net"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 func (g *LaborServerImpl) loadConfiguration() {
@@ -23,11 +26,52 @@ func (g *LaborServerImpl) loadConfiguration() {
 		fmt.Fprintf(os.Stderr, "Failed to start labor. error: %s\n", err.Error())
 		os.Exit(1)
 	}
+	if err := alerting.ReadAlertingConfig(setting.Raw); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start labor. error: %s\n", err.Error())
+		os.Exit(1)
+	}
+	// secrets are loaded before plugins, which are skipped without credentials
+	if err := setting.LoadSecrets(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start labor. error: %s\n", err.Error())
+		os.Exit(1)
+	}
 
 	g.log.Info("Starting " + setting.ApplicationName)
 	g.cfg.LogConfigSources()
 }
 
+// reloadConfiguration on SIGHUP, services apply the new values on setting.ConfigChanged.
+// An invalid configuration is rejected and the loaded one is kept.
+// A SIGHUP before services are initialized is ignored, they read the settings in Init.
+func (g *LaborServerImpl) reloadConfiguration() {
+	select {
+	case <-g.initializedC:
+	default:
+		g.log.Warn("Configuration reload ignored, services are starting")
+		return
+	}
+	changed, err := g.cfg.Reload()
+	if err != nil {
+		g.log.Error("Configuration reload rejected", "error", err)
+		alerting.Notify(err, "configuration reload rejected, the loaded one is kept")
+		return
+	}
+	if err := alerting.ReadAlertingConfig(changed.File); err != nil {
+		g.log.Error("Alerting reload rejected", "error", err)
+		alerting.Notify(err, "alerting reload rejected, the loaded backends are kept")
+	}
+	if len(changed.Restart) > 0 {
+		g.log.Warn("Configuration changes need a restart", "keys", strings.Join(changed.Restart, ","))
+	}
+	g.log.Info("Configuration reloaded", "changed", strings.Join(changed.Changed, ","))
+	if len(changed.Changed) == 0 {
+		return
+	}
+	if err := bus.Publish(changed); err != nil {
+		g.log.Error("Failed to apply reloaded configuration", "error", err)
+	}
+}
+
 func (g *LaborServerImpl) Shutdown(reason string) {
 	g.log.Info("Shutdown started", "reason", reason)
 	g.shutdownReason = reason
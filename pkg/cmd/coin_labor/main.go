@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/setting"
 	"jasonzhu.com/coin_labor/core/util"
 	"os"
 	"os/signal"
@@ -15,6 +16,11 @@ var configFile = flag.String("config", "", "path to config file")
 var appConfigFile = flag.String("app-config", "", "path to app config file")
 var pidFile = flag.String("pidfile", "", "path to pid file")
 
+// set by ldflags of build.go
+var version = "dev"
+var commit = "unknown"
+var buildBranch = "unknown"
+
 func main() {
 	flag.Parse()
 
@@ -22,6 +28,17 @@ func main() {
 		*configFile = "conf/dev.ini"
 	}
 
+	if flag.Arg(0) == "secrets" {
+		os.Exit(runSecrets(flag.Args()[1:]))
+	}
+	if flag.Arg(0) == "journal" {
+		os.Exit(runJournal(flag.Args()[1:]))
+	}
+
+	setting.BuildVersion = version
+	setting.BuildCommit = commit
+	setting.BuildBranch = buildBranch
+
 	fmt.Println("starting... time: " + util.UnixToStr(time.Now().Unix()))
 	server := NewLaborServer()
 
@@ -47,8 +64,9 @@ func listenToSystemSignals(server *LaborServerImpl) {
 	for {
 		select {
 		case sig := <-sighupChan:
-			fmt.Printf("System signal: %s, type: SIGHUP", sig)
+			fmt.Printf("System signal: %s, type: SIGHUP\n", sig)
 			log.Reload()
+			server.reloadConfiguration()
 		case sig := <-signalChan:
 			go func() {
 				// force kill
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"jasonzhu.com/coin_labor/core/setting"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	secretsUsage = `usage: coin_labor secrets <command>
+
+commands:
+  encrypt   encrypt the plaintext secrets file in place
+  edit      decrypt into $EDITOR, and encrypt again when saved
+  rotate    re-encrypt with a new master password
+
+The master password is read from %s, the file of %s, or prompted.
+The new master password of encrypt and rotate is read from %s, or prompted twice.
+`
+	secretsNewMasterPasswordEnv = "GF_SECRETS_NEW_MASTER_PASSWORD"
+)
+
+// runSecrets the secrets file is the one loaded by setting.LoadSecrets, see setting.SecretCfg
+func runSecrets(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, secretsUsage, setting.SecretsMasterPasswordEnv, setting.SecretsMasterPasswordFileEnv,
+			secretsNewMasterPasswordEnv)
+		return 2
+	}
+	// the file is not loaded, a corrupted or locked one can still be replaced
+	path, err := setting.SecretsPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "secrets %s failed: %v\n", args[0], err)
+		return 1
+	}
+	switch args[0] {
+	case "encrypt":
+		err = encryptSecrets(path)
+	case "edit":
+		err = editSecrets(path)
+	case "rotate":
+		err = rotateSecrets(path)
+	default:
+		err = fmt.Errorf("unknown command %q", args[0])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "secrets %s failed: %v\n", args[0], err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "secrets %s done: %s\n", args[0], path)
+	return 0
+}
+
+func newMasterPassword() ([]byte, error) {
+	if env := os.Getenv(secretsNewMasterPasswordEnv); env != "" {
+		return []byte(env), nil
+	}
+	first, err := setting.PromptPassphrase("New master password: ")
+	if err != nil {
+		return nil, err
+	}
+	second, err := setting.PromptPassphrase("Repeat new master password: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(first, second) {
+		return nil, errors.New("passwords do not match")
+	}
+	return first, nil
+}
+
+// readEncryptedSecrets returns the plaintext and the master password unlocking it
+func readEncryptedSecrets(path string) ([]byte, []byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !setting.IsEncryptedSecrets(data) {
+		return nil, nil, errors.New("secrets file is not encrypted, run: coin_labor secrets encrypt")
+	}
+	passphrase, err := setting.UnlockPassphrase()
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := setting.DecryptSecrets(data, passphrase)
+	return plaintext, passphrase, err
+}
+
+func writeEncryptedSecrets(path string, plaintext, passphrase []byte) error {
+	var cfg setting.SecretCfg
+	if err := yaml.Unmarshal(plaintext, &cfg); err != nil {
+		return fmt.Errorf("invalid secrets: %w", err)
+	}
+	data, err := setting.EncryptSecrets(plaintext, passphrase)
+	if err != nil {
+		return err
+	}
+	return setting.WriteSecretsFile(path, data)
+}
+
+func encryptSecrets(path string) error {
+	plaintext, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if setting.IsEncryptedSecrets(plaintext) {
+		return errors.New("secrets file is encrypted already")
+	}
+	passphrase, err := newMasterPassword()
+	if err != nil {
+		return err
+	}
+	return writeEncryptedSecrets(path, plaintext, passphrase)
+}
+
+func rotateSecrets(path string) error {
+	plaintext, _, err := readEncryptedSecrets(path)
+	if err != nil {
+		return err
+	}
+	passphrase, err := newMasterPassword()
+	if err != nil {
+		return err
+	}
+	return writeEncryptedSecrets(path, plaintext, passphrase)
+}
+
+// editSecrets the plaintext only lives in a private temporary directory while the editor runs
+func editSecrets(path string) error {
+	plaintext, passphrase, err := readEncryptedSecrets(path)
+	if err != nil {
+		return err
+	}
+	dir, err := ioutil.TempDir("", "coin_labor-secrets-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "secrets.conf.yml")
+	if err := ioutil.WriteFile(tmp, plaintext, 0600); err != nil {
+		return err
+	}
+
+	// editors may take arguments, like: code --wait
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], tmp)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	edited, err := ioutil.ReadFile(tmp)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, plaintext) {
+		fmt.Fprintln(os.Stderr, "no changes")
+		return nil
+	}
+	return writeEncryptedSecrets(path, edited, passphrase)
+}
@@ -0,0 +1,362 @@
+package plugins
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-macaron/gzip"
+	"gopkg.in/macaron.v1"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	AdminServiceName = "AdminService"
+
+	adminShutdownTimeout  = 5 * time.Second
+	adminDefaultLimit     = 50
+	adminOpportunityQueue = 100
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         AdminServiceName,
+		Instance:     &AdminService{},
+		InitPriority: registry.Low,
+	})
+}
+
+// AdminService serves the admin HTTP API: GET endpoints for status, balances, orders, opportunities and trades,
+// POST actions to cancel orders and to pause or resume trading, protected by bearer token.
+type AdminService struct {
+	lg        log.Logger
+	Bus       bus.Bus           `inject:""`
+	Inventory *InventoryService `inject:""`
+	Operator  *OperatorService  `inject:""`
+
+	opportunities *OpportunityLog
+	opportunityC  *bus.Subscription[*Opportunity]
+	server        *http.Server
+	token         atomic.Value // string, rotated on reload
+}
+
+func (s *AdminService) Init() error {
+	s.lg = log.New("service.admin")
+	s.opportunities = NewOpportunityLog(setting.AdminRecentOpportunities)
+	s.opportunityC = Opportunities.Subscribe(AdminServiceName, adminOpportunityQueue, bus.PolicyDrop)
+	s.Bus.AddEventListener(s.onConfigChanged)
+	s.token.Store(setting.AdminToken)
+	if setting.AdminToken == "" {
+		s.lg.Warn("admin token is not set, POST actions are refused")
+	}
+	s.server = &http.Server{
+		Addr:              setting.AdminHttpAddr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return nil
+}
+
+func (s *AdminService) IsDisabled() bool {
+	return !setting.AdminEnabled
+}
+
+func (s *AdminService) Run(ctx context.Context) error {
+	defer s.opportunityC.Close()
+	go s.opportunityC.Listen(ctx, s.onOpportunity)
+
+	errC := make(chan error, 1)
+	go func() {
+		s.lg.Info("admin server listening", "addr", s.server.Addr)
+		errC <- s.server.ListenAndServe()
+	}()
+	select {
+	case err := <-errC:
+		return fmt.Errorf("admin server stopped: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			s.lg.Warn("failed to shutdown admin server", "err", err)
+		}
+		s.lg.Info("Stopped")
+		return nil
+	}
+}
+
+func (s *AdminService) onOpportunity(opportunity *Opportunity) {
+	s.opportunities.Add(*opportunity)
+}
+
+func (s *AdminService) routes() http.Handler {
+	m := macaron.New()
+	m.Use(macaron.Recovery())
+	m.Use(gzip.Gziper())
+	m.Use(macaron.Renderer())
+	m.Group("/api", func() {
+		m.Get("/status", s.wrap(s.status))
+		m.Get("/balances", s.wrap(s.balances))
+		m.Get("/orders", s.wrap(s.openOrders))
+		m.Get("/opportunities", s.wrap(s.recentOpportunities))
+		m.Get("/trades", s.wrap(s.recentTrades))
+		m.Post("/orders/cancel", s.authorize, s.wrap(s.cancelOrder))
+		m.Post("/trading/pause", s.authorize, s.wrap(s.pauseTrading))
+		m.Post("/trading/resume", s.authorize, s.wrap(s.resumeTrading))
+	})
+	m.NotFound(func(ctx *macaron.Context) {
+		s.writeError(ctx, &adminError{code: http.StatusNotFound, msg: "not found"})
+	})
+	return m
+}
+
+// adminError is written as {"error": msg} with its status code
+type adminError struct {
+	code int
+	msg  string
+}
+
+func (e *adminError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &adminError{code: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+type adminHandler func(r *http.Request) (interface{}, error)
+
+// wrap writes the result of handler as json
+func (s *AdminService) wrap(handler adminHandler) macaron.Handler {
+	return func(ctx *macaron.Context) {
+		res, err := handler(ctx.Req.Request)
+		if err != nil {
+			s.writeError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+// authorize POST actions by the bearer token, the chain stops once the error is written
+func (s *AdminService) authorize(ctx *macaron.Context) {
+	if !s.authorized(ctx.Req.Request) {
+		s.lg.Warn("unauthorized admin action", "path", ctx.Req.URL.Path, "remote", ctx.RemoteAddr())
+		s.writeError(ctx, &adminError{code: http.StatusUnauthorized, msg: "unauthorized"})
+	}
+}
+
+// onConfigChanged the token is rotated, the address needs a restart
+func (s *AdminService) onConfigChanged(event *setting.ConfigChanged) error {
+	if event.SectionChanged("admin") {
+		s.token.Store(setting.AdminToken)
+	}
+	return nil
+}
+
+func (s *AdminService) authorized(r *http.Request) bool {
+	expected := s.token.Load().(string)
+	if expected == "" {
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+func (s *AdminService) writeError(ctx *macaron.Context, err error) {
+	code := http.StatusInternalServerError
+	var adminErr *adminError
+	if errors.As(err, &adminErr) {
+		code = adminErr.code
+	}
+	ctx.JSON(code, map[string]string{"error": err.Error()})
+}
+
+type serviceStatus struct {
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled"`
+}
+
+type adminStatus struct {
+	Services   []serviceStatus `json:"services"`
+	Health     map[string]bool `json:"health"`
+	AllHealthy bool            `json:"allHealthy"`
+	Trading    TradingState    `json:"trading"`
+}
+
+func (s *AdminService) status(_ *http.Request) (interface{}, error) {
+	res := &adminStatus{
+		Health:     make(map[string]bool),
+		AllHealthy: DefaultHealthChecker.IsAllFeaturesHealthy(),
+		Trading:    DefaultTradingSwitch.State(),
+	}
+	for _, descriptor := range registry.GetServices() {
+		res.Services = append(res.Services, serviceStatus{
+			Name:     descriptor.Name,
+			Disabled: registry.IsDisabled(descriptor.Instance),
+		})
+	}
+	for feature, state := range DefaultHealthChecker.States() {
+		res.Health[feature.String()] = bool(state)
+	}
+	return res, nil
+}
+
+type adminBalances struct {
+	Exchanges map[Exchange]map[Asset]string `json:"exchanges"`
+	InTransit map[Asset]string              `json:"inTransit"`
+}
+
+func (s *AdminService) balances(_ *http.Request) (interface{}, error) {
+	inventory := s.Inventory.Inventory()
+	res := &adminBalances{
+		Exchanges: make(map[Exchange]map[Asset]string),
+		InTransit: make(map[Asset]string),
+	}
+	for _, exchange := range inventory.Exchanges() {
+		balances := make(map[Asset]string)
+		for asset, amount := range inventory.Balances(exchange) {
+			if !amount.IsZero() {
+				balances[asset] = amount.String()
+			}
+		}
+		res.Exchanges[exchange] = balances
+	}
+	for asset, amount := range inventory.InTransitAll() {
+		if !amount.IsZero() {
+			res.InTransit[asset] = amount.String()
+		}
+	}
+	return res, nil
+}
+
+// parseExchangeSymbol query or body fields exchange and symbol, e.g. binance and INJUSDT
+func parseExchangeSymbol(exchange, symbol string) (ExManager, Symbol, error) {
+	plugin := GetExPluginByExchange(Exchange(exchange))
+	if plugin == nil {
+		return nil, Symbol{}, badRequest("exchange %q is not supported", exchange)
+	}
+	parsed := ParseSymbol(symbol)
+	if parsed.BaseAsset == UnKnown {
+		return nil, Symbol{}, badRequest("symbol %q is invalid", symbol)
+	}
+	return plugin, parsed, nil
+}
+
+func parseLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return adminDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, badRequest("limit %q is invalid", value)
+	}
+	return limit, nil
+}
+
+func (s *AdminService) openOrders(r *http.Request) (interface{}, error) {
+	plugin, symbol, err := parseExchangeSymbol(r.URL.Query().Get("exchange"), r.URL.Query().Get("symbol"))
+	if err != nil {
+		return nil, err
+	}
+	orders, err := plugin.GetOrderInterface().ListOpenOrdersOfSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []*Order{}
+	}
+	return orders, nil
+}
+
+func (s *AdminService) recentOpportunities(r *http.Request) (interface{}, error) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.opportunities.Recent(limit), nil
+}
+
+func (s *AdminService) recentTrades(r *http.Request) (interface{}, error) {
+	plugin, symbol, err := parseExchangeSymbol(r.URL.Query().Get("exchange"), r.URL.Query().Get("symbol"))
+	if err != nil {
+		return nil, err
+	}
+	limit, err := parseLimit(r)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := plugin.GetTradeInterface().ListTrades(symbol, TradeQuery{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	if trades == nil {
+		trades = []*Trade{}
+	}
+	return trades, nil
+}
+
+type cancelOrderRequest struct {
+	Exchange      string `json:"exchange"`
+	Symbol        string `json:"symbol"`
+	OrderID       string `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+}
+
+func (s *AdminService) cancelOrder(r *http.Request) (interface{}, error) {
+	var req cancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, badRequest("invalid body: %v", err)
+	}
+	plugin, symbol, err := parseExchangeSymbol(req.Exchange, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if req.OrderID == "" && req.ClientOrderID == "" {
+		return nil, badRequest("orderId or clientOrderId is required")
+	}
+	status, err := plugin.GetOrderInterface().CancelOrder(symbol, req.OrderID, req.ClientOrderID)
+	if err != nil {
+		return nil, err
+	}
+	s.lg.Warn("order canceled by admin", "exchange", req.Exchange, "symbol", symbol, "orderId", req.OrderID,
+		"clientOrderId", req.ClientOrderID, "status", status)
+	return map[string]OrderStatusType{"status": status}, nil
+}
+
+type pauseTradingRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (s *AdminService) pauseTrading(r *http.Request) (interface{}, error) {
+	var req pauseTradingRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, badRequest("invalid body: %v", err)
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "paused by admin"
+	}
+	if s.Operator.PauseTrading(req.Reason) {
+		s.lg.Warn("trading paused by admin", "remote", r.RemoteAddr)
+	}
+	return DefaultTradingSwitch.State(), nil
+}
+
+func (s *AdminService) resumeTrading(r *http.Request) (interface{}, error) {
+	if s.Operator.ResumeTrading() {
+		s.lg.Warn("trading resumed by admin", "remote", r.RemoteAddr)
+	}
+	return DefaultTradingSwitch.State(), nil
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"github.com/adshao/go-binance/v2"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sync"
 )
 
 type BaseInfoManager struct {
 	ExchangeInfo *binance.ExchangeInfo
 	SymbolsMap   map[string]*SymbolBasicInfo
+	rwM          sync.RWMutex
 	client       *binance.Client
 }
 
@@ -28,23 +30,21 @@ func (s *BaseInfoManager) ServerTime() (serverTime int64, err error) {
 }
 
 // FetchExchangeInfo demo: https://api.binance.com/api/v3/exchangeInfo?symbol=BNBBTC
-func (s *BaseInfoManager) syncExchangeInfo() (*binance.ExchangeInfo, error) {
-	symbols := make([]string, len(supportedAssets))
-	for i, asset := range supportedAssets {
-		symbols[i] = getSymbolAlias(buildSymbolWithDefaultQuoteCoin(asset))
-	}
-	res, err := s.client.NewExchangeInfoService().Symbols(symbols...).Do(context.Background())
+// All symbols are fetched, so pairs quoted by BTC, ETH, USDC, FDUSD etc. can be mapped as well.
+func (s *BaseInfoManager) syncExchangeInfo() ([]*SymbolInfoChange, error) {
+	res, err := s.client.NewExchangeInfoService().Do(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	s.ExchangeInfo = res
+	symbolsMap := make(map[string]*SymbolBasicInfo)
 	for _, symbol := range res.Symbols {
 		priceFilter := symbol.PriceFilter()
 		lotSizeFilter := symbol.LotSizeFilter()
 		minNotionalFilter := symbol.MinNotionalFilter()
 		info := &SymbolBasicInfo{
 			Symbol:              symbol.Symbol,
+			Status:              SymbolStatus(symbol.Status),
 			BaseAsset:           symbol.BaseAsset,
 			BaseAssetPrecision:  int32(symbol.BaseAssetPrecision),
 			QuoteAsset:          symbol.QuoteAsset,
@@ -65,30 +65,41 @@ func (s *BaseInfoManager) syncExchangeInfo() (*binance.ExchangeInfo, error) {
 		if minNotionalFilter != nil {
 			info.MinNotional = NewDecimalFromStringIgnoreErr(minNotionalFilter.MinNotional)
 		}
-		s.SymbolsMap[symbol.Symbol] = info
+		symbolsMap[symbol.Symbol] = info
+		symbolMapper.Put(symbol.Symbol, symbol.BaseAsset, symbol.QuoteAsset)
 	}
-	return res, nil
+
+	s.rwM.Lock()
+	defer s.rwM.Unlock()
+	changes := DiffSymbolsBasicInfo(Binance, s.SymbolsMap, symbolsMap, newSymbolFromString, isAssetSupported)
+	s.ExchangeInfo = res
+	s.SymbolsMap = symbolsMap
+	return changes, nil
+}
+
+func (s *BaseInfoManager) RefreshExchangeInfo() ([]*SymbolInfoChange, error) {
+	return s.syncExchangeInfo()
 }
 
 func (s *BaseInfoManager) GetSymbolBasicInfo(symbol Symbol) (*SymbolBasicInfo, error) {
-	symbol2USDT := getSymbolAlias(symbol)
-	info := s.SymbolsMap[symbol2USDT]
+	alias := getSymbolAlias(symbol)
+	s.rwM.RLock()
+	info := s.SymbolsMap[alias]
+	s.rwM.RUnlock()
 	if info == nil {
-		return nil, errors.New(fmt.Sprintf("symbol[%s] not supported", symbol2USDT))
+		return nil, errors.New(fmt.Sprintf("symbol[%s] not supported", alias))
 	}
 	return info, nil
 }
 
+// GetSymbolsBasicInfo returns the symbols of supported assets quoted by the enabled quote assets, halted ones included.
 func (s *BaseInfoManager) GetSymbolsBasicInfo() map[Symbol]*SymbolBasicInfo {
+	s.rwM.RLock()
+	defer s.rwM.RUnlock()
 	var res = make(map[Symbol]*SymbolBasicInfo)
-	for _, asset := range supportedAssets {
-		symbol := Symbol{
-			BaseAsset:  asset,
-			QuoteAsset: DefaultQuoteCoin,
-		}
-		symbol2USDT := getSymbolAlias(symbol)
-		info := s.SymbolsMap[symbol2USDT]
-		if info != nil {
+	for alias, info := range s.SymbolsMap {
+		symbol := newSymbolFromString(alias)
+		if isAssetSupported(symbol) && IsQuoteAssetEnabled(symbol.QuoteAsset) {
 			res[symbol] = info
 		}
 	}
@@ -2,13 +2,25 @@ package binance
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
+	"github.com/shopspring/decimal"
 	"jasonzhu.com/coin_labor/core/components/log"
-	"jasonzhu.com/coin_labor/core/components/simplejson"
 	"jasonzhu.com/coin_labor/core/setting"
 	. "jasonzhu.com/coin_labor/core/util/http"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	convertGetQuoteEndpoint    = "/sapi/v1/convert/getQuote"
+	convertAcceptQuoteEndpoint = "/sapi/v1/convert/acceptQuote"
+	convertOrderStatusEndpoint = "/sapi/v1/convert/orderStatus"
+
+	// convertValidTime validity of quote: 10s, 30s, 1m or 2m
+	convertValidTime = "10s"
 )
 
 type ConvertManager struct {
@@ -22,34 +34,129 @@ func newConvertManager() *ConvertManager {
 	return &ConvertManager{
 		lg:     log.New("binance.convert_manager"),
 		secret: secret,
-		client: NewHMACClient(secret, "https://api.binance.com", "X-MBX-APIKEY"),
+		client: NewHMACClient(secret, getAPIEndpoint(), apiKeyHeader),
 	}
 }
 
-func (s *ConvertManager) GetQuote() error {
+type bConvertQuote struct {
+	QuoteID        string          `json:"quoteId"`
+	Ratio          decimal.Decimal `json:"ratio"`
+	InverseRatio   decimal.Decimal `json:"inverseRatio"`
+	ValidTimestamp int64           `json:"validTimestamp"`
+	ToAmount       decimal.Decimal `json:"toAmount"`
+	FromAmount     decimal.Decimal `json:"fromAmount"`
+}
+
+type bConvertOrder struct {
+	OrderID     json.Number     `json:"orderId"`
+	OrderStatus string          `json:"orderStatus"`
+	FromAsset   string          `json:"fromAsset"`
+	FromAmount  decimal.Decimal `json:"fromAmount"`
+	ToAsset     string          `json:"toAsset"`
+	ToAmount    decimal.Decimal `json:"toAmount"`
+	Ratio       decimal.Decimal `json:"ratio"`
+	CreateTime  int64           `json:"createTime"`
+}
+
+// GetQuote https://binance-docs.github.io/apidocs/spot/cn/#trade-3
+// fromAmount is the amount deducted after done, toAmount is the amount received after done, only one of them is sent.
+func (s *ConvertManager) GetQuote(from, to Asset, fromAmount, toAmount decimal.Decimal) (quote *ConvertQuote, err error) {
+	start := time.Now()
+	defer func() { uploadMetrics(from, "ConvertGetQuote", err, start) }()
+
 	r := &Request{
 		Method:   http.MethodPost,
-		Endpoint: "/sapi/v1/convert/getQuote",
+		Endpoint: convertGetQuoteEndpoint,
 		SecType:  SecTypeSigned,
 	}
+	r.SetParam("fromAsset", from)
+	r.SetParam("toAsset", to)
+	if fromAmount.IsPositive() {
+		r.SetParam("fromAmount", fromAmount.String())
+	} else if toAmount.IsPositive() {
+		r.SetParam("toAmount", toAmount.String())
+	} else {
+		return nil, errors.New("either fromAmount or toAmount is required")
+	}
+	r.SetParam("validTime", convertValidTime)
+	data, err := s.client.CallAPI(context.Background(), r)
+	if err != nil {
+		return nil, err
+	}
+	res := new(bConvertQuote)
+	if err = json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	if res.QuoteID == "" {
+		return nil, errors.New("no quote available: " + string(data))
+	}
+	s.lg.Info("convert quote", "from", from, "to", to, "fromAmount", res.FromAmount, "toAmount", res.ToAmount, "ratio", res.Ratio)
+	return &ConvertQuote{
+		QuoteID:        res.QuoteID,
+		FromAsset:      from,
+		ToAsset:        to,
+		FromAmount:     res.FromAmount,
+		ToAmount:       res.ToAmount,
+		Ratio:          res.Ratio,
+		InverseRatio:   res.InverseRatio,
+		ValidTimestamp: res.ValidTimestamp,
+	}, nil
+}
+
+// AcceptQuote accept the quote within its validity
+func (s *ConvertManager) AcceptQuote(quoteID string) (order *ConvertOrder, err error) {
+	start := time.Now()
+	defer func() { uploadMetrics(UnKnown, "ConvertAcceptQuote", err, start) }()
 
-	//fromAsset	STRING	YES
-	//toAsset	STRING	YES
-	//fromAmount	DECIMAL	EITHER	这是成交后将被扣除的金额
-	//toAmount	DECIMAL	EITHER	这是成交后将会获得的金额
-	r.SetParam("fromAsset", INJ)
-	r.SetParam("toAsset", USDT)
-	//r.SetParam("fromAmount", 10)
-	r.SetParam("toAmount", 3)
+	r := &Request{
+		Method:   http.MethodPost,
+		Endpoint: convertAcceptQuoteEndpoint,
+		SecType:  SecTypeSigned,
+	}
+	r.SetParam("quoteId", quoteID)
 	data, err := s.client.CallAPI(context.Background(), r)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	res := new(bConvertOrder)
+	if err = json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	s.lg.Warn("convert quote accepted", "quoteId", quoteID, "orderId", res.OrderID, "status", res.OrderStatus)
+	return convertToConvertOrder(res), nil
+}
+
+func (s *ConvertManager) GetConvertOrder(orderID string) (order *ConvertOrder, err error) {
+	r := &Request{
+		Method:   http.MethodGet,
+		Endpoint: convertOrderStatusEndpoint,
+		SecType:  SecTypeSigned,
 	}
-	j, err := simplejson.NewJson(data)
+	r.SetParam("orderId", orderID)
+	data, err := s.client.CallAPI(context.Background(), r)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	res := new(bConvertOrder)
+	if err = json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	return convertToConvertOrder(res), nil
+}
+
+func convertToConvertOrder(res *bConvertOrder) *ConvertOrder {
+	orderID := res.OrderID.String()
+	if id, err := res.OrderID.Int64(); err == nil {
+		orderID = strconv.FormatInt(id, 10)
+	}
+	return &ConvertOrder{
+		OrderID:    orderID,
+		Status:     ConvertOrderStatus(res.OrderStatus),
+		FromAsset:  Asset(res.FromAsset),
+		ToAsset:    Asset(res.ToAsset),
+		FromAmount: res.FromAmount,
+		ToAmount:   res.ToAmount,
+		Ratio:      res.Ratio,
+		CreateTime: res.CreateTime,
 	}
-	fmt.Println(string(data))
-	fmt.Println(j)
-	return nil
 }
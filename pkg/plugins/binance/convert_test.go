@@ -2,11 +2,13 @@ package binance
 
 import (
 	"fmt"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/pkg/plugins/general"
 	"testing"
 )
 
 func TestConvertManager_GetQuote(t *testing.T) {
 	manager := newConvertManager()
-	err := manager.GetQuote()
-	fmt.Println(err)
+	quote, err := manager.GetQuote(general.INJ, general.USDT, decimal.Zero, decimal.NewFromInt(3))
+	fmt.Println(quote, err)
 }
@@ -0,0 +1,190 @@
+package binance
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2/futures"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"strconv"
+	"time"
+)
+
+type FuturesAccountManager struct {
+	lg                 log.Logger
+	secret             *setting.Secret
+	client             *futures.Client
+	balancesMapAtStart map[Asset]Balance
+}
+
+func newFuturesAccountManager() AccountInterface {
+	secret := GetSecretsForExchanger(BinanceFutures)
+	return &FuturesAccountManager{
+		lg:     log.New("binance.futures_account_manager"),
+		secret: secret,
+		client: getFuturesClient(secret),
+	}
+}
+
+// GetAccountInfo balances of margin assets, Free is the max withdraw amount and Locked is the initial margin.
+// Positions have no mark price and liquidation price, use DerivativesInterface.GetPositions for them.
+func (s *FuturesAccountManager) GetAccountInfo() (*Account, error) {
+	res, err := s.client.NewGetAccountService().Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var balances []Balance
+	for _, asset := range res.Assets {
+		wallet := NewDecimalFromStringIgnoreErr(asset.WalletBalance)
+		if !wallet.IsPositive() {
+			continue
+		}
+		balances = append(balances, Balance{
+			Asset:  ToAsset(asset.Asset),
+			Free:   NewDecimalFromStringIgnoreErr(asset.MaxWithdrawAmount),
+			Locked: NewDecimalFromStringIgnoreErr(asset.InitialMargin),
+		})
+	}
+	if s.balancesMapAtStart == nil {
+		s.balancesMapAtStart = make(map[Asset]Balance)
+		for _, balance := range balances {
+			s.balancesMapAtStart[balance.Asset] = balance
+		}
+	}
+
+	var positions []*Position
+	for _, p := range res.Positions {
+		position := &Position{
+			Symbol:           newFuturesSymbolFromString(p.Symbol),
+			PositionSide:     PositionSide(p.PositionSide),
+			Amount:           NewDecimalFromStringIgnoreErr(p.PositionAmt),
+			EntryPrice:       NewDecimalFromStringIgnoreErr(p.EntryPrice),
+			UnrealizedProfit: NewDecimalFromStringIgnoreErr(p.UnrealizedProfit),
+			Notional:         NewDecimalFromStringIgnoreErr(p.Notional),
+			IsolatedMargin:   NewDecimalFromStringIgnoreErr(p.IsolatedWallet),
+			MarginType:       MarginTypeCrossed,
+			UpdateTime:       p.UpdateTime,
+		}
+		if p.Isolated {
+			position.MarginType = MarginTypeIsolated
+		}
+		position.Leverage, _ = strconv.Atoi(p.Leverage)
+		if !position.IsEmpty() {
+			positions = append(positions, position)
+		}
+	}
+
+	a := &Account{
+		CanTrade:    res.CanTrade,
+		CanWithdraw: res.CanWithdraw,
+		CanDeposit:  res.CanDeposit,
+		UpdateTime:  uint64(res.UpdateTime),
+		AccountType: TradingDerivatives,
+		Margin: &Margin{
+			TotalWalletBalance:    NewDecimalFromStringIgnoreErr(res.TotalWalletBalance),
+			TotalMarginBalance:    NewDecimalFromStringIgnoreErr(res.TotalMarginBalance),
+			TotalUnrealizedProfit: NewDecimalFromStringIgnoreErr(res.TotalUnrealizedProfit),
+			TotalInitialMargin:    NewDecimalFromStringIgnoreErr(res.TotalInitialMargin),
+			TotalMaintMargin:      NewDecimalFromStringIgnoreErr(res.TotalMaintMargin),
+			AvailableBalance:      NewDecimalFromStringIgnoreErr(res.AvailableBalance),
+		},
+		Positions: positions,
+	}
+	a.InitBalances(balances)
+	return a, nil
+}
+
+func (s *FuturesAccountManager) GetBalanceAtStart(asset Asset) *Balance {
+	if b, ok := s.balancesMapAtStart[asset]; ok {
+		return &b
+	}
+	return nil
+}
+
+// WsWatchUserDataChanges ORDER_TRADE_UPDATE is converted to executionReport and ACCOUNT_UPDATE to
+// outboundAccountPosition, so order tracking works the same as spot.
+func (s *FuturesAccountManager) WsWatchUserDataChanges(ctx context.Context, eventC chan *UserDataEvent) error {
+	listenKey, err := s.client.NewStartUserStreamService().Do(context.Background())
+	if err != nil {
+		return err
+	}
+	go s.keepaliveListenKey(ctx, listenKey)
+
+	wsHandler := func(fEvent *futures.WsUserDataEvent) {
+		if event := convertFuturesUserDataEvent(fEvent); event != nil {
+			eventC <- event
+		}
+	}
+	errHandler := func(err error) {
+		s.lg.Error("failed to fetch account changing messages from binance futures websocket.", "err", err)
+		alerting.NotifyRightNow(err, "error occurred when fetching UserData from binance futures websocket.")
+	}
+	doneC, stopC, err := futures.WsUserDataServe(listenKey, wsHandler, errHandler)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		close(stopC)
+		<-doneC
+	case <-doneC:
+	}
+	return nil
+}
+
+func (s *FuturesAccountManager) keepaliveListenKey(ctx context.Context, listenKey string) {
+	ticker := time.NewTicker(25 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(context.Background()); err != nil {
+				s.lg.Error("failed to keepalive listen key for futures user stream", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func convertFuturesUserDataEvent(fEvent *futures.WsUserDataEvent) *UserDataEvent {
+	event := &UserDataEvent{
+		Time:            uint64(fEvent.Time),
+		TransactionTime: fEvent.TransactionTime,
+	}
+	switch fEvent.Event {
+	case futures.UserDataEventTypeOrderTradeUpdate:
+		o := fEvent.OrderTradeUpdate
+		event.Event = UserDataEventTypeExecutionReport
+		filledVolume := NewDecimalFromStringIgnoreErr(o.AccumulatedFilledQty)
+		event.OrderUpdate = WsOrderUpdate{
+			Symbol:            newFuturesSymbolFromString(o.Symbol),
+			ClientOrderId:     o.ClientOrderID,
+			Side:              SideType(o.Side),
+			Type:              OrderType(o.Type),
+			TimeInForce:       TimeInForceType(o.TimeInForce),
+			Volume:            NewDecimalFromStringIgnoreErr(o.OriginalQty),
+			Price:             NewDecimalFromStringIgnoreErr(o.OriginalPrice),
+			Status:            OrderStatusType(o.Status),
+			Id:                o.ID,
+			FilledVolume:      filledVolume,
+			LatestPrice:       NewDecimalFromStringIgnoreErr(o.LastFilledPrice),
+			TransactionTime:   o.TradeTime,
+			IsMaker:           o.IsMaker,
+			FilledQuoteVolume: filledVolume.Mul(NewDecimalFromStringIgnoreErr(o.AveragePrice)),
+		}
+	case futures.UserDataEventTypeAccountUpdate:
+		event.Event = UserDataEventTypeOutboundAccountPosition
+		event.AccountUpdateTime = fEvent.TransactionTime
+		for _, b := range fEvent.AccountUpdate.Balances {
+			event.AccountUpdate.WsAccountUpdates = append(event.AccountUpdate.WsAccountUpdates, WsAccountUpdate{
+				Asset: ToAsset(b.Asset),
+				Free:  NewDecimalFromStringIgnoreErr(b.CrossWalletBalance),
+			})
+		}
+	default:
+		return nil
+	}
+	return event
+}
@@ -0,0 +1,106 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"github.com/adshao/go-binance/v2/futures"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sync"
+)
+
+type FuturesBaseInfoManager struct {
+	SymbolsMap map[string]*SymbolBasicInfo
+	rwM        sync.RWMutex
+	client     *futures.Client
+}
+
+func newFuturesBaseInfoManager() (BaseInterface, error) {
+	s := &FuturesBaseInfoManager{
+		SymbolsMap: make(map[string]*SymbolBasicInfo),
+		client:     getFuturesClient(nil),
+	}
+	_, err := s.syncExchangeInfo()
+	return s, err
+}
+
+func (s *FuturesBaseInfoManager) ServerTime() (int64, error) {
+	return s.client.NewSetServerTimeService().Do(context.Background())
+}
+
+// syncExchangeInfo only perpetual contracts are kept, https://fapi.binance.com/fapi/v1/exchangeInfo
+func (s *FuturesBaseInfoManager) syncExchangeInfo() ([]*SymbolInfoChange, error) {
+	res, err := s.client.NewExchangeInfoService().Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	symbolsMap := make(map[string]*SymbolBasicInfo)
+	for _, symbol := range res.Symbols {
+		if symbol.ContractType != futures.ContractTypePerpetual {
+			continue
+		}
+		priceFilter := symbol.PriceFilter()
+		lotSizeFilter := symbol.LotSizeFilter()
+		minNotionalFilter := symbol.MinNotionalFilter()
+		info := &SymbolBasicInfo{
+			Symbol:              symbol.Symbol,
+			Status:              SymbolStatus(symbol.Status),
+			BaseAsset:           symbol.BaseAsset,
+			BaseAssetPrecision:  int32(symbol.BaseAssetPrecision),
+			QuoteAsset:          symbol.QuoteAsset,
+			QuoteAssetPrecision: int32(symbol.QuotePrecision),
+		}
+		if priceFilter != nil {
+			info.MinPrice = NewDecimalFromStringIgnoreErr(priceFilter.MinPrice)
+			info.MaxPrice = NewDecimalFromStringIgnoreErr(priceFilter.MaxPrice)
+			info.TickSize = NewDecimalFromStringIgnoreErr(priceFilter.TickSize)
+			info.TickSizePrecision = ConvertPrecisionFromStringToInt(priceFilter.TickSize)
+		}
+		if lotSizeFilter != nil {
+			info.MinQuantity = NewDecimalFromStringIgnoreErr(lotSizeFilter.MinQuantity)
+			info.MaxQuantity = NewDecimalFromStringIgnoreErr(lotSizeFilter.MaxQuantity)
+			info.StepSize = NewDecimalFromStringIgnoreErr(lotSizeFilter.StepSize)
+			info.StepSizePrecision = ConvertPrecisionFromStringToInt(lotSizeFilter.StepSize)
+		}
+		if minNotionalFilter != nil {
+			info.MinNotional = NewDecimalFromStringIgnoreErr(minNotionalFilter.Notional)
+		}
+		symbolsMap[symbol.Symbol] = info
+		futuresSymbolMapper.Put(symbol.Symbol, symbol.BaseAsset, symbol.QuoteAsset)
+	}
+
+	s.rwM.Lock()
+	defer s.rwM.Unlock()
+	changes := DiffSymbolsBasicInfo(BinanceFutures, s.SymbolsMap, symbolsMap, newFuturesSymbolFromString, isAssetSupported)
+	s.SymbolsMap = symbolsMap
+	return changes, nil
+}
+
+func (s *FuturesBaseInfoManager) RefreshExchangeInfo() ([]*SymbolInfoChange, error) {
+	return s.syncExchangeInfo()
+}
+
+func (s *FuturesBaseInfoManager) GetSymbolBasicInfo(symbol Symbol) (*SymbolBasicInfo, error) {
+	alias := getFuturesSymbolAlias(symbol)
+	s.rwM.RLock()
+	info := s.SymbolsMap[alias]
+	s.rwM.RUnlock()
+	if info == nil {
+		return nil, fmt.Errorf("contract[%s] not supported", alias)
+	}
+	return info, nil
+}
+
+// GetSymbolsBasicInfo returns the contracts of supported assets
+func (s *FuturesBaseInfoManager) GetSymbolsBasicInfo() map[Symbol]*SymbolBasicInfo {
+	s.rwM.RLock()
+	defer s.rwM.RUnlock()
+	var res = make(map[Symbol]*SymbolBasicInfo)
+	for alias, info := range s.SymbolsMap {
+		symbol := newFuturesSymbolFromString(alias)
+		if isAssetSupported(symbol) {
+			res[symbol] = info
+		}
+	}
+	return res
+}
@@ -0,0 +1,193 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/adshao/go-binance/v2/futures"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/metrics"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"time"
+)
+
+var flg = plg.New("s", "futures")
+
+type FuturesMarketManager struct {
+	GMarketManager
+	client *futures.Client
+}
+
+func newFuturesMarketManager() *FuturesMarketManager {
+	s := &FuturesMarketManager{}
+	s.GMarketManager = InitGMarketManager(s.fetchDepth, s.wsWatchDepth)
+	s.client = getFuturesClient(nil)
+	return s
+}
+
+func (s *FuturesMarketManager) fetchDepth(symbol Symbol, limit int) *DepthInfo {
+	if !isAssetSupported(symbol) {
+		return NewDepthInfoWithErr(symbol, errors.New("not supported symbol"))
+	}
+	res, err := s.client.NewDepthService().Symbol(getFuturesSymbolAlias(symbol)).Limit(limit).Do(context.Background())
+	if err != nil {
+		return NewDepthInfoWithErr(symbol, err)
+	}
+
+	asks := make([]*Ask, 0, len(res.Asks))
+	for _, item := range res.Asks {
+		ask, err := NewPriceLevelFromString(item.Price, item.Quantity)
+		if err != nil {
+			continue
+		}
+		asks = append(asks, &ask)
+	}
+	bids := make([]*Bid, 0, len(res.Bids))
+	for _, item := range res.Bids {
+		bid, err := NewPriceLevelFromString(item.Price, item.Quantity)
+		if err != nil {
+			continue
+		}
+		bids = append(bids, &bid)
+	}
+	return &DepthInfo{
+		Symbol:          symbol,
+		Time:            res.Time,
+		TransactionTime: res.TradeTime,
+		Asks:            asks,
+		Bids:            bids,
+		LastUpdateID:    res.LastUpdateID,
+	}
+}
+
+// wsWatchDepth partial depth of every symbol in its own stream, it returns when any stream is done
+func (s *FuturesMarketManager) wsWatchDepth(ctx context.Context, infoC chan *DepthInfo, limit int, symbols ...Symbol) error {
+	wsDepthHandler := func(event *futures.WsDepthEvent) {
+		var bids []*Bid
+		var asks []*Ask
+		for _, item := range event.Bids {
+			if bid, err := NewPriceLevelFromString(item.Price, item.Quantity); err == nil {
+				bids = append(bids, &bid)
+			}
+		}
+		for _, item := range event.Asks {
+			if ask, err := NewPriceLevelFromString(item.Price, item.Quantity); err == nil {
+				asks = append(asks, &ask)
+			}
+		}
+		info := &DepthInfo{
+			Symbol:          newFuturesSymbolFromString(event.Symbol),
+			Time:            event.Time,
+			TransactionTime: event.TransactionTime,
+			LastUpdateID:    event.LastUpdateID,
+			Bids:            bids,
+			Asks:            asks,
+		}
+		infoC <- info
+		go func() {
+			metrics.M_Coin_Market_Depth_Total.WithLabelValues(string(BinanceFutures), TradingDerivatives, string(info.Symbol.BaseAsset)).Inc()
+		}()
+	}
+	errHandler := func(err error) {
+		flg.Error("failed to fetch new message from binance futures websocket.", "err", err)
+		alerting.NotifyRightNow(err, "error occurred when fetching Market Depth from binance futures websocket.")
+	}
+
+	doneCs := make([]chan struct{}, 0, len(symbols))
+	for _, symbol := range symbols {
+		doneC, stopC, err := futures.WsPartialDepthServeWithRate(getFuturesSymbolAlias(symbol), limit, 100*time.Millisecond, wsDepthHandler, errHandler)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			close(stopC)
+		}()
+		doneCs = append(doneCs, doneC)
+	}
+	for _, doneC := range doneCs {
+		<-doneC
+	}
+	return nil
+}
+
+func (s *FuturesMarketManager) GetMarkPrice(symbol Symbol) (*MarkPrice, error) {
+	res, err := s.client.NewPremiumIndexService().Symbol(getFuturesSymbolAlias(symbol)).Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no mark price of %s", symbol)
+	}
+	return &MarkPrice{
+		Symbol:          symbol,
+		MarkPrice:       NewDecimalFromStringIgnoreErr(res[0].MarkPrice),
+		FundingRate:     NewDecimalFromStringIgnoreErr(res[0].LastFundingRate),
+		NextFundingTime: res[0].NextFundingTime,
+		Time:            res[0].Time,
+	}, nil
+}
+
+// WsWatchMarkPrice mark price and funding rate of the symbols every second, until ctx is done
+func (s *FuturesMarketManager) WsWatchMarkPrice(ctx context.Context, infoC chan *MarkPrice, symbols ...Symbol) error {
+	watched := make(map[string]Symbol)
+	for _, symbol := range symbols {
+		watched[getFuturesSymbolAlias(symbol)] = symbol
+	}
+	handler := func(events futures.WsAllMarkPriceEvent) {
+		for _, event := range events {
+			symbol, ok := watched[event.Symbol]
+			if !ok {
+				continue
+			}
+			infoC <- &MarkPrice{
+				Symbol:          symbol,
+				MarkPrice:       NewDecimalFromStringIgnoreErr(event.MarkPrice),
+				IndexPrice:      NewDecimalFromStringIgnoreErr(event.IndexPrice),
+				FundingRate:     NewDecimalFromStringIgnoreErr(event.FundingRate),
+				NextFundingTime: event.NextFundingTime,
+				Time:            event.Time,
+			}
+		}
+	}
+	errHandler := func(err error) {
+		flg.Error("failed to fetch mark price from binance futures websocket.", "err", err)
+	}
+	doneC, stopC, err := futures.WsAllMarkPriceServeWithRate(time.Second, handler, errHandler)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		close(stopC)
+		<-doneC
+	case <-doneC:
+	}
+	return nil
+}
+
+func (s *FuturesMarketManager) ListFundingRates(symbol Symbol, startTime, endTime int64, limit int) ([]*FundingRate, error) {
+	service := s.client.NewFundingRateService().Symbol(getFuturesSymbolAlias(symbol))
+	if startTime > 0 {
+		service.StartTime(startTime)
+	}
+	if endTime > 0 {
+		service.EndTime(endTime)
+	}
+	if limit > 0 {
+		service.Limit(limit)
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	rates := make([]*FundingRate, len(res))
+	for i, r := range res {
+		rates[i] = &FundingRate{
+			Symbol:      symbol,
+			Rate:        NewDecimalFromStringIgnoreErr(r.FundingRate),
+			FundingTime: r.FundingTime,
+		}
+	}
+	return rates, nil
+}
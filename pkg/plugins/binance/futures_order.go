@@ -0,0 +1,221 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"github.com/adshao/go-binance/v2/futures"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"strconv"
+	"time"
+)
+
+type FuturesOrderManager struct {
+	secret *setting.Secret
+	client *futures.Client
+}
+
+func newFuturesOrderManager() *FuturesOrderManager {
+	secret := GetSecretsForExchanger(BinanceFutures)
+	return &FuturesOrderManager{
+		secret: secret,
+		client: getFuturesClient(secret),
+	}
+}
+
+func convertFuturesOrder(o *futures.Order) *Order {
+	return &Order{
+		Symbol:                   o.Symbol,
+		OrderID:                  strconv.FormatInt(o.OrderID, 10),
+		ClientOrderID:            o.ClientOrderID,
+		Price:                    NewDecimalFromStringIgnoreErr(o.Price),
+		OrigQuantity:             NewDecimalFromStringIgnoreErr(o.OrigQuantity),
+		ExecutedQuantity:         NewDecimalFromStringIgnoreErr(o.ExecutedQuantity),
+		CummulativeQuoteQuantity: NewDecimalFromStringIgnoreErr(o.CumQuote),
+		Status:                   OrderStatusType(o.Status),
+		TimeInForce:              TimeInForceType(o.TimeInForce),
+		Type:                     OrderType(o.Type),
+		Side:                     SideType(o.Side),
+		StopPrice:                NewDecimalFromStringIgnoreErr(o.StopPrice),
+		Time:                     o.Time,
+		UpdateTime:               o.UpdateTime,
+	}
+}
+
+func (s *FuturesOrderManager) ListOpenOrdersOfSymbol(symbol Symbol) (res []*Order, err error) {
+	openOrders, err := s.client.NewListOpenOrdersService().Symbol(getFuturesSymbolAlias(symbol)).Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range openOrders {
+		res = append(res, convertFuturesOrder(o))
+	}
+	return res, nil
+}
+
+func (s *FuturesOrderManager) ListAllOrders(symbol Symbol) (res []*Order, err error) {
+	orders, err := s.client.NewListOrdersService().Symbol(getFuturesSymbolAlias(symbol)).Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		res = append(res, convertFuturesOrder(o))
+	}
+	return res, nil
+}
+
+// CreateOrder in one-way mode without reduce-only
+func (s *FuturesOrderManager) CreateOrder(plan OrderPlan) (*CreateOrderResponse, error) {
+	return s.CreateFuturesOrder(FuturesOrderPlan{OrderPlan: plan, PositionSide: PositionSideBoth})
+}
+
+// CreateFuturesOrder reduce-only is rejected in hedge mode, where closing is told by position side
+func (s *FuturesOrderManager) CreateFuturesOrder(plan FuturesOrderPlan) (*CreateOrderResponse, error) {
+	start := time.Now()
+	flg.Warn("Create Futures Order Start", "plan", plan.ToString(), "positionSide", plan.PositionSide, "reduceOnly", plan.ReduceOnly)
+	service := s.client.NewCreateOrderService().Symbol(getFuturesSymbolAlias(plan.Symbol)).
+		Side(futures.SideType(plan.Side)).
+		Type(futures.OrderType(plan.OrderType))
+
+	if plan.ClientOrderID != "" {
+		service.NewClientOrderID(plan.ClientOrderID)
+	}
+	if plan.PositionSide != "" {
+		service.PositionSide(futures.PositionSideType(plan.PositionSide))
+	}
+	if plan.ReduceOnly {
+		service.ReduceOnly(true)
+	}
+	switch plan.OrderType {
+	case OrderTypeLimit:
+		if plan.Quantity == nil || plan.Price == nil {
+			return nil, errors.New("quantity and price are required by limit order of futures")
+		}
+		service.
+			TimeInForce(futures.TimeInForceType(plan.TimeInForce)).
+			Quantity(plan.Quantity.String()).
+			Price(plan.Price.String())
+	case OrderTypeMarket:
+		if plan.Quantity == nil {
+			return nil, errors.New("quantity is required by market order of futures")
+		}
+		service.Quantity(plan.Quantity.String())
+	default:
+		return nil, errors.New("not supported orderType")
+	}
+	order, err := service.Do(context.Background())
+	defer func() { uploadMetrics(plan.Symbol.BaseAsset, "CreateFuturesOrder", err, start) }()
+
+	if err != nil {
+		flg.Error("Create Futures Order Failed", "ClientOrderID", plan.ClientOrderID, "err", err)
+		alerting.Notify(err, "Create Futures Order Failed in binance", "ClientOrderID", plan.ClientOrderID)
+		return nil, convertOrderErr(err)
+	}
+	flg.Warn("Create Futures Order Succeed", "ClientOrderID", plan.ClientOrderID, "OrderID", order.OrderID)
+	return NewCreateOrderResponse(order.OrderID, order.ClientOrderID), nil
+}
+
+func (s *FuturesOrderManager) GetOrder(symbol Symbol, orderId string, clientOrderId string) (*Order, error) {
+	service := s.client.NewGetOrderService().Symbol(getFuturesSymbolAlias(symbol))
+	if clientOrderId != "" {
+		service.OrigClientOrderID(clientOrderId)
+	}
+	if orderId != "" {
+		if oId, err := strconv.ParseInt(orderId, 10, 64); err == nil {
+			service.OrderID(oId)
+		}
+	}
+	order, err := service.Do(context.Background())
+	if err != nil {
+		return nil, convertOrderErr(err)
+	}
+	return convertFuturesOrder(order), nil
+}
+
+func (s *FuturesOrderManager) CancelOrder(symbol Symbol, orderId string, clientOrderId string) (OrderStatusType, error) {
+	service := s.client.NewCancelOrderService().Symbol(getFuturesSymbolAlias(symbol))
+	if clientOrderId != "" {
+		service.OrigClientOrderID(clientOrderId)
+	}
+	if orderId != "" {
+		if oId, err := strconv.ParseInt(orderId, 10, 64); err == nil {
+			service.OrderID(oId)
+		}
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		return "", err
+	}
+	return OrderStatusType(res.Status), nil
+}
+
+// BatchCreateOrders orders are created by parallel single requests.
+func (s *FuturesOrderManager) BatchCreateOrders(plans []OrderPlan) ([]*BatchOrderResult, error) {
+	return ParallelCreateOrders(s, plans), nil
+}
+
+// BatchCancelOrders orders are canceled by parallel single requests.
+func (s *FuturesOrderManager) BatchCancelOrders(symbol Symbol, refs []OrderRef) ([]*BatchOrderResult, error) {
+	return ParallelCancelOrders(s, symbol, refs), nil
+}
+
+// GetPositions positions not empty, with mark price and liquidation price
+func (s *FuturesOrderManager) GetPositions() ([]*Position, error) {
+	res, err := s.client.NewGetPositionRiskService().Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var positions []*Position
+	for _, p := range res {
+		position := &Position{
+			Symbol:           newFuturesSymbolFromString(p.Symbol),
+			PositionSide:     PositionSide(p.PositionSide),
+			Amount:           NewDecimalFromStringIgnoreErr(p.PositionAmt),
+			EntryPrice:       NewDecimalFromStringIgnoreErr(p.EntryPrice),
+			MarkPrice:        NewDecimalFromStringIgnoreErr(p.MarkPrice),
+			UnrealizedProfit: NewDecimalFromStringIgnoreErr(p.UnRealizedProfit),
+			Notional:         NewDecimalFromStringIgnoreErr(p.Notional),
+			LiquidationPrice: NewDecimalFromStringIgnoreErr(p.LiquidationPrice),
+			IsolatedMargin:   NewDecimalFromStringIgnoreErr(p.IsolatedMargin),
+			MarginType:       MarginTypeCrossed,
+		}
+		if p.MarginType == "isolated" {
+			position.MarginType = MarginTypeIsolated
+		}
+		position.Leverage, _ = strconv.Atoi(p.Leverage)
+		if !position.IsEmpty() {
+			positions = append(positions, position)
+		}
+	}
+	return positions, nil
+}
+
+func (s *FuturesOrderManager) SetLeverage(symbol Symbol, leverage int) error {
+	_, err := s.client.NewChangeLeverageService().Symbol(getFuturesSymbolAlias(symbol)).Leverage(leverage).Do(context.Background())
+	return err
+}
+
+const futuresIncomeTypeFundingFee = "FUNDING_FEE"
+
+func (s *FuturesOrderManager) ListFundingFees(symbol Symbol, startTime int64) ([]*FundingFee, error) {
+	service := s.client.NewGetIncomeHistoryService().Symbol(getFuturesSymbolAlias(symbol)).
+		IncomeType(futuresIncomeTypeFundingFee).Limit(1000)
+	if startTime > 0 {
+		service.StartTime(startTime)
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	fees := make([]*FundingFee, len(res))
+	for i, income := range res {
+		fees[i] = &FundingFee{
+			Symbol: symbol,
+			Asset:  ToAsset(income.Asset),
+			Amount: NewDecimalFromStringIgnoreErr(income.Income),
+			Time:   income.Time,
+		}
+	}
+	return fees, nil
+}
@@ -0,0 +1,112 @@
+package binance
+
+import (
+	"fmt"
+	"github.com/adshao/go-binance/v2/futures"
+	"jasonzhu.com/coin_labor/core/setting"
+	"jasonzhu.com/coin_labor/pkg/plugins/general"
+)
+
+func init() {
+	futures.WebsocketKeepalive = true
+	general.RegisterFactory(&general.PluginFactory{
+		ExName:  general.BinanceFutures,
+		Ranking: 100,
+		New:     newBinanceFuturesPlugin,
+	})
+}
+
+// BinanceFuturesPlugin USDⓈ-M perpetual contracts, to hedge spot inventory while waiting for spreads to converge.
+// Deposit and withdrawal go through the spot wallet, so the wallet is not supported.
+type BinanceFuturesPlugin struct {
+	baseInfoManager    general.BaseInterface
+	marketManager      general.MarketInterface
+	accountManager     general.AccountInterface
+	orderManager       *FuturesOrderManager
+	tradeManager       general.TradeInterface
+	derivativesManager general.DerivativesInterface
+}
+
+func newBinanceFuturesPlugin() (general.ExManager, error) {
+	manager, err := newFuturesBaseInfoManager()
+	if err != nil {
+		fmt.Printf("failed to init plugin [%s]\n", general.BinanceFutures)
+		return nil, err
+	}
+	marketManager := newFuturesMarketManager()
+	orderManager := newFuturesOrderManager()
+	return &BinanceFuturesPlugin{
+		baseInfoManager: manager,
+		marketManager:   marketManager,
+		accountManager:  newFuturesAccountManager(),
+		orderManager:    orderManager,
+		tradeManager:    newFuturesTradesManager(),
+		derivativesManager: &futuresDerivativesManager{
+			FuturesMarketManager: marketManager,
+			FuturesOrderManager:  orderManager,
+		},
+	}, nil
+}
+
+func (p *BinanceFuturesPlugin) ExchangeAlias() general.Exchange {
+	return general.BinanceFutures
+}
+
+func (p *BinanceFuturesPlugin) GetBaseInfoManager() general.BaseInterface {
+	return p.baseInfoManager
+}
+
+func (p *BinanceFuturesPlugin) GetMarketInfoManager() general.MarketInterface {
+	return p.marketManager
+}
+
+func (p *BinanceFuturesPlugin) GetAccountManager() general.AccountInterface {
+	return p.accountManager
+}
+
+func (p *BinanceFuturesPlugin) GetOrderInterface() general.OrderInterface {
+	return p.orderManager
+}
+
+func (p *BinanceFuturesPlugin) GetTradeInterface() general.TradeInterface {
+	return p.tradeManager
+}
+
+func (p *BinanceFuturesPlugin) GetWalletInterface() general.WalletInterface {
+	return general.UnsupportedWallet{}
+}
+
+func (p *BinanceFuturesPlugin) GetDerivativesInterface() general.DerivativesInterface {
+	return p.derivativesManager
+}
+
+// futuresDerivativesManager mark price from market, positions and orders with position side from order
+type futuresDerivativesManager struct {
+	*FuturesMarketManager
+	*FuturesOrderManager
+}
+
+// futuresSymbolMapper is filled by FuturesBaseInfoManager, contracts like 1000SHIBUSDT differ from spot symbols.
+var futuresSymbolMapper = general.NewSymbolMapper()
+
+func getFuturesSymbolAlias(symbol general.Symbol) string {
+	if alias, ok := futuresSymbolMapper.ToAlias(symbol); ok {
+		return alias
+	}
+	return string(symbol.BaseAsset) + string(symbol.QuoteAsset)
+}
+
+func newFuturesSymbolFromString(symbol string) general.Symbol {
+	if s, ok := futuresSymbolMapper.ToSymbol(symbol); ok {
+		return s
+	}
+	return general.ParseSymbol(symbol)
+}
+
+func getFuturesClient(secret *setting.Secret) *futures.Client {
+	futures.UseTestnet = UseTestnet
+	if secret == nil {
+		return futures.NewClient(apiKey, secretKey)
+	}
+	return futures.NewClient(secret.Key, secret.Secret)
+}
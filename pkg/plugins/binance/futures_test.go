@@ -0,0 +1,31 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"jasonzhu.com/coin_labor/pkg/plugins/general"
+	"testing"
+	"time"
+)
+
+func TestFuturesMarketManager_GetMarkPrice(t *testing.T) {
+	manager := newFuturesMarketManager()
+	markPrice, err := manager.GetMarkPrice(general.NewSymbol(general.INJ))
+	fmt.Println(markPrice, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	infoC := make(chan *general.MarkPrice, 10)
+	go func() {
+		for info := range infoC {
+			fmt.Println(info.Symbol, info.MarkPrice, info.FundingRate)
+		}
+	}()
+	fmt.Println(manager.WsWatchMarkPrice(ctx, infoC, general.NewSymbol(general.INJ)))
+}
+
+func TestFuturesOrderManager_GetPositions(t *testing.T) {
+	manager := newFuturesOrderManager()
+	positions, err := manager.GetPositions()
+	fmt.Println(positions, err)
+}
@@ -0,0 +1,65 @@
+package binance
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2/futures"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"strconv"
+)
+
+type FuturesTradesManager struct {
+	secret *setting.Secret
+	client *futures.Client
+}
+
+func newFuturesTradesManager() *FuturesTradesManager {
+	secret := GetSecretsForExchanger(BinanceFutures)
+	return &FuturesTradesManager{
+		secret: secret,
+		client: getFuturesClient(secret),
+	}
+}
+
+// ListTrades userTrades of futures, fromId is used if set. ClientOrderId is not returned by futures either.
+func (s *FuturesTradesManager) ListTrades(symbol Symbol, query TradeQuery) ([]*Trade, error) {
+	service := s.client.NewListAccountTradeService().Symbol(getFuturesSymbolAlias(symbol))
+	if query.FromID != "" {
+		fromID, err := strconv.ParseInt(query.FromID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		service.FromID(fromID)
+	} else {
+		if query.StartTime > 0 {
+			service.StartTime(query.StartTime)
+		}
+		if query.EndTime > 0 {
+			service.EndTime(query.EndTime)
+		}
+	}
+	if query.Limit > 0 {
+		service.Limit(query.Limit)
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	trades := make([]*Trade, len(res))
+	for i, t := range res {
+		trades[i] = &Trade{
+			Symbol:          t.Symbol,
+			Id:              strconv.FormatInt(t.ID, 10),
+			OrderId:         strconv.FormatInt(t.OrderID, 10),
+			Price:           NewDecimalFromStringIgnoreErr(t.Price),
+			Qty:             NewDecimalFromStringIgnoreErr(t.Quantity),
+			QuoteQty:        NewDecimalFromStringIgnoreErr(t.QuoteQuantity),
+			Commission:      t.Commission,
+			CommissionAsset: t.CommissionAsset,
+			Time:            t.Time,
+			IsBuyer:         t.Buyer,
+			IsMaker:         t.Maker,
+		}
+	}
+	return trades, nil
+}
@@ -6,7 +6,6 @@ import (
 	"jasonzhu.com/coin_labor/core/components/log"
 	"jasonzhu.com/coin_labor/core/setting"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
-	"strings"
 )
 
 const exName = Binance
@@ -31,6 +30,9 @@ func isAssetSupported(symbol Symbol) bool {
 	return false
 }
 
+// symbolMapper is filled by BaseInfoManager with the base/quote assets from exchangeInfo.
+var symbolMapper = NewSymbolMapper()
+
 func buildSymbolWithDefaultQuoteCoin(asset Asset) Symbol {
 	return Symbol{
 		BaseAsset:  asset,
@@ -41,26 +43,18 @@ func getSymbolAliasWithDefaultQuoteCoin(asset Asset) string {
 	return getSymbolAlias(Symbol{BaseAsset: asset, QuoteAsset: DefaultQuoteCoin})
 }
 func getSymbolAlias(symbol Symbol) string {
+	if alias, ok := symbolMapper.ToAlias(symbol); ok {
+		return alias
+	}
 	return string(symbol.BaseAsset) + string(symbol.QuoteAsset)
 }
 
-// Convert from string, like ETHUSDT
+// Convert from string, like ETHUSDT, ETHBTC or BTCFDUSD
 func newSymbolFromString(symbol string) Symbol {
-	if strings.HasSuffix(strings.ToUpper(symbol), string(DefaultQuoteCoin)) {
-		baseCoin := symbol[0 : len(symbol)-len(DefaultQuoteCoin)]
-		for _, s := range supportedAssets {
-			if baseCoin == string(s) {
-				return Symbol{
-					BaseAsset:  ToAsset(baseCoin),
-					QuoteAsset: DefaultQuoteCoin,
-				}
-			}
-		}
-	}
-	return Symbol{
-		BaseAsset:  UnKnown,
-		QuoteAsset: DefaultQuoteCoin,
+	if s, ok := symbolMapper.ToSymbol(symbol); ok {
+		return s
 	}
+	return ParseSymbol(symbol)
 }
 
 var (
@@ -68,6 +62,24 @@ var (
 	secretKey = ""
 )
 
+// Endpoints of APIs not covered by go-binance
+const (
+	baseAPIMainURL    = "https://api.binance.com"
+	baseAPITestnetURL = "https://testnet.binance.vision"
+	apiKeyHeader      = "X-MBX-APIKEY"
+
+	orderListEndpoint     = "/api/v3/orderList"
+	openOrderListEndpoint = "/api/v3/openOrderList"
+)
+
+// getAPIEndpoint return the base endpoint of the Rest API according the UseTestnet flag
+func getAPIEndpoint() string {
+	if UseTestnet {
+		return baseAPITestnetURL
+	}
+	return baseAPIMainURL
+}
+
 func getBinanceClient(secret *setting.Secret) *binance.Client {
 	if secret == nil {
 		return binance.NewClient(apiKey, secretKey)
@@ -3,25 +3,30 @@ package binance
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
 	"jasonzhu.com/coin_labor/core/components/alerting"
 	"jasonzhu.com/coin_labor/core/components/metrics"
 	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/core/util/http"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
 	"strconv"
 	"time"
 )
 
 type OrderManager struct {
-	secret *setting.Secret
-	client *binance.Client
+	secret     *setting.Secret
+	client     *binance.Client
+	restClient *Client // for APIs not covered by go-binance
 }
 
 func NewOrderManager() OrderInterface {
 	secret := GetSecretsForExchanger(Binance)
 	return &OrderManager{
-		secret: secret,
-		client: getBinanceClient(secret),
+		secret:     secret,
+		client:     getBinanceClient(secret),
+		restClient: NewHMACClient(secret, getAPIEndpoint(), apiKeyHeader),
 	}
 }
 
@@ -143,13 +148,17 @@ func (s *OrderManager) CreateOrder(plan OrderPlan) (*CreateOrderResponse, error)
 	if err != nil {
 		plg.Error("Create Order Failed", "ClientOrderID", plan.ClientOrderID, "err", err)
 		alerting.Notify(err, "Create Order Failed in binance", "ClientOrderID", plan.ClientOrderID)
-		return nil, err
+		return nil, convertOrderErr(err)
 	}
 	plg.Warn("Create Order Succeed", "ClientOrderID", plan.ClientOrderID, "OrderID", order.OrderID)
 	//alerting.Info("Create Order Succeed in Binance", "type", plan.OrderType, "clientOrderID", plan.ClientOrderID)
 	return NewCreateOrderResponse(order.OrderID, order.ClientOrderID), nil
 }
 
+func (s *OrderManager) SupportsQuoteOrderQty() bool {
+	return true
+}
+
 func (s *OrderManager) GetOrder(symbol Symbol, orderId string, clientOrderId string) (*Order, error) {
 	start := time.Now()
 	symbol2USDT := getSymbolAlias(symbol)
@@ -167,7 +176,7 @@ func (s *OrderManager) GetOrder(symbol Symbol, orderId string, clientOrderId str
 	defer func() { uploadMetrics(symbol.BaseAsset, "GetOrder", err, start) }()
 
 	if err != nil {
-		return nil, err
+		return nil, convertOrderErr(err)
 	}
 	return convertToOrder(order), nil
 }
@@ -194,6 +203,16 @@ func (s *OrderManager) CancelOrder(symbol Symbol, orderId string, clientOrderId
 	return OrderStatusType(res.Status), nil
 }
 
+// BatchCreateOrders Binance spot has no batch API, orders are created by parallel single requests.
+func (s *OrderManager) BatchCreateOrders(plans []OrderPlan) ([]*BatchOrderResult, error) {
+	return ParallelCreateOrders(s, plans), nil
+}
+
+// BatchCancelOrders Binance spot has no batch API, orders are canceled by parallel single requests.
+func (s *OrderManager) BatchCancelOrders(symbol Symbol, refs []OrderRef) ([]*BatchOrderResult, error) {
+	return ParallelCancelOrders(s, symbol, refs), nil
+}
+
 func uploadMetrics(asset Asset, typ string, err error, start time.Time) {
 	go func() {
 		metrics.M_Coin_Order_Total.WithLabelValues(
@@ -208,3 +227,19 @@ func uploadMetrics(asset Asset, typ string, err error, start time.Time) {
 		).Observe(float64(duration))
 	}()
 }
+
+// convertOrderErr wraps API errors after which the order may exist with ErrSendStatusUnknown,
+// https://binance-docs.github.io/apidocs/spot/en/#error-codes
+func convertOrderErr(err error) error {
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Code {
+	case 0, -1000, -1001, -1006, -1007: // 5xx without body, UNKNOWN, DISCONNECTED, UNEXPECTED_RESP, TIMEOUT
+		return fmt.Errorf("%w: %v", ErrSendStatusUnknown, err)
+	case -2013: // NO_SUCH_ORDER
+		return fmt.Errorf("%w: %v", ErrOrderNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/adshao/go-binance/v2"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	. "jasonzhu.com/coin_labor/core/util/http"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// bOrderList orderList and openOrderList response, not covered by go-binance
+type bOrderList struct {
+	OrderListID       int64               `json:"orderListId"`
+	ContingencyType   string              `json:"contingencyType"`
+	ListStatusType    string              `json:"listStatusType"`
+	ListOrderStatus   string              `json:"listOrderStatus"`
+	ListClientOrderID string              `json:"listClientOrderId"`
+	TransactionTime   int64               `json:"transactionTime"`
+	Symbol            string              `json:"symbol"`
+	Orders            []*binance.OCOOrder `json:"orders"`
+}
+
+// CreateOCOOrder https://binance-docs.github.io/apidocs/spot/cn/#oco-trade
+func (s *OrderManager) CreateOCOOrder(plan OCOOrderPlan) (*OrderList, error) {
+	if !DefaultHealthChecker.IsAllFeaturesHealthy() {
+		plg.Warn("unhealthy, skip create OCO order in Binance")
+		return nil, errors.New("unhealthy right now, unable to create order")
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	plg.Warn("Create OCO Order Start", "symbol", plan.Symbol, "side", plan.Side, "quantity", plan.Quantity,
+		"price", plan.Price, "stopPrice", plan.StopPrice, "stopLimitPrice", plan.StopLimitPrice)
+	service := s.client.NewCreateOCOService().Symbol(getSymbolAlias(plan.Symbol)).
+		Side(binance.SideType(plan.Side)).
+		Quantity(plan.Quantity.String()).
+		Price(plan.Price.String()).
+		StopPrice(plan.StopPrice.String()).
+		StopLimitPrice(plan.StopLimitPrice.String()).
+		StopLimitTimeInForce(binance.TimeInForceType(plan.StopLimitTimeInForce))
+	if plan.ListClientOrderID != "" {
+		service.ListClientOrderID(plan.ListClientOrderID)
+	}
+	if plan.LimitClientOrderID != "" {
+		service.LimitClientOrderID(plan.LimitClientOrderID)
+	}
+	if plan.StopClientOrderID != "" {
+		service.StopClientOrderID(plan.StopClientOrderID)
+	}
+	res, err := service.Do(context.Background())
+
+	defer func() { uploadMetrics(plan.Symbol.BaseAsset, "CreateOCOOrder", err, start) }()
+
+	if err != nil {
+		plg.Error("Create OCO Order Failed", "ListClientOrderID", plan.ListClientOrderID, "err", err)
+		alerting.Notify(err, "Create OCO Order Failed in binance", "ListClientOrderID", plan.ListClientOrderID)
+		return nil, convertOrderErr(err)
+	}
+	plg.Warn("Create OCO Order Succeed", "ListClientOrderID", plan.ListClientOrderID, "OrderListID", res.OrderListID)
+	return convertToOrderList(&bOrderList{
+		OrderListID:       res.OrderListID,
+		ContingencyType:   res.ContingencyType,
+		ListStatusType:    res.ListStatusType,
+		ListOrderStatus:   res.ListOrderStatus,
+		ListClientOrderID: res.ListClientOrderID,
+		TransactionTime:   res.TransactionTime,
+		Symbol:            res.Symbol,
+		Orders:            res.Orders,
+	}), nil
+}
+
+// CancelOCOOrder cancels the whole list, by orderListId or listClientOrderId
+func (s *OrderManager) CancelOCOOrder(symbol Symbol, orderListId int64, listClientOrderID string) (*OrderList, error) {
+	start := time.Now()
+	service := s.client.NewCancelOCOService().Symbol(getSymbolAlias(symbol))
+	if orderListId > 0 {
+		service.OrderListID(orderListId)
+	}
+	if listClientOrderID != "" {
+		service.ListClientOrderID(listClientOrderID)
+	}
+	res, err := service.Do(context.Background())
+	defer func() { uploadMetrics(symbol.BaseAsset, "CancelOCOOrder", err, start) }()
+
+	if err != nil {
+		return nil, convertOrderErr(err)
+	}
+	return convertToOrderList(&bOrderList{
+		OrderListID:       res.OrderListID,
+		ContingencyType:   res.ContingencyType,
+		ListStatusType:    res.ListStatusType,
+		ListOrderStatus:   res.ListOrderStatus,
+		ListClientOrderID: res.ListClientOrderID,
+		TransactionTime:   res.TransactionTime,
+		Symbol:            res.Symbol,
+		Orders:            res.Orders,
+	}), nil
+}
+
+// GetOCOOrder by orderListId or origClientOrderId
+func (s *OrderManager) GetOCOOrder(orderListId int64, listClientOrderID string) (*OrderList, error) {
+	r := &Request{
+		Method:   http.MethodGet,
+		Endpoint: orderListEndpoint,
+		SecType:  SecTypeSigned,
+	}
+	if orderListId > 0 {
+		r.SetParam("orderListId", strconv.FormatInt(orderListId, 10))
+	}
+	if listClientOrderID != "" {
+		r.SetParam("origClientOrderId", listClientOrderID)
+	}
+	data, err := s.restClient.CallAPI(context.Background(), r)
+	if err != nil {
+		return nil, convertOrderErr(err)
+	}
+	res := new(bOrderList)
+	if err = json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	return convertToOrderList(res), nil
+}
+
+// ListOpenOCOOrders NewListOpenOcoService of go-binance has a broken endpoint
+func (s *OrderManager) ListOpenOCOOrders() ([]*OrderList, error) {
+	r := &Request{
+		Method:   http.MethodGet,
+		Endpoint: openOrderListEndpoint,
+		SecType:  SecTypeSigned,
+	}
+	data, err := s.restClient.CallAPI(context.Background(), r)
+	if err != nil {
+		return nil, convertOrderErr(err)
+	}
+	var res []*bOrderList
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	lists := make([]*OrderList, len(res))
+	for i, item := range res {
+		lists[i] = convertToOrderList(item)
+	}
+	return lists, nil
+}
+
+func convertToOrderList(res *bOrderList) *OrderList {
+	orders := make([]OrderRef, len(res.Orders))
+	for i, order := range res.Orders {
+		orders[i] = OrderRef{
+			OrderID:       strconv.FormatInt(order.OrderID, 10),
+			ClientOrderID: order.ClientOrderID,
+		}
+	}
+	return &OrderList{
+		Symbol:            res.Symbol,
+		OrderListId:       res.OrderListID,
+		ListClientOrderID: res.ListClientOrderID,
+		ContingencyType:   res.ContingencyType,
+		ListStatusType:    ListStatusType(res.ListStatusType),
+		ListOrderStatus:   ListOrderStatusType(res.ListOrderStatus),
+		Orders:            orders,
+		TransactionTime:   res.TransactionTime,
+	}
+}
@@ -8,13 +8,11 @@ import (
 
 func init() {
 	binance.WebsocketKeepalive = true
-	if plugin, err := newBinancePlugin(); err == nil {
-		general.Register(&general.ExPlugin{
-			ExName:   general.Binance,
-			Instance: plugin,
-			Ranking:  300,
-		})
-	}
+	general.RegisterFactory(&general.PluginFactory{
+		ExName:  general.Binance,
+		Ranking: 300,
+		New:     newBinancePlugin,
+	})
 }
 
 var UseTestnet = false
@@ -24,6 +22,9 @@ type BinancePlugin struct {
 	marketManager   general.MarketInterface
 	accountManager  general.AccountInterface
 	orderManager    general.OrderInterface
+	tradeManager    general.TradeInterface
+	walletManager   general.WalletInterface
+	convertManager  general.ConvertInterface
 }
 
 func newBinancePlugin() (general.ExManager, error) {
@@ -35,11 +36,15 @@ func newBinancePlugin() (general.ExManager, error) {
 	marketManager := newMarketInfoManager()
 	accountManager := newAccountManager()
 	orderManager := NewOrderManager()
+	tradeManager := NewTradesManager()
 	return &BinancePlugin{
 		baseInfoManager: manager,
 		marketManager:   marketManager,
 		accountManager:  accountManager,
 		orderManager:    orderManager,
+		tradeManager:    tradeManager,
+		walletManager:   newWalletManager(),
+		convertManager:  newConvertManager(),
 	}, nil
 }
 
@@ -62,3 +67,15 @@ func (p *BinancePlugin) GetAccountManager() general.AccountInterface {
 func (p *BinancePlugin) GetOrderInterface() general.OrderInterface {
 	return p.orderManager
 }
+
+func (p *BinancePlugin) GetTradeInterface() general.TradeInterface {
+	return p.tradeManager
+}
+
+func (p *BinancePlugin) GetWalletInterface() general.WalletInterface {
+	return p.walletManager
+}
+
+func (p *BinancePlugin) GetConvertInterface() general.ConvertInterface {
+	return p.convertManager
+}
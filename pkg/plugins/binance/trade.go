@@ -8,6 +8,8 @@ import (
 	"strconv"
 )
 
+const maxListOrdersLimit = 1000
+
 type TradesManager struct {
 	secret *setting.Secret
 	client *binance.Client
@@ -22,21 +24,81 @@ func NewTradesManager() *TradesManager {
 	return s
 }
 
-func (s *TradesManager) ListTrades(symbol Symbol, limit int) ([]*Trade, error) {
+// ListTrades fromId can not be sent together with startTime, fromId is used if set.
+// myTrades of Binance has no clientOrderId, it is filled by allOrders since the first orderId.
+func (s *TradesManager) ListTrades(symbol Symbol, query TradeQuery) ([]*Trade, error) {
 	alias := getSymbolAlias(symbol)
-	res, err := s.client.NewListTradesService().Symbol(alias).Limit(limit).Do(context.Background())
+	service := s.client.NewListTradesService().Symbol(alias)
+	if query.FromID != "" {
+		fromID, err := strconv.ParseInt(query.FromID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		service.FromID(fromID)
+	} else {
+		if query.StartTime > 0 {
+			service.StartTime(query.StartTime)
+		}
+		if query.EndTime > 0 {
+			service.EndTime(query.EndTime)
+		}
+	}
+	if query.Limit > 0 {
+		service.Limit(query.Limit)
+	}
+	res, err := service.Do(context.Background())
 	if err != nil {
 		return nil, err
 	}
+	if len(res) == 0 {
+		return nil, nil
+	}
 
+	clientOrderIDs, err := s.listClientOrderIDs(alias, res)
+	if err != nil {
+		return nil, err
+	}
 	var trades []*Trade
 	for _, trade := range res {
-		trades = append(trades, convertToTrade(trade))
+		t := convertToTrade(trade)
+		t.ClientOrderId = clientOrderIDs[trade.OrderID]
+		trades = append(trades, t)
 	}
 
 	return trades, nil
 }
 
+// listClientOrderIDs orderId -> clientOrderId of the orders of trades
+func (s *TradesManager) listClientOrderIDs(alias string, trades []*binance.TradeV3) (map[int64]string, error) {
+	orderIDs := make(map[int64]string)
+	minOrderID := trades[0].OrderID
+	for _, trade := range trades {
+		orderIDs[trade.OrderID] = ""
+		if trade.OrderID < minOrderID {
+			minOrderID = trade.OrderID
+		}
+	}
+
+	for remaining := len(orderIDs); remaining > 0; {
+		orders, err := s.client.NewListOrdersService().Symbol(alias).OrderID(minOrderID).Limit(maxListOrdersLimit).
+			Do(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			if clientOrderID, ok := orderIDs[order.OrderID]; ok && clientOrderID == "" {
+				orderIDs[order.OrderID] = order.ClientOrderID
+				remaining--
+			}
+		}
+		if len(orders) < maxListOrdersLimit {
+			break
+		}
+		minOrderID = orders[len(orders)-1].OrderID + 1
+	}
+	return orderIDs, nil
+}
+
 func convertToTrade(trade *binance.TradeV3) *Trade {
 	return &Trade{
 		Symbol:          trade.Symbol,
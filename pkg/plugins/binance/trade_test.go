@@ -9,7 +9,7 @@ import (
 
 func TestTradesManager_ListTrades(t *testing.T) {
 	manager := NewTradesManager()
-	trades, err := manager.ListTrades(general.NewSymbol(general.INJ), 10)
+	trades, err := manager.ListTrades(general.NewSymbol(general.INJ), general.TradeQuery{Limit: 10})
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -27,6 +27,7 @@ func convertToOCOUpdate(update BWsOCOUpdate) WsOCOUpdate {
 		ListOrderStatus: update.ListOrderStatus,
 		RejectReason:    update.RejectReason,
 		ClientOrderId:   update.ClientOrderId,
+		TransactionTime: update.TransactionTime,
 		Orders:          convertToWsOCOOrderList(update.Orders),
 	}
 }
@@ -0,0 +1,230 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"strconv"
+	"time"
+)
+
+type WalletManager struct {
+	lg     log.Logger
+	secret *setting.Secret
+	client *binance.Client
+}
+
+func newWalletManager() *WalletManager {
+	secret := GetSecretsForExchanger(Binance)
+	return &WalletManager{
+		lg:     plg.New("s", "Wallet"),
+		secret: secret,
+		client: getBinanceClient(secret),
+	}
+}
+
+// GetNetworks https://binance-docs.github.io/apidocs/spot/cn/#user_data
+func (s *WalletManager) GetNetworks(asset Asset) ([]*NetworkInfo, error) {
+	coins, err := s.client.NewGetAllCoinsInfoService().Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, coin := range coins {
+		if Asset(coin.Coin) != asset {
+			continue
+		}
+		networks := make([]*NetworkInfo, len(coin.NetworkList))
+		for i, network := range coin.NetworkList {
+			networks[i] = &NetworkInfo{
+				Asset:                   asset,
+				Network:                 network.Network,
+				Name:                    network.Name,
+				IsDefault:               network.IsDefault,
+				DepositEnable:           network.DepositEnable,
+				WithdrawEnable:          network.WithdrawEnable,
+				WithdrawFee:             NewDecimalFromStringIgnoreErr(network.WithdrawFee),
+				WithdrawMin:             NewDecimalFromStringIgnoreErr(network.WithdrawMin),
+				WithdrawMax:             NewDecimalFromStringIgnoreErr(network.WithdrawMax),
+				WithdrawIntegerMultiple: NewDecimalFromStringIgnoreErr(network.WithdrawIntegerMultiple),
+				MinConfirm:              network.MinConfirm,
+				NeedMemo:                network.SameAddress,
+			}
+		}
+		return networks, nil
+	}
+	return nil, fmt.Errorf("asset %s not found", asset)
+}
+
+func (s *WalletManager) GetDepositAddress(asset Asset, network string) (*DepositAddress, error) {
+	service := s.client.NewGetDepositAddressService().Coin(string(asset))
+	if network != "" {
+		service.Network(network)
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &DepositAddress{
+		Asset:   asset,
+		Network: network,
+		Address: res.Address,
+		Tag:     res.Tag,
+	}, nil
+}
+
+// Withdraw to a whitelisted address in the address book only
+func (s *WalletManager) Withdraw(req WithdrawRequest) (*Withdrawal, error) {
+	address, err := LookupWithdrawAddress(req.AddressName, req.Asset)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := s.GetNetworks(req.Asset)
+	if err != nil {
+		return nil, err
+	}
+	var network *NetworkInfo
+	for _, n := range networks {
+		if n.Network == address.Network {
+			network = n
+		}
+	}
+	if network == nil {
+		return nil, fmt.Errorf("network %s of %s not found", address.Network, req.Asset)
+	}
+	if err := ValidateWithdraw(network, req.Amount); err != nil {
+		return nil, err
+	}
+
+	s.lg.Warn("Withdraw Start", "asset", req.Asset, "amount", req.Amount, "address", address.Name, "network", address.Network)
+	service := s.client.NewCreateWithdrawService().
+		Coin(string(req.Asset)).
+		Network(address.Network).
+		Address(address.Address).
+		Amount(req.Amount.String())
+	if address.Tag != "" {
+		service.AddressTag(address.Tag)
+	}
+	if req.WithdrawOrderID != "" {
+		service.WithdrawOrderID(req.WithdrawOrderID)
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		s.lg.Error("Withdraw Failed", "asset", req.Asset, "amount", req.Amount, "address", address.Name, "err", err)
+		alerting.Notify(err, "Withdraw Failed in binance", "asset", req.Asset, "amount", req.Amount)
+		return nil, err
+	}
+	alerting.Info("Withdraw Submitted in Binance", "asset", req.Asset, "amount", req.Amount, "address", address.Name)
+	return &Withdrawal{
+		ID:              res.ID,
+		WithdrawOrderID: req.WithdrawOrderID,
+		Asset:           req.Asset,
+		Network:         address.Network,
+		Address:         address.Address,
+		Tag:             address.Tag,
+		Amount:          req.Amount,
+		Fee:             network.WithdrawFee,
+		Status:          TransferStatusPending,
+		ApplyTime:       time.Now().UnixMilli(),
+	}, nil
+}
+
+func (s *WalletManager) ListWithdrawals(asset Asset, startTime, endTime int64) ([]*Withdrawal, error) {
+	service := s.client.NewListWithdrawsService()
+	if asset != "" {
+		service.Coin(string(asset))
+	}
+	if startTime > 0 {
+		service.StartTime(startTime)
+	}
+	if endTime > 0 {
+		service.EndTime(endTime)
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	withdrawals := make([]*Withdrawal, len(res))
+	for i, w := range res {
+		applyTime, _ := time.ParseInLocation("2006-01-02 15:04:05", w.ApplyTime, time.UTC)
+		withdrawals[i] = &Withdrawal{
+			ID:              w.ID,
+			WithdrawOrderID: w.WithdrawOrderID,
+			Asset:           Asset(w.Coin),
+			Network:         w.Network,
+			Address:         w.Address,
+			Amount:          NewDecimalFromStringIgnoreErr(w.Amount),
+			Fee:             NewDecimalFromStringIgnoreErr(w.TransactionFee),
+			TxID:            w.TxID,
+			Status:          convertToWithdrawStatus(w.Status),
+			ApplyTime:       applyTime.UnixMilli(),
+		}
+	}
+	return withdrawals, nil
+}
+
+func (s *WalletManager) ListDeposits(asset Asset, startTime, endTime int64) ([]*Deposit, error) {
+	service := s.client.NewListDepositsService()
+	if asset != "" {
+		service.Coin(string(asset))
+	}
+	if startTime > 0 {
+		service.StartTime(startTime)
+	}
+	if endTime > 0 {
+		service.EndTime(endTime)
+	}
+	res, err := service.Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	deposits := make([]*Deposit, len(res))
+	for i, d := range res {
+		deposits[i] = &Deposit{
+			Asset:      Asset(d.Coin),
+			Network:    d.Network,
+			Address:    d.Address,
+			Tag:        d.AddressTag,
+			Amount:     NewDecimalFromStringIgnoreErr(d.Amount),
+			TxID:       d.TxID,
+			Status:     convertToDepositStatus(d.Status),
+			InsertTime: d.InsertTime,
+		}
+	}
+	return deposits, nil
+}
+
+// convertToWithdrawStatus 0:Email Sent, 1:Cancelled, 2:Awaiting Approval, 3:Rejected, 4:Processing, 5:Failure, 6:Completed
+func convertToWithdrawStatus(status int) TransferStatus {
+	switch status {
+	case 0, 2:
+		return TransferStatusPending
+	case 4:
+		return TransferStatusProcessing
+	case 6:
+		return TransferStatusSuccess
+	case 1:
+		return TransferStatusCanceled
+	case 3, 5:
+		return TransferStatusFailed
+	}
+	return TransferStatus(strconv.Itoa(status))
+}
+
+// convertToDepositStatus 0:pending, 6:credited but cannot withdraw, 7:Wrong Deposit, 8:Waiting User confirm, 1:success
+func convertToDepositStatus(status int) TransferStatus {
+	switch status {
+	case 0, 8:
+		return TransferStatusPending
+	case 6:
+		return TransferStatusProcessing
+	case 1:
+		return TransferStatusSuccess
+	case 7:
+		return TransferStatusFailed
+	}
+	return TransferStatus(strconv.Itoa(status))
+}
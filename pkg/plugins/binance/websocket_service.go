@@ -32,6 +32,12 @@ func getCombinedEndpoint() string {
 	return baseCombinedMainURL
 }
 
+// streamName of a public endpoint for alerts, like binance btcusdt@depth5
+func streamName(endpoint string) string {
+	endpoint = strings.TrimPrefix(endpoint, getWsEndpoint()+"/")
+	return "binance " + strings.TrimPrefix(endpoint, getCombinedEndpoint())
+}
+
 // WsPartialDepthEvent define websocket partial depth book event
 type WsPartialDepthEvent struct {
 	Symbol       string
@@ -80,7 +86,7 @@ func wsPartialDepthServe(endpoint string, symbol string, handler WsPartialDepthH
 		}
 		handler(event)
 	}
-	return NewWsServe(endpoint, wsHandler)
+	return NewWsServe(streamName(endpoint), endpoint, wsHandler)
 }
 func WsCombinedPartialDepthServe100Ms(symbolLevels map[string]string, handler WsPartialDepthHandler, errHandler ErrHandler) (*WsServe, error) {
 	endpoint := getCombinedEndpoint()
@@ -129,7 +135,7 @@ func wsCombinedPartialDepthServe(endpoint string, errHandler ErrHandler, handler
 		}
 		handler(event)
 	}
-	return NewWsServe(endpoint, wsHandler)
+	return NewWsServe(streamName(endpoint), endpoint, wsHandler)
 }
 
 // WsDepthHandler handle websocket depth event
@@ -175,7 +181,7 @@ func wsDepthServe(endpoint string, handler WsDepthHandler, errHandler ErrHandler
 		}
 		handler(event)
 	}
-	return NewWsServe(endpoint, wsHandler)
+	return NewWsServe(streamName(endpoint), endpoint, wsHandler)
 }
 
 // WsDepthEvent define websocket depth event
@@ -238,7 +244,7 @@ func wsCombinedDepthServe(endpoint string, handler WsDepthHandler, errHandler Er
 		}
 		handler(event)
 	}
-	return NewWsServe(endpoint, wsHandler)
+	return NewWsServe(streamName(endpoint), endpoint, wsHandler)
 }
 
 func newJSON(data []byte) (j *simplejson.Json, err error) {
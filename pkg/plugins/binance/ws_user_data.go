@@ -14,7 +14,7 @@ const (
 	BUserDataEventTypeOutboundAccountPosition BUserDataEventType = "outboundAccountPosition"
 	BUserDataEventTypeBalanceUpdate           BUserDataEventType = "balanceUpdate"
 	BUserDataEventTypeExecutionReport         BUserDataEventType = "executionReport"
-	BUserDataEventTypeListStatus              BUserDataEventType = "ListStatus"
+	BUserDataEventTypeListStatus              BUserDataEventType = "listStatus"
 )
 
 // BWsUserDataEvent define user data event
@@ -90,6 +90,7 @@ type BWsOCOUpdate struct {
 	ListOrderStatus string `json:"L"`
 	RejectReason    string `json:"r"`
 	ClientOrderId   string `json:"C"` // List Client Order ID
+	TransactionTime int64  `json:"T"`
 	Orders          BWsOCOOrderList
 }
 
@@ -155,9 +156,16 @@ func WsUserDataServe(listenKey string, handler WsUserDataHandler, errHandler Err
 				errHandler(err)
 				return
 			}
+			// orders of list are in "O", not matched by the untagged field
+			err = json.Unmarshal(message, &event.OCOUpdate.Orders)
+			if err != nil {
+				errHandler(err)
+				return
+			}
 		}
 
 		handler(event)
 	}
-	return NewWsServe(endpoint, wsHandler)
+	// the listenKey of the endpoint is a secret, it is left out of the name
+	return NewWsServe("binance user data", endpoint, wsHandler)
 }
@@ -0,0 +1,106 @@
+package plugins
+
+import (
+	"context"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"time"
+)
+
+const (
+	ExchangeInfoServiceName = "ExchangeInfoService"
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         ExchangeInfoServiceName,
+		Instance:     &ExchangeInfoService{},
+		InitPriority: registry.Low,
+	})
+}
+
+// ExchangeInfoService syncs exchangeInfo of every plugin periodically,
+// and publishes SymbolStoppedTrading, SymbolResumedTrading and SymbolFiltersChanged on bus.
+type ExchangeInfoService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	interval time.Duration
+}
+
+func (s *ExchangeInfoService) Init() error {
+	s.lg = log.New("service.exchange_info")
+	s.interval = setting.ExchangeInfoRefreshInterval
+	return nil
+}
+
+func (s *ExchangeInfoService) IsDisabled() bool {
+	return s.interval <= 0
+}
+
+func (s *ExchangeInfoService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, plugin := range GetExPlugins() {
+				s.refresh(plugin)
+			}
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+func (s *ExchangeInfoService) refresh(plugin *ExPlugin) {
+	changes, err := plugin.Instance.GetBaseInfoManager().RefreshExchangeInfo()
+	if err != nil {
+		s.lg.Error("failed to refresh exchange info", "exchange", plugin.ExName, "err", err)
+		return
+	}
+	for _, change := range changes {
+		s.lg.Warn("symbol info changed", "exchange", change.Exchange, "symbol", change.Symbol, "type", change.Type)
+		if err := s.publish(change); err != nil {
+			s.lg.Error("failed to publish symbol info change", "exchange", change.Exchange, "symbol", change.Symbol, "err", err)
+		}
+	}
+}
+
+func (s *ExchangeInfoService) publish(change *SymbolInfoChange) error {
+	now := time.Now()
+	switch {
+	case change.StoppedTrading():
+		status := SymbolStatusDelisted
+		if change.New != nil {
+			status = change.New.Status
+		}
+		alerting.Info("symbol stopped trading", "exchange", change.Exchange, "symbol", change.Symbol, "status", status)
+		return s.Bus.Publish(&SymbolStoppedTrading{
+			Exchange: change.Exchange,
+			Symbol:   change.Symbol,
+			Status:   status,
+			Time:     now,
+		})
+	case change.ResumedTrading():
+		alerting.Info("symbol resumed trading", "exchange", change.Exchange, "symbol", change.Symbol)
+		return s.Bus.Publish(&SymbolResumedTrading{
+			Exchange: change.Exchange,
+			Symbol:   change.Symbol,
+			Time:     now,
+		})
+	case change.Type == SymbolInfoChangeTypeFilters:
+		return s.Bus.Publish(&SymbolFiltersChanged{
+			Exchange: change.Exchange,
+			Symbol:   change.Symbol,
+			Info:     change.New,
+			Time:     now,
+		})
+	}
+	return nil
+}
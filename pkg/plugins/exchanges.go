@@ -0,0 +1,7 @@
+package plugins
+
+// exchanges register their plugin factories, the ones enabled in [exchanges] are constructed at startup
+import (
+	_ "jasonzhu.com/coin_labor/pkg/plugins/binance"
+	_ "jasonzhu.com/coin_labor/pkg/plugins/mexc"
+)
@@ -0,0 +1,550 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FundingArbServiceName = "FundingArbService"
+
+	fundingArbDepthLimit      = 5
+	fundingArbOrderTimeout    = 30 * time.Second
+	fundingArbMarkPriceTTL    = 10 * time.Second
+	fundingArbWsRetryInterval = 5 * time.Second
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         FundingArbServiceName,
+		Instance:     &FundingArbService{},
+		InitPriority: registry.Low,
+	})
+}
+
+// FundingArbService holds delta-neutral pairs of spot and perpetual legs to receive funding.
+// It enters when the annualized funding beats the threshold after fees, and exits when it flips or the basis converges.
+type FundingArbService struct {
+	lg     log.Logger
+	Bus    bus.Bus              `inject:""`
+	Orders *OrderTrackerService `inject:""`
+
+	// owned by the Run loop, replaced by paramsC on reload
+	fundingArbParams
+	paramsC chan *fundingArbParams
+
+	dryRun       bool
+	spotExchange Exchange
+	perpExchange Exchange
+	derivatives  DerivativesInterface
+	store        *fundingArbStore
+	halted       *HaltedSymbols
+
+	rwM       sync.RWMutex
+	marks     map[Symbol]*MarkPrice
+	positions map[Symbol]*FundingArbPosition
+}
+
+// fundingArbParams thresholds, size and watchlist applied without restart
+type fundingArbParams struct {
+	cfg      FundingArbConfig
+	notional decimal.Decimal
+	symbols  []Symbol
+	interval time.Duration
+}
+
+func newFundingArbParams() *fundingArbParams {
+	params := &fundingArbParams{
+		cfg: FundingArbConfig{
+			EntryCarry:  decimal.NewFromFloat(setting.FundingArbEntryCarry),
+			ExitCarry:   decimal.NewFromFloat(setting.FundingArbExitCarry),
+			ExitBasis:   decimal.NewFromFloat(setting.FundingArbExitBasis),
+			FeeRate:     decimal.NewFromFloat(setting.FundingArbFeeRate),
+			HoldingDays: setting.FundingArbHoldingDays,
+		},
+		notional: decimal.NewFromFloat(setting.FundingArbNotional),
+		interval: setting.FundingArbInterval,
+	}
+	for _, asset := range setting.FundingArbAssets {
+		if asset = strings.TrimSpace(asset); asset != "" {
+			params.symbols = append(params.symbols, NewSymbol(ToAsset(asset)))
+		}
+	}
+	return params
+}
+
+func (s *FundingArbService) Init() error {
+	s.lg = log.New("service.funding_arb")
+	s.fundingArbParams = *newFundingArbParams()
+	s.paramsC = make(chan *fundingArbParams, 1)
+	s.dryRun = setting.FundingArbDryRun
+	s.spotExchange = Exchange(setting.FundingArbSpotExchange)
+	s.perpExchange = Exchange(setting.FundingArbPerpExchange)
+	if GetExPluginByExchange(s.spotExchange) == nil {
+		return fmt.Errorf("spot exchange %s is not supported", s.spotExchange)
+	}
+	perp := GetExPluginByExchange(s.perpExchange)
+	if perp == nil {
+		return fmt.Errorf("perpetual exchange %s is not supported", s.perpExchange)
+	}
+	derivatives, err := GetDerivativesInterface(perp)
+	if err != nil {
+		return fmt.Errorf("perpetual exchange %s: %w", s.perpExchange, err)
+	}
+	s.derivatives = derivatives
+
+	s.marks = make(map[Symbol]*MarkPrice)
+	s.positions = make(map[Symbol]*FundingArbPosition)
+	s.store = newFundingArbStore(filepath.Join(setting.DataPath, "funding_arb"))
+	positions, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+	for _, position := range positions {
+		// positions of dry run are never unwound by real orders, and the other way around
+		if position.DryRun != s.dryRun {
+			s.lg.Warn("position of another mode is dropped", "symbol", position.Symbol, "dryRun", position.DryRun)
+			continue
+		}
+		s.positions[position.Symbol] = position
+	}
+	s.halted = NewHaltedSymbols()
+	s.halted.Listen(s.Bus)
+	s.Bus.AddEventListener(s.onConfigChanged)
+	s.lg.Info("funding arbitrage loaded", "symbols", len(s.symbols), "positions", len(s.positions),
+		"dryRun", s.dryRun)
+	return nil
+}
+
+func (s *FundingArbService) IsDisabled() bool {
+	return !setting.FundingArbEnabled
+}
+
+func (s *FundingArbService) Run(ctx context.Context) error {
+	stopWatch := s.startWatchMarkPrice(ctx)
+	defer func() { stopWatch() }()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, symbol := range s.evaluated() {
+				s.evaluate(symbol)
+			}
+		case params := <-s.paramsC:
+			resubscribe := !SameSymbols(s.symbols, params.symbols)
+			s.fundingArbParams = *params
+			ticker.Reset(s.interval)
+			if resubscribe {
+				stopWatch()
+				stopWatch = s.startWatchMarkPrice(ctx)
+			}
+			s.lg.Info("funding arbitrage params reloaded", "symbols", len(s.symbols), "resubscribed", resubscribe)
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+// Positions returns copies of open positions
+func (s *FundingArbService) Positions() []FundingArbPosition {
+	s.rwM.RLock()
+	defer s.rwM.RUnlock()
+	positions := make([]FundingArbPosition, 0, len(s.positions))
+	for _, position := range s.positions {
+		positions = append(positions, *position)
+	}
+	return positions
+}
+
+// evaluated the watchlist, and symbols of open positions removed from it, which are only exited
+func (s *FundingArbService) evaluated() []Symbol {
+	symbols := append([]Symbol(nil), s.symbols...)
+	watched := make(map[Symbol]bool, len(symbols))
+	for _, symbol := range symbols {
+		watched[symbol] = true
+	}
+	s.rwM.RLock()
+	defer s.rwM.RUnlock()
+	for symbol := range s.positions {
+		if !watched[symbol] {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// onConfigChanged new params are applied by the Run loop
+func (s *FundingArbService) onConfigChanged(event *setting.ConfigChanged) error {
+	if !event.SectionChanged("funding_arb") {
+		return nil
+	}
+	// params not applied yet are replaced
+	select {
+	case <-s.paramsC:
+	default:
+	}
+	s.paramsC <- newFundingArbParams()
+	return nil
+}
+
+// startWatchMarkPrice of the watchlist, until the returned func is called
+func (s *FundingArbService) startWatchMarkPrice(ctx context.Context) context.CancelFunc {
+	watchCtx, cancel := context.WithCancel(ctx)
+	go s.watchMarkPrice(watchCtx, s.symbols)
+	return cancel
+}
+
+// watchMarkPrice keeps mark prices of the symbols from websocket, REST API is used when they are stale
+func (s *FundingArbService) watchMarkPrice(ctx context.Context, symbols []Symbol) {
+	infoC := make(chan *MarkPrice, 100)
+	go func() {
+		for {
+			select {
+			case mark := <-infoC:
+				s.rwM.Lock()
+				s.marks[mark.Symbol] = mark
+				s.rwM.Unlock()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for {
+		if err := s.derivatives.WsWatchMarkPrice(ctx, infoC, symbols...); err != nil {
+			s.lg.Error("failed to watch mark price", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(fundingArbWsRetryInterval):
+		}
+	}
+}
+
+func (s *FundingArbService) markPrice(symbol Symbol) (*MarkPrice, error) {
+	s.rwM.RLock()
+	mark, ok := s.marks[symbol]
+	s.rwM.RUnlock()
+	if ok && time.Since(time.UnixMilli(mark.Time)) < fundingArbMarkPriceTTL {
+		return mark, nil
+	}
+	return s.derivatives.GetMarkPrice(symbol)
+}
+
+func (s *FundingArbService) snapshot(symbol Symbol) (*FundingArbSnapshot, error) {
+	mark, err := s.markPrice(symbol)
+	if err != nil {
+		return nil, err
+	}
+	depth, err := GetExPluginByExchange(s.spotExchange).GetMarketInfoManager().FetchDepth(symbol, fundingArbDepthLimit)
+	if err != nil {
+		return nil, err
+	}
+	ask, bid, err := depth.Top()
+	if err != nil {
+		return nil, err
+	}
+	return &FundingArbSnapshot{Symbol: symbol, SpotBid: bid.Price, SpotAsk: ask.Price, Mark: *mark}, nil
+}
+
+func (s *FundingArbService) evaluate(symbol Symbol) {
+	lg := s.lg.New("symbol", symbol)
+	// legs can't be entered or closed until the symbol resumes trading on both exchanges
+	if s.halted.IsHalted(symbol, s.spotExchange, s.perpExchange) {
+		lg.Debug("symbol stopped trading, skipped")
+		return
+	}
+	snapshot, err := s.snapshot(symbol)
+	if err != nil {
+		lg.Warn("failed to get funding snapshot", "err", err)
+		return
+	}
+
+	s.rwM.RLock()
+	position, ok := s.positions[symbol]
+	s.rwM.RUnlock()
+	if !ok {
+		signal, enter := EvaluateFundingArbEntry(snapshot, s.cfg)
+		if signal != nil {
+			lg.Debug("funding carry", "direction", signal.Direction, "carry", signal.Carry, "cost", signal.Cost,
+				"netCarry", signal.NetCarry, "basis", signal.Basis)
+		}
+		if enter {
+			s.enter(lg, snapshot, signal)
+		}
+		return
+	}
+
+	if s.accrue(lg, position, snapshot) {
+		s.save(lg)
+	}
+	if position.Closing() {
+		s.exit(lg, position, snapshot, "retrying legs not closed")
+	} else if exit, reason := position.ShouldExit(snapshot, s.cfg); exit {
+		s.exit(lg, position, snapshot, reason)
+	}
+}
+
+// accrue funding settled since the last one seen, fees of the exchange in live mode, estimated by funding rates in dry run
+func (s *FundingArbService) accrue(lg log.Logger, position *FundingArbPosition, snapshot *FundingArbSnapshot) bool {
+	s.rwM.RLock()
+	since := position.LastFundingTime + 1
+	if position.LastFundingTime == 0 {
+		since = position.EntryTime.UnixMilli()
+	}
+	s.rwM.RUnlock()
+	if !position.DryRun {
+		fees, err := s.derivatives.ListFundingFees(position.Symbol, since)
+		if err != nil {
+			lg.Warn("failed to list funding fees", "err", err)
+			return false
+		}
+		s.rwM.Lock()
+		defer s.rwM.Unlock()
+		return position.AccrueFees(fees)
+	}
+	rates, err := s.derivatives.ListFundingRates(position.Symbol, since, 0, 0)
+	if err != nil {
+		lg.Warn("failed to list funding rates", "err", err)
+		return false
+	}
+	s.rwM.Lock()
+	defer s.rwM.Unlock()
+	accrued := false
+	for _, rate := range rates {
+		if position.Accrue(rate.Rate, snapshot.Mark.MarkPrice, rate.FundingTime) {
+			accrued = true
+		}
+	}
+	return accrued
+}
+
+func (s *FundingArbService) enter(lg log.Logger, snapshot *FundingArbSnapshot, signal *FundingArbSignal) {
+	spotPrice := snapshot.SpotAsk
+	if signal.Direction == FundingArbShortSpotLongPerp {
+		spotPrice = snapshot.SpotBid
+	}
+	quantity, err := s.quantity(snapshot.Symbol, s.notional.Div(spotPrice))
+	if err != nil {
+		lg.Warn("failed to size funding arbitrage", "err", err)
+		return
+	}
+	position := &FundingArbPosition{
+		Symbol:     snapshot.Symbol,
+		Direction:  signal.Direction,
+		Quantity:   quantity,
+		SpotPrice:  spotPrice,
+		PerpPrice:  snapshot.Mark.MarkPrice,
+		EntryBasis: signal.Basis,
+		EntryTime:  time.Now(),
+		DryRun:     s.dryRun,
+	}
+	lg.Info("funding arbitrage entry planned", "direction", signal.Direction, "quantity", quantity,
+		"netCarry", signal.NetCarry, "basis", signal.Basis, "dryRun", position.DryRun)
+	Opportunities.Publish(&Opportunity{
+		Strategy:  FundingArbServiceName,
+		Symbol:    snapshot.Symbol,
+		Exchanges: []Exchange{s.spotExchange, s.perpExchange},
+		Direction: string(signal.Direction),
+		Edge:      signal.NetCarry,
+		Taken:     !position.DryRun,
+		Time:      position.EntryTime,
+	})
+
+	if !position.DryRun {
+		spotSide, perpSide := SideTypeBuy, SideTypeSell
+		if signal.Direction == FundingArbShortSpotLongPerp {
+			spotSide, perpSide = SideTypeSell, SideTypeBuy
+		}
+		spotFilled, filled, err := s.spotOrder(snapshot.Symbol, spotSide, spotPrice, quantity)
+		if err != nil {
+			alerting.Notify(err, "funding arbitrage spot leg failed", "symbol", snapshot.Symbol, "side", spotSide)
+			if filled.IsPositive() {
+				s.unwind(snapshot, oppositeSide(spotSide), filled, decimal.Zero)
+			}
+			return
+		}
+		position.SpotPrice = spotFilled
+		perpFilled, filled, err := s.perpOrder(snapshot.Symbol, perpSide, snapshot.Mark.MarkPrice, quantity, false)
+		if err != nil {
+			// never leave the spot leg unhedged
+			alerting.NotifyRightNow(err, "funding arbitrage perpetual leg failed, unwinding spot leg", "symbol", snapshot.Symbol)
+			s.unwind(snapshot, oppositeSide(spotSide), quantity, filled)
+			return
+		}
+		position.PerpPrice = perpFilled
+	}
+
+	s.rwM.Lock()
+	s.positions[position.Symbol] = position
+	s.rwM.Unlock()
+	s.save(lg)
+	alerting.Info("funding arbitrage entered", "symbol", position.Symbol, "direction", position.Direction,
+		"quantity", position.Quantity, "netCarry", signal.NetCarry.StringFixed(4), "dryRun", position.DryRun)
+}
+
+// unwind closes the legs of a failed entry, spot by a market order and perpetual by a reduce-only one
+func (s *FundingArbService) unwind(snapshot *FundingArbSnapshot, spotSide SideType, spotQuantity, perpQuantity decimal.Decimal) {
+	if perpQuantity.IsPositive() {
+		perpSide := SideTypeBuy
+		if spotSide == SideTypeBuy {
+			perpSide = SideTypeSell
+		}
+		if _, _, err := s.perpOrder(snapshot.Symbol, perpSide, snapshot.Mark.MarkPrice, perpQuantity, true); err != nil {
+			alerting.Raise(alerting.SeverityCritical, fundingArbAlertKey(snapshot.Symbol, "perp"),
+				"failed to unwind perpetual leg of funding arbitrage", "symbol", snapshot.Symbol, "quantity", perpQuantity, "err", err)
+		}
+	}
+	price := snapshot.SpotBid
+	if spotSide == SideTypeBuy {
+		price = snapshot.SpotAsk
+	}
+	if _, _, err := s.spotOrder(snapshot.Symbol, spotSide, price, spotQuantity); err != nil {
+		alerting.Raise(alerting.SeverityCritical, fundingArbAlertKey(snapshot.Symbol, "spot"),
+			"failed to unwind spot leg of funding arbitrage", "symbol", snapshot.Symbol, "quantity", spotQuantity, "err", err)
+	}
+}
+
+// exit closes the perpetual leg by reduce-only order first, then the spot leg.
+// The quantity closed of each leg is saved, a failed exit retries only what is left open.
+func (s *FundingArbService) exit(lg log.Logger, position *FundingArbPosition, snapshot *FundingArbSnapshot, reason string) {
+	lg.Info("funding arbitrage exit planned", "reason", reason, "accruedFunding", position.AccruedFunding,
+		"dryRun", position.DryRun)
+	if !position.DryRun {
+		spotSide, perpSide := SideTypeSell, SideTypeBuy
+		spotPrice := snapshot.SpotBid
+		if position.Direction == FundingArbShortSpotLongPerp {
+			spotSide, perpSide = SideTypeBuy, SideTypeSell
+			spotPrice = snapshot.SpotAsk
+		}
+		if open := position.Quantity.Sub(position.PerpClosed); open.IsPositive() {
+			_, filled, err := s.perpOrder(position.Symbol, perpSide, snapshot.Mark.MarkPrice, open, true)
+			s.closed(lg, &position.PerpClosed, filled)
+			if err != nil {
+				alerting.Notify(err, "failed to close perpetual leg of funding arbitrage", "symbol", position.Symbol)
+				return
+			}
+		}
+		if open := position.Quantity.Sub(position.SpotClosed); open.IsPositive() {
+			_, filled, err := s.spotOrder(position.Symbol, spotSide, spotPrice, open)
+			s.closed(lg, &position.SpotClosed, filled)
+			if err != nil {
+				alerting.Raise(alerting.SeverityCritical, fundingArbAlertKey(position.Symbol, "spot"),
+					"failed to close spot leg of funding arbitrage, it is unhedged",
+					"symbol", position.Symbol, "quantity", open, "err", err)
+				return
+			}
+		}
+		alerting.Resolve(fundingArbAlertKey(position.Symbol, "spot"))
+	}
+
+	s.rwM.Lock()
+	delete(s.positions, position.Symbol)
+	s.rwM.Unlock()
+	s.save(lg)
+	alerting.Info("funding arbitrage exited", "symbol", position.Symbol, "reason", reason,
+		"accruedFunding", position.AccruedFunding, "held", time.Since(position.EntryTime).Round(time.Minute),
+		"dryRun", position.DryRun)
+}
+
+// closed adds the quantity filled by exit to the leg, and saves the position
+func (s *FundingArbService) closed(lg log.Logger, leg *decimal.Decimal, filled decimal.Decimal) {
+	if !filled.IsPositive() {
+		return
+	}
+	s.rwM.Lock()
+	*leg = leg.Add(filled)
+	s.rwM.Unlock()
+	s.save(lg)
+}
+
+// quantity truncated to step sizes of both legs, it must be tradable on both
+func (s *FundingArbService) quantity(symbol Symbol, quantity decimal.Decimal) (decimal.Decimal, error) {
+	for _, exchange := range []Exchange{s.spotExchange, s.perpExchange} {
+		info, err := GetExPluginByExchange(exchange).GetBaseInfoManager().GetSymbolBasicInfo(symbol)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		quantity = quantity.Truncate(info.StepSizePrecision)
+	}
+	if !quantity.IsPositive() {
+		return decimal.Zero, fmt.Errorf("notional %s is too small", s.notional)
+	}
+	return quantity, nil
+}
+
+// spotOrder market order waited until filled, it returns the average price and the quantity filled
+func (s *FundingArbService) spotOrder(symbol Symbol, side SideType, price, quantity decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	plan := NewMarketOrder(symbol, side, price, quantity)
+	order, err := s.Orders.Submit(s.spotExchange, *plan)
+	// an order of unknown send status may be filled, it is resolved by the tracker
+	if err != nil && !IsSendStatusUnknown(err) {
+		return decimal.Zero, decimal.Zero, err
+	}
+	return s.waitFilled(order, price)
+}
+
+func (s *FundingArbService) perpOrder(symbol Symbol, side SideType, price, quantity decimal.Decimal, reduceOnly bool) (decimal.Decimal, decimal.Decimal, error) {
+	plan := NewFuturesOrderPlan(*NewMarketOrder(symbol, side, price, quantity), PositionSideBoth, reduceOnly)
+	order, err := s.Orders.SubmitFutures(s.perpExchange, *plan)
+	if err != nil && !IsSendStatusUnknown(err) {
+		return decimal.Zero, decimal.Zero, err
+	}
+	return s.waitFilled(order, price)
+}
+
+// waitFilled an order not filled in time is canceled, the quantity filled before is returned with the error
+func (s *FundingArbService) waitFilled(order *TrackedOrder, price decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	if err := order.WaitFor(OrderStatusTypeFilled, fundingArbOrderTimeout); err != nil {
+		state, cancelErr := s.Orders.Cancel(order.State().Plan.ClientOrderID)
+		if cancelErr != nil {
+			s.lg.Error("failed to cancel order not filled", "clientOrderID", state.Plan.ClientOrderID, "err", cancelErr)
+		}
+		return decimal.Zero, state.FilledQuantity, err
+	}
+	state := order.State()
+	if state.FilledQuantity.IsPositive() && state.FilledQuoteVolume.IsPositive() {
+		return state.FilledQuoteVolume.Div(state.FilledQuantity), state.FilledQuantity, nil
+	}
+	return price, *state.Plan.Quantity, nil
+}
+
+// fundingArbAlertKey alerts of a leg are deduplicated per symbol
+func fundingArbAlertKey(symbol Symbol, leg string) string {
+	return fmt.Sprintf("funding_arb.%s.%s", symbol, leg)
+}
+
+func (s *FundingArbService) save(lg log.Logger) {
+	s.rwM.RLock()
+	positions := make([]*FundingArbPosition, 0, len(s.positions))
+	for _, position := range s.positions {
+		p := *position
+		positions = append(positions, &p)
+	}
+	s.rwM.RUnlock()
+	if err := s.store.Save(positions); err != nil {
+		lg.Error("failed to save funding arbitrage positions", "err", err)
+		alerting.Notify(err, "failed to save funding arbitrage positions")
+	}
+}
+
+func oppositeSide(side SideType) SideType {
+	if side == SideTypeBuy {
+		return SideTypeSell
+	}
+	return SideTypeBuy
+}
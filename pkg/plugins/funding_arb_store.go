@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"encoding/json"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"os"
+	"path/filepath"
+)
+
+// fundingArbStore open positions of funding arbitrage in a single json file
+type fundingArbStore struct {
+	path string
+}
+
+func newFundingArbStore(dir string) *fundingArbStore {
+	return &fundingArbStore{path: filepath.Join(dir, "positions.json")}
+}
+
+// Save writes a temp file and renames it, a crash never leaves half written positions
+func (s *fundingArbStore) Save(positions []*FundingArbPosition) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(positions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *fundingArbStore) Load() ([]*FundingArbPosition, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var positions []*FundingArbPosition
+	if err := json.Unmarshal(data, &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
@@ -19,6 +19,10 @@ type Account struct {
 	UpdateTime       uint64 `json:"updateTime"`
 	AccountType      string `json:"accountType"`
 
+	// derivatives only
+	Margin    *Margin
+	Positions []*Position
+
 	BalancesMap map[Asset]Balance
 	rwM         sync.RWMutex
 }
@@ -0,0 +1,180 @@
+package general
+
+import (
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"sync"
+	"time"
+)
+
+// SymbolStatus define trading status of symbol, same as the status in exchangeInfo of Binance
+type SymbolStatus string
+
+// SymbolInfoChangeType define what has been changed between two syncs of exchangeInfo
+type SymbolInfoChangeType string
+
+const (
+	SymbolStatusTrading  SymbolStatus = "TRADING"
+	SymbolStatusHalt     SymbolStatus = "HALT"
+	SymbolStatusBreak    SymbolStatus = "BREAK"
+	SymbolStatusDelisted SymbolStatus = "DELISTED" // not in exchangeInfo any more
+
+	SymbolInfoChangeTypeListed   SymbolInfoChangeType = "LISTED"
+	SymbolInfoChangeTypeDelisted SymbolInfoChangeType = "DELISTED"
+	SymbolInfoChangeTypeStatus   SymbolInfoChangeType = "STATUS"
+	SymbolInfoChangeTypeFilters  SymbolInfoChangeType = "FILTERS"
+)
+
+func (s *SymbolBasicInfo) IsTrading() bool {
+	return s.Status == SymbolStatusTrading
+}
+
+// FiltersEqual compares price, quantity and notional filters.
+func (s *SymbolBasicInfo) FiltersEqual(o *SymbolBasicInfo) bool {
+	return s.MinPrice.Equal(o.MinPrice) &&
+		s.MaxPrice.Equal(o.MaxPrice) &&
+		s.TickSize.Equal(o.TickSize) &&
+		s.MinQuantity.Equal(o.MinQuantity) &&
+		s.MaxQuantity.Equal(o.MaxQuantity) &&
+		s.StepSize.Equal(o.StepSize) &&
+		s.MinNotional.Equal(o.MinNotional)
+}
+
+// SymbolInfoChange is one difference found by refreshing exchangeInfo. Old is nil for LISTED, New is nil for DELISTED.
+type SymbolInfoChange struct {
+	Exchange Exchange
+	Symbol   Symbol
+	Type     SymbolInfoChangeType
+	Old      *SymbolBasicInfo
+	New      *SymbolBasicInfo
+}
+
+// StoppedTrading tells if the symbol was trading before this change and is not any more.
+func (c *SymbolInfoChange) StoppedTrading() bool {
+	wasTrading := c.Old != nil && c.Old.IsTrading()
+	isTrading := c.New != nil && c.New.IsTrading()
+	return wasTrading && !isTrading
+}
+
+// ResumedTrading tells if the symbol is trading again after this change.
+func (c *SymbolInfoChange) ResumedTrading() bool {
+	wasTrading := c.Old != nil && c.Old.IsTrading()
+	isTrading := c.New != nil && c.New.IsTrading()
+	return !wasTrading && isTrading
+}
+
+// DiffSymbolsBasicInfo compares two snapshots of exchangeInfo keyed by symbol alias.
+// Nothing is reported for the very first sync, and only symbols accepted by watched are compared.
+func DiffSymbolsBasicInfo(exchange Exchange, old, new map[string]*SymbolBasicInfo, toSymbol func(alias string) Symbol, watched func(symbol Symbol) bool) []*SymbolInfoChange {
+	if len(old) == 0 {
+		return nil
+	}
+
+	var changes []*SymbolInfoChange
+	for alias, newInfo := range new {
+		symbol := toSymbol(alias)
+		if !watched(symbol) {
+			continue
+		}
+		change := &SymbolInfoChange{
+			Exchange: exchange,
+			Symbol:   symbol,
+			Old:      old[alias],
+			New:      newInfo,
+		}
+		if change.Old == nil {
+			change.Type = SymbolInfoChangeTypeListed
+		} else if change.Old.Status != newInfo.Status {
+			change.Type = SymbolInfoChangeTypeStatus
+		} else if !change.Old.FiltersEqual(newInfo) {
+			change.Type = SymbolInfoChangeTypeFilters
+		} else {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	for alias, oldInfo := range old {
+		if _, ok := new[alias]; ok {
+			continue
+		}
+		symbol := toSymbol(alias)
+		if !watched(symbol) {
+			continue
+		}
+		changes = append(changes, &SymbolInfoChange{
+			Exchange: exchange,
+			Symbol:   symbol,
+			Type:     SymbolInfoChangeTypeDelisted,
+			Old:      oldInfo,
+		})
+	}
+	return changes
+}
+
+// SymbolStoppedTrading is published on bus when a symbol is halted or delisted, strategies should stop quoting it.
+type SymbolStoppedTrading struct {
+	Exchange Exchange
+	Symbol   Symbol
+	Status   SymbolStatus
+	Time     time.Time
+}
+
+// SymbolResumedTrading is published on bus when a symbol is back to TRADING.
+type SymbolResumedTrading struct {
+	Exchange Exchange
+	Symbol   Symbol
+	Time     time.Time
+}
+
+// HaltedSymbols keeps symbols stopped trading per exchange from SymbolStoppedTrading and SymbolResumedTrading,
+// so strategies can skip them until they resume.
+type HaltedSymbols struct {
+	m      sync.RWMutex
+	halted map[Exchange]map[Symbol]bool
+}
+
+func NewHaltedSymbols() *HaltedSymbols {
+	return &HaltedSymbols{halted: make(map[Exchange]map[Symbol]bool)}
+}
+
+// Listen adds the listeners of halt and resume events to bus
+func (h *HaltedSymbols) Listen(b bus.Bus) {
+	b.AddEventListener(h.onSymbolStoppedTrading)
+	b.AddEventListener(h.onSymbolResumedTrading)
+}
+
+func (h *HaltedSymbols) onSymbolStoppedTrading(event *SymbolStoppedTrading) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if h.halted[event.Exchange] == nil {
+		h.halted[event.Exchange] = make(map[Symbol]bool)
+	}
+	h.halted[event.Exchange][event.Symbol] = true
+	return nil
+}
+
+func (h *HaltedSymbols) onSymbolResumedTrading(event *SymbolResumedTrading) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+	delete(h.halted[event.Exchange], event.Symbol)
+	return nil
+}
+
+// IsHalted whether the symbol stopped trading on any of the exchanges
+func (h *HaltedSymbols) IsHalted(symbol Symbol, exchanges ...Exchange) bool {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	for _, exchange := range exchanges {
+		if h.halted[exchange][symbol] {
+			return true
+		}
+	}
+	return false
+}
+
+// SymbolFiltersChanged is published on bus when tick size, step size or other filters of a symbol are changed.
+type SymbolFiltersChanged struct {
+	Exchange Exchange
+	Symbol   Symbol
+	Info     *SymbolBasicInfo
+	Time     time.Time
+}
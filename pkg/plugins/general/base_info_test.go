@@ -0,0 +1,52 @@
+package general
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDiffSymbolsBasicInfo(t *testing.T) {
+	watchAll := func(symbol Symbol) bool { return symbol.BaseAsset != UnKnown }
+	old := map[string]*SymbolBasicInfo{
+		"INJUSDT": {Symbol: "INJUSDT", Status: SymbolStatusTrading, TickSize: decimal.NewFromFloat(0.01)},
+		"WOOUSDT": {Symbol: "WOOUSDT", Status: SymbolStatusTrading},
+		"NEOUSDT": {Symbol: "NEOUSDT", Status: SymbolStatusTrading},
+	}
+	new := map[string]*SymbolBasicInfo{
+		"INJUSDT": {Symbol: "INJUSDT", Status: SymbolStatusTrading, TickSize: decimal.NewFromFloat(0.001)},
+		"WOOUSDT": {Symbol: "WOOUSDT", Status: SymbolStatusHalt},
+		"OGNUSDT": {Symbol: "OGNUSDT", Status: SymbolStatusTrading},
+	}
+
+	if changes := DiffSymbolsBasicInfo(Binance, nil, new, ParseSymbol, watchAll); len(changes) != 0 {
+		t.Fatalf("first sync should not report changes, got %d", len(changes))
+	}
+
+	changes := DiffSymbolsBasicInfo(Binance, old, new, ParseSymbol, watchAll)
+	types := make(map[Asset]SymbolInfoChangeType)
+	for _, change := range changes {
+		types[change.Symbol.BaseAsset] = change.Type
+	}
+	expected := map[Asset]SymbolInfoChangeType{
+		INJ: SymbolInfoChangeTypeFilters,
+		WOO: SymbolInfoChangeTypeStatus,
+		OGN: SymbolInfoChangeTypeListed,
+		NEO: SymbolInfoChangeTypeDelisted,
+	}
+	if len(types) != len(expected) {
+		t.Fatalf("unexpected changes: %v", types)
+	}
+	for asset, typ := range expected {
+		if types[asset] != typ {
+			t.Fatalf("change of %s is %s, expected: %s", asset, types[asset], typ)
+		}
+	}
+
+	for _, change := range changes {
+		stopped := change.Symbol.BaseAsset == WOO || change.Symbol.BaseAsset == NEO
+		if change.StoppedTrading() != stopped {
+			t.Fatalf("StoppedTrading of %s should be %v", change.Symbol, stopped)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package general
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// ConvertOrderStatus status of convert order
+type ConvertOrderStatus string
+
+const (
+	ConvertOrderStatusProcess       ConvertOrderStatus = "PROCESS"
+	ConvertOrderStatusAcceptSuccess ConvertOrderStatus = "ACCEPT_SUCCESS"
+	ConvertOrderStatusSuccess       ConvertOrderStatus = "SUCCESS"
+	ConvertOrderStatusFail          ConvertOrderStatus = "FAIL"
+)
+
+var ErrConvertQuoteExpired = errors.New("convert quote expired")
+
+// ConvertQuote quote of converting FromAmount of FromAsset to ToAmount of ToAsset, valid until ValidTimestamp
+type ConvertQuote struct {
+	QuoteID        string
+	FromAsset      Asset
+	ToAsset        Asset
+	FromAmount     decimal.Decimal
+	ToAmount       decimal.Decimal
+	Ratio          decimal.Decimal
+	InverseRatio   decimal.Decimal
+	ValidTimestamp int64 // ms
+}
+
+func (q *ConvertQuote) IsValid(now time.Time) bool {
+	return now.UnixMilli() < q.ValidTimestamp
+}
+
+type ConvertOrder struct {
+	OrderID    string
+	Status     ConvertOrderStatus
+	FromAsset  Asset
+	ToAsset    Asset
+	FromAmount decimal.Decimal
+	ToAmount   decimal.Decimal
+	Ratio      decimal.Decimal
+	CreateTime int64
+}
+
+func (o *ConvertOrder) IsDone() bool {
+	return o.Status == ConvertOrderStatusSuccess || o.Status == ConvertOrderStatusFail
+}
+
+// ConvertInterface converts assets without touching the order book
+type ConvertInterface interface {
+	// GetQuote either fromAmount or toAmount is set, the other one should be zero
+	GetQuote(from, to Asset, fromAmount, toAmount decimal.Decimal) (*ConvertQuote, error)
+	AcceptQuote(quoteID string) (*ConvertOrder, error)
+	GetConvertOrder(orderID string) (*ConvertOrder, error)
+}
+
+// ConvertProvider is optional for ExManager, only plugins supporting convert implement it.
+type ConvertProvider interface {
+	GetConvertInterface() ConvertInterface
+}
+
+var ErrConvertNotSupported = errors.New("convert is not supported by the exchange")
+
+// GetConvertInterface returns the ConvertInterface of the plugin, or ErrConvertNotSupported
+func GetConvertInterface(manager ExManager) (ConvertInterface, error) {
+	if provider, ok := manager.(ConvertProvider); ok {
+		return provider.GetConvertInterface(), nil
+	}
+	return nil, ErrConvertNotSupported
+}
+
+// Convert accepts the quote if still valid and polls the order until done or timeout
+func Convert(convertInterface ConvertInterface, quote *ConvertQuote, pollInterval, timeout time.Duration) (*ConvertOrder, error) {
+	if !quote.IsValid(time.Now()) {
+		return nil, ErrConvertQuoteExpired
+	}
+	if err := DefaultTradingSwitch.Check(); err != nil {
+		return nil, err
+	}
+	order, err := convertInterface.AcceptQuote(quote.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for !order.IsDone() {
+		if time.Now().After(deadline) {
+			return order, fmt.Errorf("timeout waiting for convert order %s, status: %s", order.OrderID, order.Status)
+		}
+		time.Sleep(pollInterval)
+		res, err := convertInterface.GetConvertOrder(order.OrderID)
+		if err != nil {
+			return order, err
+		}
+		order = res
+	}
+	if order.Status == ConvertOrderStatusFail {
+		return order, fmt.Errorf("convert order %s failed", order.OrderID)
+	}
+	return order, nil
+}
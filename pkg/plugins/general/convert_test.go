@@ -0,0 +1,46 @@
+package general
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type fakeConvertInterface struct {
+	polled int
+}
+
+func (f *fakeConvertInterface) GetQuote(from, to Asset, fromAmount, toAmount decimal.Decimal) (*ConvertQuote, error) {
+	return &ConvertQuote{QuoteID: "q", FromAsset: from, ToAsset: to, ValidTimestamp: time.Now().Add(10 * time.Second).UnixMilli()}, nil
+}
+
+func (f *fakeConvertInterface) AcceptQuote(quoteID string) (*ConvertOrder, error) {
+	return &ConvertOrder{OrderID: "1", Status: ConvertOrderStatusProcess}, nil
+}
+
+func (f *fakeConvertInterface) GetConvertOrder(orderID string) (*ConvertOrder, error) {
+	f.polled++
+	if f.polled < 2 {
+		return &ConvertOrder{OrderID: orderID, Status: ConvertOrderStatusAcceptSuccess}, nil
+	}
+	return &ConvertOrder{OrderID: orderID, Status: ConvertOrderStatusSuccess}, nil
+}
+
+func TestConvert(t *testing.T) {
+	fake := &fakeConvertInterface{}
+	quote, _ := fake.GetQuote(INJ, USDT, decimal.NewFromInt(1), decimal.Zero)
+	order, err := Convert(fake, quote, time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != ConvertOrderStatusSuccess || fake.polled != 2 {
+		t.Fatalf("unexpected order: %+v, polled: %d", order, fake.polled)
+	}
+
+	quote.ValidTimestamp = time.Now().Add(-time.Second).UnixMilli()
+	if _, err := Convert(fake, quote, time.Millisecond, time.Second); !errors.Is(err, ErrConvertQuoteExpired) {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
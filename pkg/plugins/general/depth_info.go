@@ -1,6 +1,17 @@
 package general
 
-import "errors"
+import (
+	"errors"
+	"jasonzhu.com/coin_labor/core/components/bus"
+)
+
+// DepthUpdates carries depth received from websockets, tagged with the exchange
+var DepthUpdates = bus.NewTopic[ExchangeDepth]("depth")
+
+type ExchangeDepth struct {
+	Exchange Exchange
+	Depth    *DepthInfo
+}
 
 type DepthInfo struct {
 	Symbol          Symbol
@@ -0,0 +1,139 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+)
+
+// PositionSide BOTH for one-way mode, LONG or SHORT for hedge mode
+type PositionSide string
+
+const (
+	PositionSideBoth  PositionSide = "BOTH"
+	PositionSideLong  PositionSide = "LONG"
+	PositionSideShort PositionSide = "SHORT"
+)
+
+type MarginType string
+
+const (
+	MarginTypeCrossed  MarginType = "CROSSED"
+	MarginTypeIsolated MarginType = "ISOLATED"
+)
+
+// Position of perpetual contract, Amount is negative for short in one-way mode
+type Position struct {
+	Symbol           Symbol
+	PositionSide     PositionSide
+	Amount           decimal.Decimal
+	EntryPrice       decimal.Decimal
+	MarkPrice        decimal.Decimal
+	UnrealizedProfit decimal.Decimal
+	Notional         decimal.Decimal
+	LiquidationPrice decimal.Decimal
+	IsolatedMargin   decimal.Decimal
+	Leverage         int
+	MarginType       MarginType
+	UpdateTime       int64
+}
+
+func (p *Position) IsEmpty() bool {
+	return p.Amount.IsZero()
+}
+
+// Margin summary of derivatives account, in the margin asset
+type Margin struct {
+	TotalWalletBalance    decimal.Decimal
+	TotalMarginBalance    decimal.Decimal
+	TotalUnrealizedProfit decimal.Decimal
+	TotalInitialMargin    decimal.Decimal
+	TotalMaintMargin      decimal.Decimal
+	AvailableBalance      decimal.Decimal
+}
+
+// MarginRatio maintenance margin over margin balance, the account is liquidated at 1
+func (m *Margin) MarginRatio() decimal.Decimal {
+	if !m.TotalMarginBalance.IsPositive() {
+		return decimal.Zero
+	}
+	return m.TotalMaintMargin.Div(m.TotalMarginBalance)
+}
+
+// MarkPrice mark price and funding rate of perpetual contract
+type MarkPrice struct {
+	Symbol          Symbol
+	MarkPrice       decimal.Decimal
+	IndexPrice      decimal.Decimal
+	FundingRate     decimal.Decimal
+	NextFundingTime int64
+	Time            int64
+}
+
+// FundingRate settled at FundingTime, paid by longs to shorts if positive
+type FundingRate struct {
+	Symbol      Symbol
+	Rate        decimal.Decimal
+	FundingTime int64
+}
+
+// FundingFee funding received if positive, paid if negative
+type FundingFee struct {
+	Symbol Symbol
+	Asset  Asset
+	Amount decimal.Decimal
+	Time   int64
+}
+
+// FuturesOrderPlan OrderPlan with position side and reduce-only
+type FuturesOrderPlan struct {
+	OrderPlan
+	PositionSide PositionSide
+	ReduceOnly   bool
+}
+
+func NewFuturesOrderPlan(plan OrderPlan, positionSide PositionSide, reduceOnly bool) *FuturesOrderPlan {
+	return &FuturesOrderPlan{
+		OrderPlan:    plan,
+		PositionSide: positionSide,
+		ReduceOnly:   reduceOnly,
+	}
+}
+
+// NewHedgeOrderPlan shorts quantity at market in one-way mode, to hedge spot inventory of the same quantity
+func NewHedgeOrderPlan(symbol Symbol, price, quantity decimal.Decimal) *FuturesOrderPlan {
+	return NewFuturesOrderPlan(*NewMarketOrder(symbol, SideTypeSell, price, quantity), PositionSideBoth, false)
+}
+
+// NewUnhedgeOrderPlan closes the short at market, it never opens a long
+func NewUnhedgeOrderPlan(symbol Symbol, price, quantity decimal.Decimal) *FuturesOrderPlan {
+	return NewFuturesOrderPlan(*NewMarketOrder(symbol, SideTypeBuy, price, quantity), PositionSideBoth, true)
+}
+
+// DerivativesInterface is provided by plugins of perpetual contracts, orders of OrderInterface are placed
+// in one-way mode without reduce-only.
+type DerivativesInterface interface {
+	GetMarkPrice(symbol Symbol) (*MarkPrice, error)
+	WsWatchMarkPrice(ctx context.Context, infoC chan *MarkPrice, symbols ...Symbol) error
+	ListFundingRates(symbol Symbol, startTime, endTime int64, limit int) ([]*FundingRate, error)
+	// ListFundingFees funding fees of our positions since startTime
+	ListFundingFees(symbol Symbol, startTime int64) ([]*FundingFee, error)
+	GetPositions() ([]*Position, error)
+	SetLeverage(symbol Symbol, leverage int) error
+	CreateFuturesOrder(plan FuturesOrderPlan) (*CreateOrderResponse, error)
+}
+
+// DerivativesProvider is optional for ExManager, only plugins of derivatives implement it.
+type DerivativesProvider interface {
+	GetDerivativesInterface() DerivativesInterface
+}
+
+var ErrDerivativesNotSupported = errors.New("derivatives are not supported by the exchange")
+
+// GetDerivativesInterface returns the DerivativesInterface of the plugin, or ErrDerivativesNotSupported
+func GetDerivativesInterface(manager ExManager) (DerivativesInterface, error) {
+	if provider, ok := manager.(DerivativesProvider); ok {
+		return provider.GetDerivativesInterface(), nil
+	}
+	return nil, ErrDerivativesNotSupported
+}
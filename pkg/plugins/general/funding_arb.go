@@ -0,0 +1,159 @@
+package general
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// FundingPeriodsPerYear funding is settled every 8 hours
+const FundingPeriodsPerYear = 365 * 3
+
+// FundingArbDirection delta-neutral pair of spot and perpetual legs
+type FundingArbDirection string
+
+const (
+	// FundingArbLongSpotShortPerp receives positive funding paid by longs
+	FundingArbLongSpotShortPerp FundingArbDirection = "LONG_SPOT_SHORT_PERP"
+	// FundingArbShortSpotLongPerp sells spot inventory and receives negative funding paid by shorts
+	FundingArbShortSpotLongPerp FundingArbDirection = "SHORT_SPOT_LONG_PERP"
+)
+
+// sign +1 if positive funding is received
+func (d FundingArbDirection) sign() decimal.Decimal {
+	if d == FundingArbShortSpotLongPerp {
+		return decimal.NewFromInt(-1)
+	}
+	return decimal.NewFromInt(1)
+}
+
+// FundingArbConfig carries and basis are ratios, carries are annualized
+type FundingArbConfig struct {
+	EntryCarry  decimal.Decimal // min annualized carry after fees to enter
+	ExitCarry   decimal.Decimal // exit when annualized carry drops below, 0 to exit only when it flips
+	ExitBasis   decimal.Decimal // exit when basis converges within
+	FeeRate     decimal.Decimal // fees of entering and exiting both legs, in ratio of notional
+	HoldingDays int             // expected holding period to amortize fees
+}
+
+// AnnualizedFunding funding rate of one period to annual
+func AnnualizedFunding(rate decimal.Decimal) decimal.Decimal {
+	return rate.Mul(decimal.NewFromInt(FundingPeriodsPerYear))
+}
+
+// FundingArbSnapshot spot book top and perpetual mark price of a symbol
+type FundingArbSnapshot struct {
+	Symbol  Symbol
+	SpotBid decimal.Decimal
+	SpotAsk decimal.Decimal
+	Mark    MarkPrice
+}
+
+// Basis perpetual premium over the spot price the direction trades at: ask to buy, bid to sell
+func (s *FundingArbSnapshot) Basis(direction FundingArbDirection) decimal.Decimal {
+	spot := s.SpotAsk
+	if direction == FundingArbShortSpotLongPerp {
+		spot = s.SpotBid
+	}
+	if !spot.IsPositive() {
+		return decimal.Zero
+	}
+	return s.Mark.MarkPrice.Sub(spot).Div(spot)
+}
+
+// FundingArbSignal an entry opportunity, NetCarry is the annualized carry after fees and adverse basis
+type FundingArbSignal struct {
+	Direction FundingArbDirection
+	Carry     decimal.Decimal
+	Cost      decimal.Decimal
+	NetCarry  decimal.Decimal
+	Basis     decimal.Decimal
+}
+
+// EvaluateFundingArbEntry the direction receiving the funding is taken if its carry beats the threshold.
+// Fees and the basis lost on convergence are amortized over the holding period.
+func EvaluateFundingArbEntry(snapshot *FundingArbSnapshot, cfg FundingArbConfig) (*FundingArbSignal, bool) {
+	rate := snapshot.Mark.FundingRate
+	if rate.IsZero() || cfg.HoldingDays <= 0 {
+		return nil, false
+	}
+	direction := FundingArbLongSpotShortPerp
+	if rate.IsNegative() {
+		direction = FundingArbShortSpotLongPerp
+	}
+	basis := snapshot.Basis(direction)
+	// the short perp loses if perp is below spot and converges up, and the other way around
+	adverseBasis := decimal.Max(decimal.Zero, basis.Mul(direction.sign()).Neg())
+	amortize := decimal.NewFromInt(365).Div(decimal.NewFromInt(int64(cfg.HoldingDays)))
+	signal := &FundingArbSignal{
+		Direction: direction,
+		Carry:     AnnualizedFunding(rate.Abs()),
+		Cost:      cfg.FeeRate.Add(adverseBasis).Mul(amortize),
+		Basis:     basis,
+	}
+	signal.NetCarry = signal.Carry.Sub(signal.Cost)
+	return signal, signal.NetCarry.GreaterThanOrEqual(cfg.EntryCarry)
+}
+
+// FundingArbPosition both legs of Quantity, funding accrued since entry
+type FundingArbPosition struct {
+	Symbol          Symbol              `json:"symbol"`
+	Direction       FundingArbDirection `json:"direction"`
+	Quantity        decimal.Decimal     `json:"quantity"`
+	SpotPrice       decimal.Decimal     `json:"spotPrice"`
+	PerpPrice       decimal.Decimal     `json:"perpPrice"`
+	EntryBasis      decimal.Decimal     `json:"entryBasis"`
+	EntryTime       time.Time           `json:"entryTime"`
+	AccruedFunding  decimal.Decimal     `json:"accruedFunding"`
+	LastFundingTime int64               `json:"lastFundingTime"`
+	DryRun          bool                `json:"dryRun"` // never traded, funding is estimated
+	// quantity of each leg closed by exit, the rest is retried
+	PerpClosed decimal.Decimal `json:"perpClosed"`
+	SpotClosed decimal.Decimal `json:"spotClosed"`
+}
+
+// Closing tells if a part of the position is closed, the rest must be closed regardless of signals
+func (p *FundingArbPosition) Closing() bool {
+	return p.PerpClosed.IsPositive() || p.SpotClosed.IsPositive()
+}
+
+// Carry annualized carry of the position at the funding rate, negative if paying
+func (p *FundingArbPosition) Carry(rate decimal.Decimal) decimal.Decimal {
+	return AnnualizedFunding(rate).Mul(p.Direction.sign())
+}
+
+// Accrue estimates funding of the settlement at fundingTime, settlements seen are ignored
+func (p *FundingArbPosition) Accrue(rate, markPrice decimal.Decimal, fundingTime int64) bool {
+	if fundingTime <= p.LastFundingTime {
+		return false
+	}
+	p.AccruedFunding = p.AccruedFunding.Add(p.Quantity.Mul(markPrice).Mul(rate).Mul(p.Direction.sign()))
+	p.LastFundingTime = fundingTime
+	return true
+}
+
+// AccrueFees adds funding fees settled by the exchange after the last one seen
+func (p *FundingArbPosition) AccrueFees(fees []*FundingFee) bool {
+	accrued := false
+	for _, fee := range fees {
+		if fee.Time <= p.LastFundingTime {
+			continue
+		}
+		p.AccruedFunding = p.AccruedFunding.Add(fee.Amount)
+		p.LastFundingTime = fee.Time
+		accrued = true
+	}
+	return accrued
+}
+
+// ShouldExit when the carry flips or decays below ExitCarry, or the basis converges within ExitBasis
+func (p *FundingArbPosition) ShouldExit(snapshot *FundingArbSnapshot, cfg FundingArbConfig) (bool, string) {
+	if carry := p.Carry(snapshot.Mark.FundingRate); carry.LessThan(cfg.ExitCarry) || carry.IsNegative() {
+		return true, fmt.Sprintf("carry %s below %s", carry.StringFixed(4), cfg.ExitCarry)
+	}
+	basis := snapshot.Basis(p.Direction)
+	if p.EntryBasis.Abs().GreaterThan(cfg.ExitBasis) && basis.Abs().LessThanOrEqual(cfg.ExitBasis) {
+		return true, fmt.Sprintf("basis converged from %s to %s", p.EntryBasis.StringFixed(5), basis.StringFixed(5))
+	}
+	return false, ""
+}
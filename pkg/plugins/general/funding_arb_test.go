@@ -0,0 +1,100 @@
+package general
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFundingArbEntry(t *testing.T) {
+	cfg := FundingArbConfig{
+		EntryCarry:  decimal.NewFromFloat(0.15),
+		ExitCarry:   decimal.NewFromFloat(0.03),
+		ExitBasis:   decimal.NewFromFloat(0.0005),
+		FeeRate:     decimal.NewFromFloat(0.002),
+		HoldingDays: 7,
+	}
+	snapshot := func(rate, bid, ask, mark float64) *FundingArbSnapshot {
+		return &FundingArbSnapshot{
+			Symbol:  NewSymbol(INJ),
+			SpotBid: decimal.NewFromFloat(bid),
+			SpotAsk: decimal.NewFromFloat(ask),
+			Mark:    MarkPrice{MarkPrice: decimal.NewFromFloat(mark), FundingRate: decimal.NewFromFloat(rate)},
+		}
+	}
+
+	signal, ok := EvaluateFundingArbEntry(snapshot(0.0003, 9.99, 10, 10.01), cfg)
+	if !ok || signal.Direction != FundingArbLongSpotShortPerp {
+		t.Fatalf("positive funding should be taken by long spot and short perp: %+v", signal)
+	}
+	if _, ok := EvaluateFundingArbEntry(snapshot(0.0001, 9.99, 10, 10.01), cfg); ok {
+		t.Fatal("carry below fees should not be taken")
+	}
+	// perp below spot is lost by the short perp on convergence
+	if _, ok := EvaluateFundingArbEntry(snapshot(0.0003, 9.99, 10, 9.9), cfg); ok {
+		t.Fatal("adverse basis should not be taken")
+	}
+	signal, ok = EvaluateFundingArbEntry(snapshot(-0.0003, 10, 10.01, 9.99), cfg)
+	if !ok || signal.Direction != FundingArbShortSpotLongPerp {
+		t.Fatalf("negative funding should be taken by short spot and long perp: %+v", signal)
+	}
+}
+
+func TestFundingArbExit(t *testing.T) {
+	cfg := FundingArbConfig{ExitCarry: decimal.NewFromFloat(0.03), ExitBasis: decimal.NewFromFloat(0.0005)}
+	position := &FundingArbPosition{
+		Symbol:     NewSymbol(INJ),
+		Direction:  FundingArbLongSpotShortPerp,
+		Quantity:   decimal.NewFromInt(10),
+		EntryBasis: decimal.NewFromFloat(0.001),
+	}
+	snapshot := &FundingArbSnapshot{
+		Symbol:  position.Symbol,
+		SpotBid: decimal.NewFromFloat(9.99),
+		SpotAsk: decimal.NewFromFloat(10),
+		Mark:    MarkPrice{MarkPrice: decimal.NewFromFloat(10.01), FundingRate: decimal.NewFromFloat(0.0003)},
+	}
+	if exit, reason := position.ShouldExit(snapshot, cfg); exit {
+		t.Fatalf("position should be held: %s", reason)
+	}
+
+	snapshot.Mark.FundingRate = decimal.NewFromFloat(-0.0001)
+	if exit, _ := position.ShouldExit(snapshot, cfg); !exit {
+		t.Fatal("position should exit when carry flips")
+	}
+
+	snapshot.Mark.FundingRate = decimal.NewFromFloat(0.0003)
+	snapshot.Mark.MarkPrice = decimal.NewFromFloat(10.002)
+	if exit, _ := position.ShouldExit(snapshot, cfg); !exit {
+		t.Fatal("position should exit when basis converges")
+	}
+}
+
+func TestFundingArbAccrue(t *testing.T) {
+	position := &FundingArbPosition{Direction: FundingArbShortSpotLongPerp, Quantity: decimal.NewFromInt(10)}
+	if !position.Accrue(decimal.NewFromFloat(-0.0003), decimal.NewFromInt(10), 1000) {
+		t.Fatal("funding should be accrued")
+	}
+	if position.Accrue(decimal.NewFromFloat(-0.0003), decimal.NewFromInt(10), 1000) {
+		t.Fatal("settlement should be accrued once")
+	}
+	if !position.AccruedFunding.Equal(decimal.NewFromFloat(0.03)) {
+		t.Fatalf("unexpected accrued funding %s", position.AccruedFunding)
+	}
+
+	fees := []*FundingFee{{Amount: decimal.NewFromFloat(0.5), Time: 900}, {Amount: decimal.NewFromFloat(0.02), Time: 2000}}
+	if !position.AccrueFees(fees) || !position.AccruedFunding.Equal(decimal.NewFromFloat(0.05)) {
+		t.Fatalf("unexpected accrued funding %s", position.AccruedFunding)
+	}
+}
+
+func TestFundingArbPositionClosing(t *testing.T) {
+	position := &FundingArbPosition{Quantity: decimal.NewFromInt(10)}
+	if position.Closing() {
+		t.Fatal("position should not be closing before exit")
+	}
+	position.PerpClosed = decimal.NewFromInt(10)
+	if !position.Closing() {
+		t.Fatal("position should be closing once the perpetual leg is closed")
+	}
+}
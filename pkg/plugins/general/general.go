@@ -27,15 +27,20 @@ const (
 	TradingSpot        = "spot"
 	TradingDerivatives = "derivatives"
 
-	Binance  Exchange = "binance"
-	MEXC     Exchange = "MEXC"
-	CoinEX   Exchange = "coinEx"
-	OKX      Exchange = "okx"
-	CoinBase Exchange = "coinBase"
+	Binance        Exchange = "binance"
+	BinanceFutures Exchange = "binanceFutures" // USDⓈ-M perpetual contracts
+	MEXC           Exchange = "MEXC"
+	CoinEX         Exchange = "coinEx"
+	OKX            Exchange = "okx"
+	CoinBase       Exchange = "coinBase"
 )
 
 const (
-	USDT Asset = "USDT"
+	USDT  Asset = "USDT"
+	USDC  Asset = "USDC"
+	BUSD  Asset = "BUSD"
+	FDUSD Asset = "FDUSD"
+	BTC   Asset = "BTC"
 
 	NEO Asset = "NEO"
 	OGN Asset = "OGN"
@@ -67,24 +72,42 @@ const (
 	DefaultQuoteCoin = USDT
 )
 
+// IsQuoteAssetEnabled whether pairs quoted by the asset are listed, see [exchange_info] quote_assets
+func IsQuoteAssetEnabled(asset Asset) bool {
+	for _, quote := range setting.ExchangeInfoQuoteAssets {
+		if strings.EqualFold(strings.TrimSpace(quote), string(asset)) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretAliases exchanges falling back to credentials of another exchange, e.g. futures of the same account
+var secretAliases = map[Exchange]Exchange{
+	BinanceFutures: Binance,
+}
+
+// GetSecretsForExchanger returns nil if credentials of the exchange are missing, its plugin should be disabled then
 func GetSecretsForExchanger(exchange Exchange) *setting.Secret {
-	switch exchange {
-	case Binance:
-		return &setting.SecretsConf.Binance
-	case MEXC:
-		return &setting.SecretsConf.MEXC
-	default:
-		return nil
+	secret, err := setting.SecretsConf.Get(string(exchange))
+	if err == nil {
+		return secret
+	}
+	if alias, ok := secretAliases[exchange]; ok {
+		return GetSecretsForExchanger(alias)
 	}
+	glg.Warn("no credentials", "exchange", exchange, "err", err)
+	return nil
 }
 
 // SymbolBasicInfo including asset precision, quota asset, quota asset precision, etc.
 type SymbolBasicInfo struct {
-	Symbol              string `json:"symbol"`
-	BaseAsset           string `json:"baseAsset"`
-	BaseAssetPrecision  int32  `json:"baseAssetPrecision"` // 似乎没啥用
-	QuoteAsset          string `json:"quoteAsset"`
-	QuoteAssetPrecision int32  `json:"quoteAssetPrecision"` // 似乎没啥用
+	Symbol              string       `json:"symbol"`
+	Status              SymbolStatus `json:"status"`
+	BaseAsset           string       `json:"baseAsset"`
+	BaseAssetPrecision  int32        `json:"baseAssetPrecision"` // 似乎没啥用
+	QuoteAsset          string       `json:"quoteAsset"`
+	QuoteAssetPrecision int32        `json:"quoteAssetPrecision"` // 似乎没啥用
 
 	// Price
 	MinPrice          decimal.Decimal
@@ -2,6 +2,7 @@ package general
 
 import (
 	"context"
+	"fmt"
 	"golang.org/x/sync/errgroup"
 	"jasonzhu.com/coin_labor/core/components/bus"
 	"jasonzhu.com/coin_labor/core/components/log"
@@ -23,8 +24,25 @@ const (
 	MEXCMarketDepthWatchFeature
 )
 
+var exchangeFeatureNames = map[ExchangeFeature]string{
+	BinanceUserDataWatchFeature:    "BinanceUserDataWatch",
+	BinanceMarketDepthWatchFeature: "BinanceMarketDepthWatch",
+	MEXCUserDataWatchFeature:       "MEXCUserDataWatch",
+	MEXCMarketDepthWatchFeature:    "MEXCMarketDepthWatch",
+}
+
+func (f ExchangeFeature) String() string {
+	if name, ok := exchangeFeatureNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("ExchangeFeature(%d)", int(f))
+}
+
 var DefaultHealthChecker = newHealthChecker()
 
+// HealthReports carries changes of the overall health, published by DefaultHealthChecker
+var HealthReports = bus.NewTopic[*HealthReport]("health_report")
+
 type HealthData struct {
 	ExchangeFeature ExchangeFeature
 	State           HealthState
@@ -96,19 +114,19 @@ func (s *HealthChecker) run() {
 	})
 }
 
+// handleHealthData reports are published after the lock is released, readers of the states are never held by subscribers
 func (s *HealthChecker) handleHealthData(data HealthData) {
 	s.healthDataMapRWM.Lock()
-	defer s.healthDataMapRWM.Unlock()
 	s.healthDataMap[data.ExchangeFeature] = data.State
+	// confirm if all features are healthy, maybe for auto-recovery in the future
+	allHealthy := data.State == HealthStateHealthy && s.isAllFeaturesHealthy()
+	s.healthDataMapRWM.Unlock()
 	s.lg.Info("got health data", "exchangeFeature", data.ExchangeFeature, "state", data.State, "time", data.Time)
 
 	if data.State == HealthStateUnhealthy {
-		_ = bus.Publish(newHealthReport(HealthStateUnhealthy))
-	} else if data.State == HealthStateHealthy {
-		// confirm if all features are healthy, maybe for auto-recovery in the future
-		if s.isAllFeaturesHealthy() {
-			_ = bus.Publish(newHealthReport(HealthStateHealthy))
-		}
+		HealthReports.Publish(newHealthReport(HealthStateUnhealthy))
+	} else if allHealthy {
+		HealthReports.Publish(newHealthReport(HealthStateHealthy))
 	}
 }
 
@@ -118,6 +136,17 @@ func (s *HealthChecker) IsAllFeaturesHealthy() bool {
 	return s.isAllFeaturesHealthy()
 }
 
+// States copy of the state of every feature
+func (s *HealthChecker) States() map[ExchangeFeature]HealthState {
+	s.healthDataMapRWM.RLock()
+	defer s.healthDataMapRWM.RUnlock()
+	states := make(map[ExchangeFeature]HealthState, len(s.healthDataMap))
+	for feature, state := range s.healthDataMap {
+		states[feature] = state
+	}
+	return states
+}
+
 func (s *HealthChecker) isAllFeaturesHealthy() bool {
 	if s.healthDataMap[BinanceUserDataWatchFeature] == HealthStateHealthy &&
 		s.healthDataMap[BinanceMarketDepthWatchFeature] == HealthStateHealthy &&
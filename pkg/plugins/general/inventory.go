@@ -0,0 +1,134 @@
+package general
+
+import (
+	"github.com/shopspring/decimal"
+	"sync"
+)
+
+// Inventory tradable balances of every exchange, and funds in transit between exchanges
+type Inventory struct {
+	rwM       sync.RWMutex
+	balances  map[Exchange]map[Asset]decimal.Decimal
+	inTransit map[Asset]decimal.Decimal
+}
+
+func NewInventory() *Inventory {
+	return &Inventory{
+		balances:  make(map[Exchange]map[Asset]decimal.Decimal),
+		inTransit: make(map[Asset]decimal.Decimal),
+	}
+}
+
+// Reset replaces tradable balances of the exchange with the account info
+func (i *Inventory) Reset(exchange Exchange, account *Account) {
+	balances := make(map[Asset]decimal.Decimal)
+	account.rwM.RLock()
+	for asset, balance := range account.BalancesMap {
+		balances[asset] = balance.Free
+	}
+	account.rwM.RUnlock()
+
+	i.rwM.Lock()
+	defer i.rwM.Unlock()
+	i.balances[exchange] = balances
+}
+
+func (i *Inventory) Set(exchange Exchange, asset Asset, amount decimal.Decimal) {
+	i.rwM.Lock()
+	defer i.rwM.Unlock()
+	i.set(exchange, asset, amount)
+}
+
+func (i *Inventory) set(exchange Exchange, asset Asset, amount decimal.Decimal) {
+	if _, ok := i.balances[exchange]; !ok {
+		i.balances[exchange] = make(map[Asset]decimal.Decimal)
+	}
+	i.balances[exchange][asset] = amount
+}
+
+// Tradable free balance of asset in the exchange
+func (i *Inventory) Tradable(exchange Exchange, asset Asset) decimal.Decimal {
+	i.rwM.RLock()
+	defer i.rwM.RUnlock()
+	return i.balances[exchange][asset]
+}
+
+func (i *Inventory) InTransit(asset Asset) decimal.Decimal {
+	i.rwM.RLock()
+	defer i.rwM.RUnlock()
+	return i.inTransit[asset]
+}
+
+// Total tradable balances of all exchanges plus funds in transit
+func (i *Inventory) Total(asset Asset) decimal.Decimal {
+	i.rwM.RLock()
+	defer i.rwM.RUnlock()
+	total := i.inTransit[asset]
+	for _, balances := range i.balances {
+		total = total.Add(balances[asset])
+	}
+	return total
+}
+
+// Exchanges having balances in the inventory
+func (i *Inventory) Exchanges() []Exchange {
+	i.rwM.RLock()
+	defer i.rwM.RUnlock()
+	exchanges := make([]Exchange, 0, len(i.balances))
+	for exchange := range i.balances {
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges
+}
+
+// Withdrawn amount leaves the exchange and is in transit
+func (i *Inventory) Withdrawn(exchange Exchange, asset Asset, amount decimal.Decimal) {
+	i.rwM.Lock()
+	defer i.rwM.Unlock()
+	i.set(exchange, asset, decimal.Max(decimal.Zero, i.balances[exchange][asset].Sub(amount)))
+	i.inTransit[asset] = i.inTransit[asset].Add(amount)
+}
+
+// Resumed amount in transit of a transfer withdrawn before restart
+func (i *Inventory) Resumed(asset Asset, amount decimal.Decimal) {
+	i.rwM.Lock()
+	defer i.rwM.Unlock()
+	i.inTransit[asset] = i.inTransit[asset].Add(amount)
+}
+
+// Landed amount in transit is credited to the exchange, the difference is the fee
+func (i *Inventory) Landed(exchange Exchange, asset Asset, amount, credited decimal.Decimal) {
+	i.rwM.Lock()
+	defer i.rwM.Unlock()
+	i.inTransit[asset] = decimal.Max(decimal.Zero, i.inTransit[asset].Sub(amount))
+	i.set(exchange, asset, i.balances[exchange][asset].Add(credited))
+}
+
+// Lost amount in transit will never land, e.g. withdrawal failed, balances are fixed by the next Reset
+func (i *Inventory) Lost(asset Asset, amount decimal.Decimal) {
+	i.rwM.Lock()
+	defer i.rwM.Unlock()
+	i.inTransit[asset] = decimal.Max(decimal.Zero, i.inTransit[asset].Sub(amount))
+}
+
+// Balances copy of tradable balances of the exchange
+func (i *Inventory) Balances(exchange Exchange) map[Asset]decimal.Decimal {
+	i.rwM.RLock()
+	defer i.rwM.RUnlock()
+	balances := make(map[Asset]decimal.Decimal, len(i.balances[exchange]))
+	for asset, amount := range i.balances[exchange] {
+		balances[asset] = amount
+	}
+	return balances
+}
+
+// InTransitAll copy of funds in transit of every asset
+func (i *Inventory) InTransitAll() map[Asset]decimal.Decimal {
+	i.rwM.RLock()
+	defer i.rwM.RUnlock()
+	inTransit := make(map[Asset]decimal.Decimal, len(i.inTransit))
+	for asset, amount := range i.inTransit {
+		inTransit[asset] = amount
+	}
+	return inTransit
+}
@@ -0,0 +1,40 @@
+package general
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestInventoryTransfer(t *testing.T) {
+	inventory := NewInventory()
+	inventory.Reset(Binance, &Account{BalancesMap: map[Asset]Balance{
+		USDT: {Asset: USDT, Free: decimal.NewFromInt(100), Locked: decimal.NewFromInt(5)},
+	}})
+	inventory.Set(MEXC, USDT, decimal.NewFromInt(10))
+
+	inventory.Withdrawn(Binance, USDT, decimal.NewFromInt(50))
+	if !inventory.Tradable(Binance, USDT).Equal(decimal.NewFromInt(50)) || !inventory.InTransit(USDT).Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("unexpected inventory after withdrawn: %s, %s", inventory.Tradable(Binance, USDT), inventory.InTransit(USDT))
+	}
+	if !inventory.Total(USDT).Equal(decimal.NewFromInt(110)) {
+		t.Fatalf("unexpected total: %s", inventory.Total(USDT))
+	}
+
+	inventory.Landed(MEXC, USDT, decimal.NewFromInt(50), decimal.NewFromInt(49))
+	if !inventory.Tradable(MEXC, USDT).Equal(decimal.NewFromInt(59)) || !inventory.InTransit(USDT).IsZero() {
+		t.Fatalf("unexpected inventory after landed: %s, %s", inventory.Tradable(MEXC, USDT), inventory.InTransit(USDT))
+	}
+	if !inventory.Total(USDT).Equal(decimal.NewFromInt(109)) {
+		t.Fatalf("fee should be deducted from total: %s", inventory.Total(USDT))
+	}
+}
+
+func TestInventoryResumed(t *testing.T) {
+	inventory := NewInventory()
+	inventory.Set(Binance, USDT, decimal.NewFromInt(50))
+	inventory.Resumed(USDT, decimal.NewFromInt(50))
+	if !inventory.Tradable(Binance, USDT).Equal(decimal.NewFromInt(50)) || !inventory.InTransit(USDT).Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("balance withdrawn before restart should be kept: %s, %s", inventory.Tradable(Binance, USDT), inventory.InTransit(USDT))
+	}
+}
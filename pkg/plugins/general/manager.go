@@ -2,12 +2,15 @@ package general
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 )
 
 var plugins []*ExPlugin
 var pluginsMap = make(map[Exchange]ExManager)
+var factories []*PluginFactory
 
 type ExPlugin struct {
 	ExName   Exchange
@@ -30,6 +33,50 @@ func Register(plugin *ExPlugin) {
 	pluginsMap[plugin.ExName] = plugin.Instance
 }
 
+// PluginFactory constructs the plugin of an exchange, it is called by InitPlugins once configuration is loaded
+type PluginFactory struct {
+	ExName  Exchange
+	Ranking int
+	New     func() (ExManager, error)
+}
+
+func RegisterFactory(factory *PluginFactory) {
+	factories = append(factories, factory)
+}
+
+// InitPlugins constructs and registers the plugins of enabled exchanges, matched case-insensitively.
+// An exchange without credentials is skipped.
+func InitPlugins(enabled []string) {
+	for _, factory := range factories {
+		if !containsFold(enabled, string(factory.ExName)) {
+			continue
+		}
+		if GetSecretsForExchanger(factory.ExName) == nil {
+			fmt.Printf("plugin [%s] is disabled, its credentials are missing\n", factory.ExName)
+			continue
+		}
+		plugin, err := factory.New()
+		if err != nil {
+			fmt.Printf("failed to init plugin [%s]: %v\n", factory.ExName, err)
+			continue
+		}
+		Register(&ExPlugin{
+			ExName:   factory.ExName,
+			Instance: plugin,
+			Ranking:  factory.Ranking,
+		})
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
 func GetExPlugins() []*ExPlugin {
 	slice := plugins
 	sort.Slice(slice, func(i, j int) bool {
@@ -49,12 +96,16 @@ type ExManager interface {
 	GetMarketInfoManager() MarketInterface
 	GetAccountManager() AccountInterface
 	GetOrderInterface() OrderInterface
+	GetTradeInterface() TradeInterface
+	GetWalletInterface() WalletInterface
 }
 
 type BaseInterface interface {
 	ServerTime() (int64, error)
 	GetSymbolBasicInfo(symbol Symbol) (*SymbolBasicInfo, error)
 	GetSymbolsBasicInfo() map[Symbol]*SymbolBasicInfo
+	// RefreshExchangeInfo syncs exchangeInfo again and returns the changes of watched symbols.
+	RefreshExchangeInfo() ([]*SymbolInfoChange, error)
 }
 
 type MarketInterface interface {
@@ -3,6 +3,7 @@ package general
 import (
 	"context"
 	"errors"
+	"sync"
 )
 
 const DefaultLimit = 10
@@ -11,9 +12,12 @@ type GMarketManager struct {
 	fetchDepthFn   func(symbol Symbol, limit int) *DepthInfo
 	wsWatchDepthFn func(ctx context.Context, infoC chan *DepthInfo, limit int, symbols ...Symbol) error
 
-	Watching           bool
-	WatchingSymbols    []Symbol
 	WatchingDepthLimit int
+
+	// websockets of WsWatchMarketDepth running concurrently, by the id of each call
+	watchM      *sync.RWMutex
+	watches     map[int][]Symbol
+	nextWatchID int
 }
 
 func InitGMarketManager(fetchDepthFn func(symbol Symbol, limit int) *DepthInfo,
@@ -22,15 +26,35 @@ func InitGMarketManager(fetchDepthFn func(symbol Symbol, limit int) *DepthInfo,
 	return GMarketManager{
 		fetchDepthFn:       fetchDepthFn,
 		wsWatchDepthFn:     wsWatchDepthFn,
-		Watching:           false,
 		WatchingDepthLimit: DefaultLimit,
+		watchM:             &sync.RWMutex{},
+		watches:            make(map[int][]Symbol),
 	}
 }
 
 // Public Method
 
 func (s *GMarketManager) IsWatching() bool {
-	return s.Watching
+	s.watchM.RLock()
+	defer s.watchM.RUnlock()
+	return len(s.watches) > 0
+}
+
+// WatchingSymbols of all running websockets, a symbol watched twice is listed once
+func (s *GMarketManager) WatchingSymbols() []Symbol {
+	s.watchM.RLock()
+	defer s.watchM.RUnlock()
+	var symbols []Symbol
+	seen := make(map[Symbol]bool)
+	for _, watched := range s.watches {
+		for _, symbol := range watched {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
 }
 
 // FetchDepth 同步获取DepthInfo数据，Via API.
@@ -47,11 +71,15 @@ func (s *GMarketManager) WsWatchMarketDepth(ctx context.Context, infoC chan *Dep
 		return errors.New("wsWatchDepthFn is not defined")
 	}
 
-	s.WatchingSymbols = symbols
-	s.Watching = true
+	s.watchM.Lock()
+	id := s.nextWatchID
+	s.nextWatchID++
+	s.watches[id] = symbols
+	s.watchM.Unlock()
 	defer func() {
-		s.Watching = false
-		s.WatchingSymbols = []Symbol{}
+		s.watchM.Lock()
+		delete(s.watches, id)
+		s.watchM.Unlock()
 	}()
 
 	return s.wsWatchDepthFn(ctx, infoC, s.WatchingDepthLimit, symbols...)
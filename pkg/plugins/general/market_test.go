@@ -0,0 +1,41 @@
+package general
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWsWatchMarketDepthConcurrent(t *testing.T) {
+	started := make(chan struct{})
+	market := InitGMarketManager(nil, func(ctx context.Context, infoC chan *DepthInfo, limit int, symbols ...Symbol) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil
+	})
+	btc, eth := NewSymbol(BTC), NewSymbol(ETH)
+
+	firstCtx, stopFirst := context.WithCancel(context.Background())
+	firstDone := make(chan struct{})
+	go func() {
+		_ = market.WsWatchMarketDepth(firstCtx, nil, btc)
+		close(firstDone)
+	}()
+	<-started
+	secondCtx, stopSecond := context.WithCancel(context.Background())
+	defer stopSecond()
+	go func() { _ = market.WsWatchMarketDepth(secondCtx, nil, eth) }()
+	<-started
+	if symbols := market.WatchingSymbols(); len(symbols) != 2 {
+		t.Fatalf("expected 2 watched symbols, got %v", symbols)
+	}
+
+	// the first websocket stopping must not clear the second one
+	stopFirst()
+	<-firstDone
+	if !market.IsWatching() {
+		t.Fatal("expected the second websocket to be watching")
+	}
+	if symbols := market.WatchingSymbols(); len(symbols) != 1 || symbols[0] != eth {
+		t.Fatalf("expected only %s watched, got %v", eth, symbols)
+	}
+}
@@ -0,0 +1,56 @@
+package general
+
+import (
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"sync"
+	"time"
+)
+
+// Opportunities carries opportunities of every strategy
+var Opportunities = bus.NewTopic[*Opportunity]("opportunity")
+
+// Opportunity is published to Opportunities by strategies for every edge beating their threshold, Taken is false for dry runs
+type Opportunity struct {
+	Strategy  string          `json:"strategy"`
+	Symbol    Symbol          `json:"symbol"`
+	Exchanges []Exchange      `json:"exchanges"`
+	Direction string          `json:"direction"`
+	Edge      decimal.Decimal `json:"edge"`
+	Taken     bool            `json:"taken"`
+	Time      time.Time       `json:"time"`
+}
+
+// OpportunityLog the most recent opportunities, older ones are dropped
+type OpportunityLog struct {
+	rwM           sync.RWMutex
+	size          int
+	opportunities []Opportunity
+}
+
+func NewOpportunityLog(size int) *OpportunityLog {
+	return &OpportunityLog{size: size}
+}
+
+func (l *OpportunityLog) Add(opportunity Opportunity) {
+	l.rwM.Lock()
+	defer l.rwM.Unlock()
+	l.opportunities = append(l.opportunities, opportunity)
+	if len(l.opportunities) > l.size {
+		l.opportunities = append([]Opportunity(nil), l.opportunities[len(l.opportunities)-l.size:]...)
+	}
+}
+
+// Recent at most limit opportunities, the latest first
+func (l *OpportunityLog) Recent(limit int) []Opportunity {
+	l.rwM.RLock()
+	defer l.rwM.RUnlock()
+	if limit <= 0 || limit > len(l.opportunities) {
+		limit = len(l.opportunities)
+	}
+	res := make([]Opportunity, 0, limit)
+	for i := len(l.opportunities) - 1; i >= 0 && len(res) < limit; i-- {
+		res = append(res, l.opportunities[i])
+	}
+	return res
+}
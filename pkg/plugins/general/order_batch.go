@@ -0,0 +1,95 @@
+package general
+
+import (
+	"sync"
+)
+
+// BatchOrderInterface is optional for OrderInterface, plugins supporting batch APIs implement it.
+// Requests may be split into several calls, when one fails the results of the calls sent before are returned with the error.
+// Use BatchCreateOrders and BatchCancelOrders of this package, they fall back to parallel single requests.
+type BatchOrderInterface interface {
+	BatchCreateOrders(plans []OrderPlan) ([]*BatchOrderResult, error)
+	BatchCancelOrders(symbol Symbol, refs []OrderRef) ([]*BatchOrderResult, error)
+}
+
+// OrderRef refers to an order by OrderID or ClientOrderID.
+type OrderRef struct {
+	OrderID       string
+	ClientOrderID string
+}
+
+// BatchOrderResult is the result of one order in a batch call, in the same order as the request.
+// Err is set if only this order failed.
+type BatchOrderResult struct {
+	OrderID       string
+	ClientOrderID string
+	Status        OrderStatusType // for cancel only
+	Err           error
+}
+
+func (r *BatchOrderResult) OK() bool {
+	return r.Err == nil
+}
+
+// BatchCreateOrders creates orders in batch if supported by the plugin, or creates them in parallel.
+func BatchCreateOrders(orderInterface OrderInterface, plans []OrderPlan) ([]*BatchOrderResult, error) {
+	if err := DefaultTradingSwitch.Check(); err != nil {
+		return nil, err
+	}
+	if batch, ok := orderInterface.(BatchOrderInterface); ok {
+		return batch.BatchCreateOrders(plans)
+	}
+	return ParallelCreateOrders(orderInterface, plans), nil
+}
+
+// BatchCancelOrders cancels orders in batch if supported by the plugin, or cancels them in parallel.
+func BatchCancelOrders(orderInterface OrderInterface, symbol Symbol, refs []OrderRef) ([]*BatchOrderResult, error) {
+	if batch, ok := orderInterface.(BatchOrderInterface); ok {
+		return batch.BatchCancelOrders(symbol, refs)
+	}
+	return ParallelCancelOrders(orderInterface, symbol, refs), nil
+}
+
+// ParallelCreateOrders sends one CreateOrder request for each plan at the same time.
+func ParallelCreateOrders(orderInterface OrderInterface, plans []OrderPlan) []*BatchOrderResult {
+	results := make([]*BatchOrderResult, len(plans))
+	var wg sync.WaitGroup
+	for i := range plans {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := orderInterface.CreateOrder(plans[i])
+			result := &BatchOrderResult{
+				ClientOrderID: plans[i].ClientOrderID,
+				Err:           err,
+			}
+			if res != nil {
+				result.OrderID = res.OrderID
+			}
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// ParallelCancelOrders sends one CancelOrder request for each order at the same time.
+func ParallelCancelOrders(orderInterface OrderInterface, symbol Symbol, refs []OrderRef) []*BatchOrderResult {
+	results := make([]*BatchOrderResult, len(refs))
+	var wg sync.WaitGroup
+	for i := range refs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status, err := orderInterface.CancelOrder(symbol, refs[i].OrderID, refs[i].ClientOrderID)
+			results[i] = &BatchOrderResult{
+				OrderID:       refs[i].OrderID,
+				ClientOrderID: refs[i].ClientOrderID,
+				Status:        status,
+				Err:           err,
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
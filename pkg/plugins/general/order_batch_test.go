@@ -0,0 +1,48 @@
+package general
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeOrderInterface struct {
+	OrderInterface
+}
+
+func (f *fakeOrderInterface) CreateOrder(plan OrderPlan) (*CreateOrderResponse, error) {
+	if plan.Side == SideTypeSell {
+		return nil, errors.New("rejected")
+	}
+	return &CreateOrderResponse{OrderID: "id_" + plan.ClientOrderID, ClientOrderID: plan.ClientOrderID}, nil
+}
+
+func TestBatchCreateOrdersFallback(t *testing.T) {
+	plans := []OrderPlan{
+		{ClientOrderID: "a", Side: SideTypeBuy},
+		{ClientOrderID: "b", Side: SideTypeSell},
+		{ClientOrderID: "c", Side: SideTypeBuy},
+	}
+	results, err := BatchCreateOrders(&fakeOrderInterface{}, plans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result.ClientOrderID != plans[i].ClientOrderID {
+			t.Fatalf("result %d is out of order: %s", i, result.ClientOrderID)
+		}
+		if result.OK() != (plans[i].Side == SideTypeBuy) {
+			t.Fatalf("unexpected result of %s: %v", result.ClientOrderID, result.Err)
+		}
+	}
+	if results[0].OrderID != "id_a" {
+		t.Fatalf("unexpected order id: %s", results[0].OrderID)
+	}
+}
+
+func TestBatchCreateOrdersPaused(t *testing.T) {
+	DefaultTradingSwitch.Pause("test")
+	defer DefaultTradingSwitch.Resume()
+	if _, err := BatchCreateOrders(&fakeOrderInterface{}, []OrderPlan{{ClientOrderID: "a", Side: SideTypeBuy}}); !errors.Is(err, ErrTradingPaused) {
+		t.Fatalf("expected ErrTradingPaused, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package general
+
+import (
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"time"
+)
+
+const (
+	OrderUpdateSourceWs       = "ws"
+	OrderUpdateSourcePoll     = "poll"
+	OrderUpdateSourceOperator = "operator"
+)
+
+var (
+	// OrderSubmissions carries every order sent by OrderTrackerService, accepted or not
+	OrderSubmissions = bus.NewTopic[*OrderSubmitted]("order_submitted")
+	// OrderUpdates carries every change of tracked orders
+	OrderUpdates = bus.NewTopic[*OrderUpdated]("order_updated")
+	// BalanceUpdates carries balance events of user data streams
+	BalanceUpdates = bus.NewTopic[*BalanceUpdated]("balance_updated")
+)
+
+// OrderSubmitted an order is sent to the exchange, Err is set if it is refused,
+// or if it is not known to be refused when SendStatusUnknown is set, see IsSendStatusUnknown
+type OrderSubmitted struct {
+	Exchange          Exchange     `json:"exchange"`
+	Plan              OrderPlan    `json:"plan"`
+	PositionSide      PositionSide `json:"positionSide,omitempty"`
+	ReduceOnly        bool         `json:"reduceOnly,omitempty"`
+	ListClientOrderID string       `json:"listClientOrderId,omitempty"`
+	OrderID           string       `json:"orderId,omitempty"`
+	Err               string       `json:"err,omitempty"`
+	SendStatusUnknown bool         `json:"sendStatusUnknown,omitempty"`
+	// TrackTime before the order is sent, updates of the order are never before it unlike Time
+	TrackTime time.Time `json:"trackTime"`
+	Time      time.Time `json:"time"`
+}
+
+func NewOrderSubmitted(exchange Exchange, plan OrderPlan, trackTime time.Time, res *CreateOrderResponse, err error) *OrderSubmitted {
+	submitted := &OrderSubmitted{Exchange: exchange, Plan: plan, TrackTime: trackTime, Time: time.Now()}
+	if res != nil {
+		submitted.OrderID = res.OrderID
+	}
+	if err != nil {
+		submitted.Err = err.Error()
+		submitted.SendStatusUnknown = IsSendStatusUnknown(err)
+	}
+	return submitted
+}
+
+// OrderUpdated the state of a tracked order after a change, Source tells where the change came from
+type OrderUpdated struct {
+	State  OrderState `json:"state"`
+	Source string     `json:"source"`
+}
+
+// BalanceUpdated Balances for outboundAccountPosition, Delta for balanceUpdate
+type BalanceUpdated struct {
+	Exchange Exchange          `json:"exchange"`
+	Event    UserDataEventType `json:"event"`
+	Balances []WsAccountUpdate `json:"balances,omitempty"`
+	Delta    *WsBalanceUpdate  `json:"delta,omitempty"`
+	Time     time.Time         `json:"time"`
+}
@@ -0,0 +1,249 @@
+package general
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ListStatusType status of order list, https://binance-docs.github.io/apidocs/spot/cn/#oco
+type ListStatusType string
+
+// ListOrderStatusType status of orders in order list
+type ListOrderStatusType string
+
+const (
+	ListStatusTypeResponse    ListStatusType = "RESPONSE"
+	ListStatusTypeExecStarted ListStatusType = "EXEC_STARTED"
+	ListStatusTypeAllDone     ListStatusType = "ALL_DONE"
+
+	ListOrderStatusTypeExecuting ListOrderStatusType = "EXECUTING"
+	ListOrderStatusTypeAllDone   ListOrderStatusType = "ALL_DONE"
+	ListOrderStatusTypeReject    ListOrderStatusType = "REJECT"
+)
+
+// OCOOrderPlan One-Cancels-the-Other, a LIMIT_MAKER order above and a STOP_LOSS_LIMIT order below for SELL,
+// the other way around for BUY.
+type OCOOrderPlan struct {
+	Symbol            Symbol
+	Side              SideType
+	ListClientOrderID string
+	Quantity          decimal.Decimal
+
+	// LIMIT_MAKER leg
+	LimitClientOrderID string
+	Price              decimal.Decimal
+
+	// STOP_LOSS_LIMIT leg
+	StopClientOrderID    string
+	StopPrice            decimal.Decimal
+	StopLimitPrice       decimal.Decimal
+	StopLimitTimeInForce TimeInForceType
+}
+
+func NewOCOOrderPlan(symbol Symbol, side SideType, quantity, price, stopPrice, stopLimitPrice decimal.Decimal) *OCOOrderPlan {
+	return &OCOOrderPlan{
+		Symbol:               symbol,
+		Side:                 side,
+		ListClientOrderID:    genClientOrderID(),
+		Quantity:             quantity,
+		LimitClientOrderID:   genClientOrderID(),
+		Price:                price,
+		StopClientOrderID:    genClientOrderID(),
+		StopPrice:            stopPrice,
+		StopLimitPrice:       stopLimitPrice,
+		StopLimitTimeInForce: TimeInForceTypeGTC,
+	}
+}
+
+// NewBracketOrderPlan take-profit and stop-loss bracket for an inventory position of quantity
+func NewBracketOrderPlan(symbol Symbol, quantity, takeProfit, stopLoss, stopLimitPrice decimal.Decimal) *OCOOrderPlan {
+	return NewOCOOrderPlan(symbol, SideTypeSell, quantity, takeProfit, stopLoss, stopLimitPrice)
+}
+
+func (p *OCOOrderPlan) Validate() error {
+	if !p.Quantity.IsPositive() {
+		return fmt.Errorf("invalid quantity of OCO: %s", p.Quantity)
+	}
+	switch p.Side {
+	case SideTypeSell:
+		if !p.Price.GreaterThan(p.StopPrice) {
+			return fmt.Errorf("price %s must be above stop price %s for SELL OCO", p.Price, p.StopPrice)
+		}
+	case SideTypeBuy:
+		if !p.Price.LessThan(p.StopPrice) {
+			return fmt.Errorf("price %s must be below stop price %s for BUY OCO", p.Price, p.StopPrice)
+		}
+	default:
+		return fmt.Errorf("invalid side of OCO: %s", p.Side)
+	}
+	return nil
+}
+
+// Legs the two orders of the list, to be tracked by OrderTracker
+func (p *OCOOrderPlan) Legs() []OrderPlan {
+	price, stopLimitPrice, quantity := p.Price, p.StopLimitPrice, p.Quantity
+	return []OrderPlan{
+		{
+			Symbol:        p.Symbol,
+			Side:          p.Side,
+			ClientOrderID: p.LimitClientOrderID,
+			OrderType:     OrderTypeLimitMaker,
+			Price:         &price,
+			Quantity:      &quantity,
+		},
+		{
+			Symbol:        p.Symbol,
+			Side:          p.Side,
+			ClientOrderID: p.StopClientOrderID,
+			OrderType:     OrderTypeStopLossLimit,
+			TimeInForce:   p.StopLimitTimeInForce,
+			Price:         &stopLimitPrice,
+			Quantity:      &quantity,
+		},
+	}
+}
+
+// OrderList order list linked by OrderListId
+type OrderList struct {
+	Symbol            string
+	OrderListId       int64
+	ListClientOrderID string
+	ContingencyType   string
+	ListStatusType    ListStatusType
+	ListOrderStatus   ListOrderStatusType
+	RejectReason      string
+	Orders            []OrderRef
+	TransactionTime   int64
+}
+
+func (l *OrderList) IsDone() bool {
+	return l.ListStatusType == ListStatusTypeAllDone || l.ListOrderStatus == ListOrderStatusTypeReject
+}
+
+// OCOOrderInterface is optional for OrderInterface, only plugins supporting OCO implement it.
+type OCOOrderInterface interface {
+	CreateOCOOrder(plan OCOOrderPlan) (*OrderList, error)
+	CancelOCOOrder(symbol Symbol, orderListId int64, listClientOrderID string) (*OrderList, error)
+	GetOCOOrder(orderListId int64, listClientOrderID string) (*OrderList, error)
+	ListOpenOCOOrders() ([]*OrderList, error)
+}
+
+var ErrOCONotSupported = errors.New("OCO is not supported by the exchange")
+
+// GetOCOOrderInterface returns the OCOOrderInterface of the plugin, or ErrOCONotSupported
+func GetOCOOrderInterface(orderInterface OrderInterface) (OCOOrderInterface, error) {
+	if oco, ok := orderInterface.(OCOOrderInterface); ok {
+		return oco, nil
+	}
+	return nil, ErrOCONotSupported
+}
+
+func NewOrderListFromWs(update WsOCOUpdate) *OrderList {
+	orders := make([]OrderRef, len(update.Orders.WsOCOOrders))
+	for i, order := range update.Orders.WsOCOOrders {
+		orders[i] = OrderRef{
+			OrderID:       strconv.FormatInt(order.OrderId, 10),
+			ClientOrderID: order.ClientOrderId,
+		}
+	}
+	return &OrderList{
+		Symbol:            update.Symbol,
+		OrderListId:       update.OrderListId,
+		ListClientOrderID: update.ClientOrderId,
+		ContingencyType:   update.ContingencyType,
+		ListStatusType:    ListStatusType(update.ListStatusType),
+		ListOrderStatus:   ListOrderStatusType(update.ListOrderStatus),
+		RejectReason:      update.RejectReason,
+		Orders:            orders,
+		TransactionTime:   update.TransactionTime,
+	}
+}
+
+var listStatusRanks = map[ListStatusType]int{
+	ListStatusTypeResponse:    0,
+	ListStatusTypeExecStarted: 1,
+	ListStatusTypeAllDone:     2,
+}
+
+// OCOTracker tracks list status per OrderListId, status never goes backwards
+type OCOTracker struct {
+	rwM     sync.RWMutex
+	lists   map[int64]*OrderList
+	updated map[int64]time.Time // last change of each list, for Prune
+}
+
+func NewOCOTracker() *OCOTracker {
+	return &OCOTracker{
+		lists:   make(map[int64]*OrderList),
+		updated: make(map[int64]time.Time),
+	}
+}
+
+// Update applies the list from REST API or user data stream, returns false if nothing changed
+func (t *OCOTracker) Update(list *OrderList) bool {
+	t.rwM.Lock()
+	defer t.rwM.Unlock()
+	current, ok := t.lists[list.OrderListId]
+	if ok {
+		if current.IsDone() || listStatusRanks[list.ListStatusType] < listStatusRanks[current.ListStatusType] {
+			return false
+		}
+		if current.ListStatusType == list.ListStatusType && current.ListOrderStatus == list.ListOrderStatus {
+			return false
+		}
+	}
+	copied := *list
+	if ok && len(copied.Orders) == 0 {
+		copied.Orders = current.Orders
+	}
+	t.lists[list.OrderListId] = &copied
+	t.updated[list.OrderListId] = time.Now()
+	return true
+}
+
+func (t *OCOTracker) UpdateFromWs(update WsOCOUpdate) bool {
+	return t.Update(NewOrderListFromWs(update))
+}
+
+// Get returns a copy of the list
+func (t *OCOTracker) Get(orderListId int64) (OrderList, bool) {
+	t.rwM.RLock()
+	defer t.rwM.RUnlock()
+	list, ok := t.lists[orderListId]
+	if !ok {
+		return OrderList{}, false
+	}
+	return *list, true
+}
+
+// Open lists not done yet
+func (t *OCOTracker) Open() []OrderList {
+	t.rwM.RLock()
+	defer t.rwM.RUnlock()
+	var res []OrderList
+	for _, list := range t.lists {
+		if !list.IsDone() {
+			res = append(res, *list)
+		}
+	}
+	return res
+}
+
+// Prune removes lists done and not updated since before
+func (t *OCOTracker) Prune(before time.Time) int {
+	t.rwM.Lock()
+	defer t.rwM.Unlock()
+	pruned := 0
+	for id, list := range t.lists {
+		if list.IsDone() && t.updated[id].Before(before) {
+			delete(t.lists, id)
+			delete(t.updated, id)
+			pruned++
+		}
+	}
+	return pruned
+}
@@ -0,0 +1,62 @@
+package general
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOCOOrderPlan(t *testing.T) {
+	symbol := Symbol{BaseAsset: INJ, QuoteAsset: USDT}
+	plan := NewBracketOrderPlan(symbol, decimal.NewFromInt(2), decimal.NewFromInt(12), decimal.NewFromInt(9), decimal.NewFromFloat(8.9))
+	if err := plan.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	legs := plan.Legs()
+	if len(legs) != 2 || legs[0].OrderType != OrderTypeLimitMaker || legs[1].OrderType != OrderTypeStopLossLimit {
+		t.Fatalf("unexpected legs: %v", legs)
+	}
+	if !legs[1].Price.Equal(plan.StopLimitPrice) {
+		t.Fatalf("stop leg should be placed at stop limit price, got %s", legs[1].Price)
+	}
+
+	plan.Side = SideTypeBuy
+	if err := plan.Validate(); err == nil {
+		t.Fatal("price above stop price should be invalid for BUY")
+	}
+}
+
+func TestOCOTracker(t *testing.T) {
+	tracker := NewOCOTracker()
+	update := WsOCOUpdate{
+		OrderListId:     1,
+		ListStatusType:  string(ListStatusTypeExecStarted),
+		ListOrderStatus: string(ListOrderStatusTypeExecuting),
+		Orders:          WsOCOOrderList{WsOCOOrders: []WsOCOOrder{{OrderId: 10, ClientOrderId: "a"}, {OrderId: 11, ClientOrderId: "b"}}},
+	}
+	if !tracker.UpdateFromWs(update) {
+		t.Fatal("new list should be tracked")
+	}
+	// response of create may come after the event
+	if tracker.Update(&OrderList{OrderListId: 1, ListStatusType: ListStatusTypeResponse, ListOrderStatus: ListOrderStatusTypeExecuting}) {
+		t.Fatal("list status should not go backwards")
+	}
+
+	update.ListStatusType = string(ListStatusTypeAllDone)
+	update.ListOrderStatus = string(ListOrderStatusTypeAllDone)
+	update.Orders = WsOCOOrderList{}
+	if !tracker.UpdateFromWs(update) {
+		t.Fatal("list should be done")
+	}
+	list, _ := tracker.Get(1)
+	if !list.IsDone() || len(list.Orders) != 2 || list.Orders[0].OrderID != "10" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+	if len(tracker.Open()) != 0 || tracker.Prune(time.Now().Add(-time.Minute)) != 0 {
+		t.Fatal("done list should be kept for the retention")
+	}
+	if tracker.Prune(time.Now().Add(time.Second)) != 1 {
+		t.Fatal("done list should be pruned")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/setting"
 	"strings"
 )
 
@@ -39,7 +40,12 @@ func NewLimitOrder(symbol Symbol, side SideType, timeInForce TimeInForceType, pr
 }
 
 func genClientOrderID() string {
-	return strings.ReplaceAll(uuid.New().String(), "-", "")
+	return setting.ClientOrderIDPrefix + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// IsOwnClientOrderID tells if the order is placed by us, by the prefix of ClientOrderID
+func IsOwnClientOrderID(clientOrderID string) bool {
+	return setting.ClientOrderIDPrefix != "" && strings.HasPrefix(clientOrderID, setting.ClientOrderIDPrefix)
 }
 
 func NewMarketOrder(symbol Symbol, side SideType, price, quantity decimal.Decimal) *OrderPlan {
@@ -69,7 +75,17 @@ func NewMarketOrderWithQuoteQty(symbol Symbol, side SideType, price, quoteOrderQ
 	return o
 }
 
+// Amount in quote asset, QuoteOrderQty for MARKET orders sized by it
 func (o *OrderPlan) Amount() decimal.Decimal {
+	if o.Quantity == nil {
+		if o.QuoteOrderQty != nil {
+			return *o.QuoteOrderQty
+		}
+		return decimal.Zero
+	}
+	if o.Price == nil {
+		return decimal.Zero
+	}
 	return o.Price.Mul(*o.Quantity)
 }
 
@@ -78,5 +94,23 @@ func (o *OrderPlan) SetCreateOrderResponse(response *CreateOrderResponse) {
 }
 
 func (o *OrderPlan) ToString() string {
-	return fmt.Sprintf("Symbol: %s, ClientOrderID: %s, type: %s, side: %s, price: %s, quantity: %s, amount: %s", o.Symbol.BaseAsset, o.ClientOrderID, o.OrderType, o.Side, o.Price, o.Quantity, o.Amount())
+	return fmt.Sprintf("Symbol: %s, ClientOrderID: %s, type: %s, side: %s, price: %s, quantity: %s, quoteOrderQty: %s, amount: %s", o.Symbol.BaseAsset, o.ClientOrderID, o.OrderType, o.Side, decimalOrNil(o.Price), decimalOrNil(o.Quantity), decimalOrNil(o.QuoteOrderQty), o.Amount())
+}
+
+func decimalOrNil(d *decimal.Decimal) string {
+	if d == nil {
+		return "nil"
+	}
+	return d.String()
+}
+
+// QuoteOrderQtyInterface is optional for OrderInterface, plugins accepting quoteOrderQty of MARKET orders implement it
+type QuoteOrderQtyInterface interface {
+	SupportsQuoteOrderQty() bool
+}
+
+// SupportsQuoteOrderQty tells if MARKET orders of the plugin can be sized by QuoteOrderQty
+func SupportsQuoteOrderQty(orderInterface OrderInterface) bool {
+	q, ok := orderInterface.(QuoteOrderQtyInterface)
+	return ok && q.SupportsQuoteOrderQty()
 }
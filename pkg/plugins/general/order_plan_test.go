@@ -0,0 +1,34 @@
+package general
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestIsOwnClientOrderID(t *testing.T) {
+	plan := NewLimitOrder(Symbol{BaseAsset: INJ, QuoteAsset: USDT}, SideTypeBuy, TimeInForceTypeGTC, decimal.NewFromInt(1), decimal.NewFromInt(10))
+	if len(plan.ClientOrderID) > 36 {
+		t.Fatalf("ClientOrderID is too long: %s", plan.ClientOrderID)
+	}
+	if !IsOwnClientOrderID(plan.ClientOrderID) {
+		t.Fatalf("%s should be own order", plan.ClientOrderID)
+	}
+	if IsOwnClientOrderID("web_ab12cd") || IsOwnClientOrderID("") {
+		t.Fatal("foreign order should not be own order")
+	}
+}
+
+func TestMarketOrderWithQuoteQty(t *testing.T) {
+	plan := NewMarketOrderWithQuoteQty(Symbol{BaseAsset: INJ, QuoteAsset: USDT}, SideTypeBuy, decimal.NewFromInt(10), decimal.NewFromInt(50))
+	if plan.Quantity != nil || !plan.Amount().Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("amount should be quoteOrderQty: %s", plan.Amount())
+	}
+	if s := plan.ToString(); !strings.Contains(s, "quantity: nil") || !strings.Contains(s, "quoteOrderQty: 50") {
+		t.Fatalf("unexpected plan: %s", s)
+	}
+	if SupportsQuoteOrderQty(&fakeOrderInterface{}) {
+		t.Fatal("quoteOrderQty should not be supported by default")
+	}
+}
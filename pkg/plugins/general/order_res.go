@@ -3,6 +3,7 @@ package general
 import (
 	"github.com/shopspring/decimal"
 	"strconv"
+	"time"
 )
 
 // Order define order info
@@ -39,3 +40,11 @@ func NewCreateOrderResponse(orderID int64, ClientOrderID string) *CreateOrderRes
 		ClientOrderID: ClientOrderID,
 	}
 }
+
+// OpenOrderAdopted is published on bus when an open order left by the last run is taken over.
+type OpenOrderAdopted struct {
+	Exchange Exchange
+	Symbol   Symbol
+	Order    *Order
+	Time     time.Time
+}
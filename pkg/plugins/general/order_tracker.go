@@ -0,0 +1,339 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOrderNotTracked   = errors.New("order is not tracked")
+	ErrWaitOrderTimeout  = errors.New("timeout waiting for order status")
+	ErrOrderStatusPassed = errors.New("order reached a final status other than the expected one")
+
+	// ErrSendStatusUnknown plugins wrap errors after which the order may or may not be accepted, e.g. timeouts and 5xx
+	ErrSendStatusUnknown = errors.New("send status unknown")
+	// ErrOrderNotFound plugins wrap errors of GetOrder when the exchange does not know the order
+	ErrOrderNotFound = errors.New("order not found")
+)
+
+// IsSendStatusUnknown tells if the order may exist although CreateOrder failed, transport errors are always unknown
+func IsSendStatusUnknown(err error) bool {
+	if errors.Is(err, ErrSendStatusUnknown) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// orderStatusRanks the state machine of order: PRE_NEW → NEW → PARTIALLY_FILLED → FILLED/CANCELED/REJECTED/EXPIRED,
+// status never goes backwards, PENDING_CANCEL may come from NEW or PARTIALLY_FILLED.
+var orderStatusRanks = map[OrderStatusType]int{
+	OrderStatusTypePreNew:          0,
+	OrderStatusTypeNew:             1,
+	OrderStatusTypePartiallyFilled: 2,
+	OrderStatusTypePendingCancel:   3,
+	OrderStatusTypeFilled:          4,
+	OrderStatusTypeCanceled:        4,
+	OrderStatusTypeRejected:        4,
+	OrderStatusTypeExpired:         4,
+}
+
+// IsFinalStatus tells if the order will never change again
+func IsFinalStatus(status OrderStatusType) bool {
+	return orderStatusRanks[status] == orderStatusRanks[OrderStatusTypeFilled]
+}
+
+// isFailedStatus the order ended without being filled
+func isFailedStatus(status OrderStatusType) bool {
+	return status == OrderStatusTypeCanceled || status == OrderStatusTypeRejected || status == OrderStatusTypeExpired
+}
+
+// OrderState order state correlated from CreateOrderResponse, REST GetOrder and WsOrderUpdate
+type OrderState struct {
+	Exchange          Exchange
+	Plan              OrderPlan
+	OrderID           string
+	Status            OrderStatusType
+	FilledQuantity    decimal.Decimal
+	FilledQuoteVolume decimal.Decimal
+	RejectReason      string
+	UpdateTime        time.Time
+}
+
+// TrackedOrder an order in OrderTracker, safe for concurrent use
+type TrackedOrder struct {
+	rwM     sync.RWMutex
+	changed chan struct{} // closed and replaced on every change
+	state   OrderState
+
+	trackTime time.Time // before the order is sent, any update of the order is after it
+	checkTime time.Time // last time polled by REST API
+	sendErr   error     // CreateOrder failed with unknown send status, the order is resolved by polling
+}
+
+func newTrackedOrder(exchange Exchange, plan OrderPlan) *TrackedOrder {
+	now := time.Now()
+	return &TrackedOrder{
+		changed: make(chan struct{}),
+		state: OrderState{
+			Exchange:   exchange,
+			Plan:       plan,
+			Status:     OrderStatusTypePreNew,
+			UpdateTime: now,
+		},
+		trackTime: now,
+	}
+}
+
+// TrackTime when the order started to be tracked
+func (o *TrackedOrder) TrackTime() time.Time {
+	return o.trackTime
+}
+
+// State returns a copy of current state
+func (o *TrackedOrder) State() OrderState {
+	o.rwM.RLock()
+	defer o.rwM.RUnlock()
+	return o.state
+}
+
+// MarkChecked the order is polled by REST API just now
+func (o *TrackedOrder) MarkChecked() {
+	o.rwM.Lock()
+	defer o.rwM.Unlock()
+	o.checkTime = time.Now()
+}
+
+func (o *TrackedOrder) markSendUnknown(err error) {
+	o.rwM.Lock()
+	defer o.rwM.Unlock()
+	o.sendErr = err
+}
+
+func (o *TrackedOrder) sendUnknownErr() error {
+	o.rwM.RLock()
+	defer o.rwM.RUnlock()
+	return o.sendErr
+}
+
+func (o *TrackedOrder) lastSeen() time.Time {
+	o.rwM.RLock()
+	defer o.rwM.RUnlock()
+	if o.checkTime.After(o.state.UpdateTime) {
+		return o.checkTime
+	}
+	return o.state.UpdateTime
+}
+
+// WaitFor blocks until the order reaches status or a later one, e.g. a fast fill satisfies NEW.
+// It fails if the order is canceled, rejected or expired, ends in another final status or timeout.
+func (o *TrackedOrder) WaitFor(status OrderStatusType, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		o.rwM.RLock()
+		current, changed := o.state.Status, o.changed
+		o.rwM.RUnlock()
+
+		if current == status {
+			return nil
+		}
+		if isFailedStatus(current) || (IsFinalStatus(current) && IsFinalStatus(status)) {
+			return fmt.Errorf("%w: %s, expected: %s", ErrOrderStatusPassed, current, status)
+		}
+		if orderStatusRanks[current] > orderStatusRanks[status] {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return fmt.Errorf("%w: %s, current: %s", ErrWaitOrderTimeout, status, current)
+		}
+	}
+}
+
+// advance moves the state machine forward, stale or backward updates are dropped
+func (o *TrackedOrder) advance(orderID string, status OrderStatusType, filled, filledQuote decimal.Decimal, reason string) bool {
+	o.rwM.Lock()
+	defer o.rwM.Unlock()
+	state := &o.state
+	if orderID != "" && state.OrderID == "" {
+		state.OrderID = orderID
+	}
+	if IsFinalStatus(state.Status) {
+		return false
+	}
+	if _, ok := orderStatusRanks[status]; !ok || orderStatusRanks[status] < orderStatusRanks[state.Status] {
+		return false
+	}
+	if status == state.Status && !filled.GreaterThan(state.FilledQuantity) {
+		return false
+	}
+	state.Status = status
+	if filled.GreaterThan(state.FilledQuantity) {
+		state.FilledQuantity = filled
+		state.FilledQuoteVolume = filledQuote
+	}
+	if reason != "" {
+		state.RejectReason = reason
+	}
+	state.UpdateTime = time.Now()
+	close(o.changed)
+	o.changed = make(chan struct{})
+	return true
+}
+
+// OrderTracker records each OrderPlan when it is submitted, keyed by ClientOrderID
+type OrderTracker struct {
+	rwM    sync.RWMutex
+	orders map[string]*TrackedOrder
+}
+
+func NewOrderTracker() *OrderTracker {
+	return &OrderTracker{
+		orders: make(map[string]*TrackedOrder),
+	}
+}
+
+// Track records the plan before it is sent, in PRE_NEW status
+func (t *OrderTracker) Track(exchange Exchange, plan OrderPlan) *TrackedOrder {
+	t.rwM.Lock()
+	defer t.rwM.Unlock()
+	if order, ok := t.orders[plan.ClientOrderID]; ok {
+		return order
+	}
+	order := newTrackedOrder(exchange, plan)
+	t.orders[plan.ClientOrderID] = order
+	return order
+}
+
+// Adopt tracks an open order placed before, e.g. by the last run
+func (t *OrderTracker) Adopt(exchange Exchange, symbol Symbol, order *Order) *TrackedOrder {
+	price, quantity := order.Price, order.OrigQuantity
+	tracked := t.Track(exchange, OrderPlan{
+		Symbol:        symbol,
+		Side:          order.Side,
+		ClientOrderID: order.ClientOrderID,
+		OrderType:     order.Type,
+		TimeInForce:   order.TimeInForce,
+		Price:         &price,
+		Quantity:      &quantity,
+	})
+	t.UpdateFromOrder(order)
+	return tracked
+}
+
+func (t *OrderTracker) Get(clientOrderID string) (*TrackedOrder, bool) {
+	t.rwM.RLock()
+	defer t.rwM.RUnlock()
+	order, ok := t.orders[clientOrderID]
+	return order, ok
+}
+
+// OnCreated applies the response of CreateOrder, the order is NEW unless events came first.
+func (t *OrderTracker) OnCreated(clientOrderID string, res *CreateOrderResponse) {
+	if order, ok := t.Get(clientOrderID); ok {
+		order.advance(res.OrderID, OrderStatusTypeNew, decimal.Zero, decimal.Zero, "")
+	}
+}
+
+// OnCreateFailed the order is REJECTED if CreateOrder is refused. If the send status is unknown, e.g. timeout or 5xx,
+// it stays PRE_NEW until polling GetOrder by ClientOrderID finds it, or OnNotFound rejects it.
+func (t *OrderTracker) OnCreateFailed(clientOrderID string, err error) {
+	order, ok := t.Get(clientOrderID)
+	if !ok {
+		return
+	}
+	if IsSendStatusUnknown(err) {
+		order.markSendUnknown(err)
+		return
+	}
+	order.advance("", OrderStatusTypeRejected, decimal.Zero, decimal.Zero, err.Error())
+}
+
+// OnNotFound rejects the order if GetOrder does not find it after CreateOrder failed with unknown send status,
+// orders still being created are kept.
+func (t *OrderTracker) OnNotFound(clientOrderID string) bool {
+	order, ok := t.Get(clientOrderID)
+	if !ok {
+		return false
+	}
+	sendErr := order.sendUnknownErr()
+	if sendErr == nil {
+		return false
+	}
+	return order.advance("", OrderStatusTypeRejected, decimal.Zero, decimal.Zero, "not found after: "+sendErr.Error())
+}
+
+// UpdateFromWs applies executionReport of user data stream, returns false if not tracked or nothing changed
+func (t *OrderTracker) UpdateFromWs(update WsOrderUpdate) bool {
+	order, ok := t.Get(update.ClientOrderId)
+	if !ok {
+		return false
+	}
+	return order.advance(strconv.FormatInt(update.Id, 10), update.Status, update.FilledVolume, update.FilledQuoteVolume, update.RejectReason)
+}
+
+// UpdateFromOrder applies the order from REST API
+func (t *OrderTracker) UpdateFromOrder(o *Order) bool {
+	order, ok := t.Get(o.ClientOrderID)
+	if !ok {
+		return false
+	}
+	return order.advance(o.OrderID, o.Status, o.ExecutedQuantity, o.CummulativeQuoteQuantity, "")
+}
+
+// Stale returns orders not final and neither updated nor checked since before, they should be polled by REST API
+func (t *OrderTracker) Stale(before time.Time) []*TrackedOrder {
+	t.rwM.RLock()
+	defer t.rwM.RUnlock()
+	var res []*TrackedOrder
+	for _, order := range t.orders {
+		if !IsFinalStatus(order.State().Status) && order.lastSeen().Before(before) {
+			res = append(res, order)
+		}
+	}
+	return res
+}
+
+// Open returns orders not final yet
+func (t *OrderTracker) Open() []*TrackedOrder {
+	t.rwM.RLock()
+	defer t.rwM.RUnlock()
+	var res []*TrackedOrder
+	for _, order := range t.orders {
+		if !IsFinalStatus(order.State().Status) {
+			res = append(res, order)
+		}
+	}
+	return res
+}
+
+// Prune removes final orders not updated since before
+func (t *OrderTracker) Prune(before time.Time) int {
+	t.rwM.Lock()
+	defer t.rwM.Unlock()
+	pruned := 0
+	for clientOrderID, order := range t.orders {
+		state := order.State()
+		if IsFinalStatus(state.Status) && state.UpdateTime.Before(before) {
+			delete(t.orders, clientOrderID)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Len number of orders tracked
+func (t *OrderTracker) Len() int {
+	t.rwM.RLock()
+	defer t.rwM.RUnlock()
+	return len(t.orders)
+}
@@ -0,0 +1,101 @@
+package general
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderTracker(t *testing.T) {
+	tracker := NewOrderTracker()
+	plan := NewLimitOrder(Symbol{BaseAsset: INJ, QuoteAsset: USDT}, SideTypeBuy, TimeInForceTypeGTC, decimal.NewFromInt(10), decimal.NewFromInt(2))
+	order := tracker.Track(Binance, *plan)
+	if order.State().Status != OrderStatusTypePreNew {
+		t.Fatalf("unexpected status: %s", order.State().Status)
+	}
+
+	done := make(chan error)
+	go func() { done <- order.WaitFor(OrderStatusTypeFilled, time.Second) }()
+
+	// event comes before the response of CreateOrder
+	tracker.UpdateFromWs(WsOrderUpdate{ClientOrderId: plan.ClientOrderID, Id: 1, Status: OrderStatusTypePartiallyFilled, FilledVolume: decimal.NewFromInt(1)})
+	tracker.OnCreated(plan.ClientOrderID, &CreateOrderResponse{OrderID: "1", ClientOrderID: plan.ClientOrderID})
+	if state := order.State(); state.Status != OrderStatusTypePartiallyFilled || state.OrderID != "1" {
+		t.Fatalf("status should not go backwards: %s", state.Status)
+	}
+	tracker.UpdateFromOrder(&Order{ClientOrderID: plan.ClientOrderID, Status: OrderStatusTypeFilled, ExecutedQuantity: decimal.NewFromInt(2)})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	tracker.UpdateFromWs(WsOrderUpdate{ClientOrderId: plan.ClientOrderID, Status: OrderStatusTypeCanceled})
+	if order.State().Status != OrderStatusTypeFilled {
+		t.Fatal("final status should never change")
+	}
+	if err := order.WaitFor(OrderStatusTypeCanceled, time.Second); !errors.Is(err, ErrOrderStatusPassed) {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+// an order filled right away has passed NEW, which is satisfied, a canceled one fails
+func TestOrderTrackerWaitForFastFill(t *testing.T) {
+	tracker := NewOrderTracker()
+	filled := tracker.Track(Binance, OrderPlan{ClientOrderID: "a"})
+	tracker.UpdateFromWs(WsOrderUpdate{ClientOrderId: "a", Id: 1, Status: OrderStatusTypeFilled, FilledVolume: decimal.NewFromInt(1)})
+	for _, status := range []OrderStatusType{OrderStatusTypeNew, OrderStatusTypePartiallyFilled} {
+		if err := filled.WaitFor(status, time.Second); err != nil {
+			t.Fatalf("%s: unexpected err: %v", status, err)
+		}
+	}
+
+	canceled := tracker.Track(Binance, OrderPlan{ClientOrderID: "b"})
+	tracker.UpdateFromWs(WsOrderUpdate{ClientOrderId: "b", Id: 2, Status: OrderStatusTypeCanceled})
+	if err := canceled.WaitFor(OrderStatusTypeNew, time.Second); !errors.Is(err, ErrOrderStatusPassed) {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+func TestOrderTrackerWaitForTimeout(t *testing.T) {
+	tracker := NewOrderTracker()
+	order := tracker.Track(Binance, OrderPlan{ClientOrderID: "a"})
+	if err := order.WaitFor(OrderStatusTypeNew, 10*time.Millisecond); !errors.Is(err, ErrWaitOrderTimeout) {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if stale := tracker.Stale(time.Now()); len(stale) != 1 {
+		t.Fatalf("order should be stale, got %d", len(stale))
+	}
+	tracker.OnCreateFailed("a", errors.New("rejected"))
+	if order.State().Status != OrderStatusTypeRejected {
+		t.Fatalf("unexpected status: %s", order.State().Status)
+	}
+	if pruned := tracker.Prune(time.Now().Add(time.Second)); pruned != 1 || tracker.Len() != 0 {
+		t.Fatalf("finished order should be pruned")
+	}
+}
+
+func TestOrderTrackerSendStatusUnknown(t *testing.T) {
+	tracker := NewOrderTracker()
+	order := tracker.Track(Binance, OrderPlan{ClientOrderID: "a"})
+	tracker.OnCreateFailed("a", fmt.Errorf("%w: code=-1007", ErrSendStatusUnknown))
+	if order.State().Status != OrderStatusTypePreNew {
+		t.Fatalf("order of unknown send status should stay PRE_NEW, got %s", order.State().Status)
+	}
+	tracker.UpdateFromOrder(&Order{ClientOrderID: "a", OrderID: "1", Status: OrderStatusTypeNew})
+	if state := order.State(); state.Status != OrderStatusTypeNew || state.OrderID != "1" {
+		t.Fatalf("order should be found by polling, got %s", state.Status)
+	}
+
+	order = tracker.Track(Binance, OrderPlan{ClientOrderID: "b"})
+	if tracker.OnNotFound("b") {
+		t.Fatal("order still being created should not be rejected")
+	}
+	tracker.OnCreateFailed("b", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded})
+	if !tracker.OnNotFound("b") || order.State().Status != OrderStatusTypeRejected {
+		t.Fatalf("order not found should be rejected, got %s", order.State().Status)
+	}
+}
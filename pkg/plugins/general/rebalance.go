@@ -0,0 +1,221 @@
+package general
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"sort"
+	"strings"
+)
+
+// RebalanceActionType how an imbalance is fixed
+type RebalanceActionType string
+
+const (
+	// RebalanceActionTransfer withdraws the asset from the exchange with surplus to the one with deficit
+	RebalanceActionTransfer RebalanceActionType = "TRANSFER"
+	// RebalanceActionTrade sells the asset on the exchange with surplus, and buys it back on the one with deficit
+	RebalanceActionTrade RebalanceActionType = "TRADE"
+	// RebalanceActionConvert like TRADE, but the asset is bought back by convert instead of order book
+	RebalanceActionConvert RebalanceActionType = "CONVERT"
+)
+
+// RebalanceTarget allocation of the asset across exchanges, weights are normalized by their sum
+type RebalanceTarget struct {
+	Asset   Asset
+	Weights map[Exchange]decimal.Decimal
+}
+
+// ParseRebalanceTarget parses spec like "binance:0.5,MEXC:0.5"
+func ParseRebalanceTarget(asset Asset, spec string) (*RebalanceTarget, error) {
+	target := &RebalanceTarget{Asset: asset, Weights: make(map[Exchange]decimal.Decimal)}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid target of %s: %s", asset, item)
+		}
+		weight, err := decimal.NewFromString(strings.TrimSpace(parts[1]))
+		if err != nil || weight.IsNegative() {
+			return nil, fmt.Errorf("invalid weight of %s: %s", asset, item)
+		}
+		target.Weights[Exchange(strings.TrimSpace(parts[0]))] = weight
+	}
+	if len(target.Weights) < 2 {
+		return nil, fmt.Errorf("target of %s needs 2 exchanges at least: %s", asset, spec)
+	}
+	return target, nil
+}
+
+// Imbalance Amount of Asset should be moved from exchange From to exchange To
+type Imbalance struct {
+	Asset  Asset
+	From   Exchange
+	To     Exchange
+	Amount decimal.Decimal
+}
+
+// FindImbalances compares balances with the target, nothing is returned if every deviation is within
+// tolerance of the total. Surpluses are matched with deficits greedily, the largest ones first.
+func FindImbalances(target *RebalanceTarget, balances map[Exchange]decimal.Decimal, tolerance decimal.Decimal) []Imbalance {
+	total, sumWeights := decimal.Zero, decimal.Zero
+	for exchange, weight := range target.Weights {
+		total = total.Add(balances[exchange])
+		sumWeights = sumWeights.Add(weight)
+	}
+	if !total.IsPositive() || !sumWeights.IsPositive() {
+		return nil
+	}
+
+	type deviation struct {
+		exchange Exchange
+		amount   decimal.Decimal
+	}
+	var surpluses, deficits []*deviation
+	exceeded := false
+	for exchange, weight := range target.Weights {
+		amount := balances[exchange].Sub(total.Mul(weight).Div(sumWeights))
+		if amount.Abs().Div(total).GreaterThan(tolerance) {
+			exceeded = true
+		}
+		if amount.IsPositive() {
+			surpluses = append(surpluses, &deviation{exchange, amount})
+		} else if amount.IsNegative() {
+			deficits = append(deficits, &deviation{exchange, amount.Neg()})
+		}
+	}
+	if !exceeded {
+		return nil
+	}
+	byAmount := func(s []*deviation) {
+		sort.Slice(s, func(i, j int) bool {
+			if s[i].amount.Equal(s[j].amount) {
+				return s[i].exchange < s[j].exchange
+			}
+			return s[i].amount.GreaterThan(s[j].amount)
+		})
+	}
+	byAmount(surpluses)
+	byAmount(deficits)
+
+	var res []Imbalance
+	for i, j := 0, 0; i < len(surpluses) && j < len(deficits); {
+		amount := decimal.Min(surpluses[i].amount, deficits[j].amount)
+		res = append(res, Imbalance{Asset: target.Asset, From: surpluses[i].exchange, To: deficits[j].exchange, Amount: amount})
+		surpluses[i].amount = surpluses[i].amount.Sub(amount)
+		deficits[j].amount = deficits[j].amount.Sub(amount)
+		if surpluses[i].amount.IsZero() {
+			i++
+		}
+		if deficits[j].amount.IsZero() {
+			j++
+		}
+	}
+	return res
+}
+
+// RebalanceAction a way to fix the imbalance, Cost is estimated in units of the asset
+type RebalanceAction struct {
+	Type      RebalanceActionType
+	Imbalance Imbalance
+	Symbol    Symbol // traded or converted symbol, not for TRANSFER
+	Cost      decimal.Decimal
+}
+
+func (a *RebalanceAction) CostRatio() decimal.Decimal {
+	if !a.Imbalance.Amount.IsPositive() {
+		return decimal.Zero
+	}
+	return a.Cost.Div(a.Imbalance.Amount)
+}
+
+var ErrNoRebalanceAction = errors.New("no rebalance action within cost limit")
+
+// CheapestRebalanceAction returns the action of the lowest cost ratio not above maxCostRatio
+func CheapestRebalanceAction(actions []*RebalanceAction, maxCostRatio decimal.Decimal) (*RebalanceAction, error) {
+	var cheapest *RebalanceAction
+	for _, action := range actions {
+		if action.CostRatio().GreaterThan(maxCostRatio) {
+			continue
+		}
+		if cheapest == nil || action.CostRatio().LessThan(cheapest.CostRatio()) {
+			cheapest = action
+		}
+	}
+	if cheapest == nil {
+		return nil, ErrNoRebalanceAction
+	}
+	return cheapest, nil
+}
+
+// MidPrice and half of the relative spread of the best levels
+func MidPrice(depth *DepthInfo) (mid, halfSpread decimal.Decimal, err error) {
+	if len(depth.Bids) == 0 || len(depth.Asks) == 0 {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("empty order book of %s", depth.Symbol)
+	}
+	bid, ask := depth.Bids[0].Price, depth.Asks[0].Price
+	mid = bid.Add(ask).Div(decimal.NewFromInt(2))
+	if !mid.IsPositive() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("invalid order book of %s", depth.Symbol)
+	}
+	return mid, ask.Sub(bid).Div(mid).Div(decimal.NewFromInt(2)), nil
+}
+
+// TransferAction costs the withdrawal fee of the network
+func TransferAction(imbalance Imbalance, network *NetworkInfo) (*RebalanceAction, error) {
+	if err := ValidateWithdraw(network, imbalance.Amount); err != nil {
+		return nil, err
+	}
+	return &RebalanceAction{Type: RebalanceActionTransfer, Imbalance: imbalance, Cost: network.WithdrawFee}, nil
+}
+
+// TradeAction costs half spread and taker fee on both exchanges
+func TradeAction(imbalance Imbalance, fromDepth, toDepth *DepthInfo, takerFee decimal.Decimal) (*RebalanceAction, error) {
+	_, fromSpread, err := MidPrice(fromDepth)
+	if err != nil {
+		return nil, err
+	}
+	_, toSpread, err := MidPrice(toDepth)
+	if err != nil {
+		return nil, err
+	}
+	ratio := fromSpread.Add(toSpread).Add(takerFee.Mul(decimal.NewFromInt(2)))
+	return &RebalanceAction{
+		Type:      RebalanceActionTrade,
+		Imbalance: imbalance,
+		Symbol:    fromDepth.Symbol,
+		Cost:      imbalance.Amount.Mul(ratio),
+	}, nil
+}
+
+// ConvertAction costs half spread and taker fee on the exchange with surplus, and the premium of the
+// convert quote over the mid price on the exchange with deficit. The quote must convert to the asset.
+func ConvertAction(imbalance Imbalance, fromDepth, toDepth *DepthInfo, quote *ConvertQuote, takerFee decimal.Decimal) (*RebalanceAction, error) {
+	_, fromSpread, err := MidPrice(fromDepth)
+	if err != nil {
+		return nil, err
+	}
+	mid, _, err := MidPrice(toDepth)
+	if err != nil {
+		return nil, err
+	}
+	if quote.ToAsset != imbalance.Asset || !quote.ToAmount.IsPositive() {
+		return nil, fmt.Errorf("quote of %s->%s does not fix %s", quote.FromAsset, quote.ToAsset, imbalance.Asset)
+	}
+	// the amount of asset the quote would get at mid price
+	fair := quote.FromAmount.Div(mid)
+	if imbalance.Asset == toDepth.Symbol.QuoteAsset {
+		fair = quote.FromAmount.Mul(mid)
+	}
+	premium := decimal.Max(decimal.Zero, decimal.NewFromInt(1).Sub(quote.ToAmount.Div(fair)))
+	ratio := fromSpread.Add(takerFee).Add(premium)
+	return &RebalanceAction{
+		Type:      RebalanceActionConvert,
+		Imbalance: imbalance,
+		Symbol:    toDepth.Symbol,
+		Cost:      imbalance.Amount.Mul(ratio),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package general
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFindImbalances(t *testing.T) {
+	target, err := ParseRebalanceTarget(USDT, "binance:0.5, MEXC:0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseRebalanceTarget(USDT, "binance:0.5"); err == nil {
+		t.Fatal("target of a single exchange should be invalid")
+	}
+
+	balances := map[Exchange]decimal.Decimal{Binance: decimal.NewFromInt(55), MEXC: decimal.NewFromInt(45)}
+	if res := FindImbalances(target, balances, decimal.NewFromFloat(0.1)); len(res) != 0 {
+		t.Fatalf("deviation within tolerance should be ignored: %v", res)
+	}
+
+	balances[Binance] = decimal.NewFromInt(90)
+	balances[MEXC] = decimal.NewFromInt(10)
+	res := FindImbalances(target, balances, decimal.NewFromFloat(0.1))
+	if len(res) != 1 || res[0].From != Binance || res[0].To != MEXC || !res[0].Amount.Equal(decimal.NewFromInt(40)) {
+		t.Fatalf("unexpected imbalances: %+v", res)
+	}
+}
+
+func TestCheapestRebalanceAction(t *testing.T) {
+	symbol := NewSymbol(INJ)
+	imbalance := Imbalance{Asset: INJ, From: Binance, To: MEXC, Amount: decimal.NewFromInt(100)}
+	depth := func(bid, ask float64) *DepthInfo {
+		return &DepthInfo{
+			Symbol: symbol,
+			Bids:   []*Bid{{Price: decimal.NewFromFloat(bid), Quantity: decimal.NewFromInt(1)}},
+			Asks:   []*Ask{{Price: decimal.NewFromFloat(ask), Quantity: decimal.NewFromInt(1)}},
+		}
+	}
+
+	transfer, err := TransferAction(imbalance, &NetworkInfo{WithdrawEnable: true, WithdrawFee: decimal.NewFromFloat(0.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// half spread 0.05% on both sides and 0.1% taker fee twice
+	trade, err := TradeAction(imbalance, depth(9.995, 10.005), depth(9.995, 10.005), decimal.NewFromFloat(0.001))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !trade.Cost.Equal(decimal.NewFromFloat(0.3)) {
+		t.Fatalf("unexpected cost of trade: %s", trade.Cost)
+	}
+	// 1000 USDT gets 99.5 INJ at mid price 10, premium 0.5%
+	quote := &ConvertQuote{FromAsset: USDT, ToAsset: INJ, FromAmount: decimal.NewFromInt(1000), ToAmount: decimal.NewFromFloat(99.5)}
+	convert, err := ConvertAction(imbalance, depth(9.995, 10.005), depth(9.995, 10.005), quote, decimal.NewFromFloat(0.001))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !convert.Cost.Equal(decimal.NewFromFloat(0.65)) {
+		t.Fatalf("unexpected cost of convert: %s", convert.Cost)
+	}
+
+	actions := []*RebalanceAction{transfer, trade, convert}
+	if action, err := CheapestRebalanceAction(actions, decimal.NewFromFloat(0.01)); err != nil || action != trade {
+		t.Fatalf("trade should be the cheapest: %v, %v", action, err)
+	}
+	if _, err := CheapestRebalanceAction(actions, decimal.NewFromFloat(0.001)); err != ErrNoRebalanceAction {
+		t.Fatalf("every action should exceed the limit: %v", err)
+	}
+}
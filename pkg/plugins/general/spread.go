@@ -0,0 +1,124 @@
+package general
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+var bpsMultiplier = decimal.NewFromInt(10000)
+
+// Spread buying at the top ask of BuyExchange and selling at the top bid of SellExchange.
+// ExecutableSize is the base quantity crossing with net profit within the top levels of both books.
+type Spread struct {
+	Symbol         Symbol
+	BuyExchange    Exchange
+	SellExchange   Exchange
+	BuyPrice       decimal.Decimal
+	SellPrice      decimal.Decimal
+	GrossBps       decimal.Decimal
+	NetBps         decimal.Decimal
+	ExecutableSize decimal.Decimal
+}
+
+// IsOpen tells if the spread is profitable after fees of both legs
+func (s *Spread) IsOpen() bool {
+	return s.NetBps.IsPositive()
+}
+
+// ComputeSpread feeBps is the taker fee of each leg, levels limits the levels walked on both books
+func ComputeSpread(buyExchange Exchange, buyDepth *DepthInfo, sellExchange Exchange, sellDepth *DepthInfo,
+	feeBps decimal.Decimal, levels int) (*Spread, error) {
+	ask, err := buyDepth.TopAsk()
+	if err != nil {
+		return nil, err
+	}
+	bid, err := sellDepth.TopBid()
+	if err != nil {
+		return nil, err
+	}
+	if !ask.Price.IsPositive() {
+		return nil, errors.New("invalid ask price")
+	}
+	fee := feeBps.Div(bpsMultiplier)
+	spread := &Spread{
+		Symbol:       buyDepth.Symbol,
+		BuyExchange:  buyExchange,
+		SellExchange: sellExchange,
+		BuyPrice:     ask.Price,
+		SellPrice:    bid.Price,
+		GrossBps:     bid.Price.Sub(ask.Price).Div(ask.Price).Mul(bpsMultiplier),
+	}
+	spread.NetBps = spread.GrossBps.Sub(feeBps.Mul(decimal.NewFromInt(2)))
+	spread.ExecutableSize = executableSize(buyDepth.Asks, sellDepth.Bids, fee, levels)
+	return spread, nil
+}
+
+// executableSize matches asks and bids from the top while the bid pays the ask and fees of both legs
+func executableSize(asks []*Ask, bids []*Bid, fee decimal.Decimal, levels int) decimal.Decimal {
+	size := decimal.Zero
+	if len(asks) > levels {
+		asks = asks[:levels]
+	}
+	if len(bids) > levels {
+		bids = bids[:levels]
+	}
+	var askLeft, bidLeft decimal.Decimal
+	i, j := 0, 0
+	for i < len(asks) && j < len(bids) {
+		ask, bid := asks[i], bids[j]
+		if bid.Price.Mul(decimal.NewFromInt(1).Sub(fee)).LessThanOrEqual(ask.Price.Mul(decimal.NewFromInt(1).Add(fee))) {
+			break
+		}
+		if askLeft.IsZero() {
+			askLeft = ask.Quantity
+		}
+		if bidLeft.IsZero() {
+			bidLeft = bid.Quantity
+		}
+		matched := decimal.Min(askLeft, bidLeft)
+		size = size.Add(matched)
+		askLeft, bidLeft = askLeft.Sub(matched), bidLeft.Sub(matched)
+		if askLeft.IsZero() {
+			i++
+		}
+		if bidLeft.IsZero() {
+			j++
+		}
+	}
+	return size
+}
+
+// SpreadKey one direction of an exchange pair of a symbol
+type SpreadKey struct {
+	Symbol       Symbol
+	BuyExchange  Exchange
+	SellExchange Exchange
+}
+
+// OpportunityTimer measures how long spreads stay open, it is not safe for concurrent use
+type OpportunityTimer struct {
+	openedAt map[SpreadKey]time.Time
+}
+
+func NewOpportunityTimer() *OpportunityTimer {
+	return &OpportunityTimer{openedAt: make(map[SpreadKey]time.Time)}
+}
+
+func (t *OpportunityTimer) IsOpen(key SpreadKey) bool {
+	_, ok := t.openedAt[key]
+	return ok
+}
+
+// Observe returns the lifetime of the opportunity when it closes
+func (t *OpportunityTimer) Observe(key SpreadKey, open bool, now time.Time) (time.Duration, bool) {
+	openedAt, wasOpen := t.openedAt[key]
+	switch {
+	case open && !wasOpen:
+		t.openedAt[key] = now
+	case !open && wasOpen:
+		delete(t.openedAt, key)
+		return now.Sub(openedAt), true
+	}
+	return 0, false
+}
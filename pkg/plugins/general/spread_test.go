@@ -0,0 +1,58 @@
+package general
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestComputeSpread(t *testing.T) {
+	level := func(price, quantity float64) PriceLevel {
+		return PriceLevel{Price: decimal.NewFromFloat(price), Quantity: decimal.NewFromFloat(quantity)}
+	}
+	ask1, ask2 := level(100, 1), level(100.5, 2)
+	bid1, bid2 := level(101, 1.5), level(100.1, 5)
+	buyDepth := &DepthInfo{Symbol: NewSymbol(INJ), Asks: []*Ask{&ask1, &ask2}}
+	sellDepth := &DepthInfo{Symbol: NewSymbol(INJ), Bids: []*Bid{&bid1, &bid2}}
+
+	spread, err := ComputeSpread(Binance, buyDepth, MEXC, sellDepth, decimal.NewFromInt(10), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !spread.GrossBps.Equal(decimal.NewFromInt(100)) || !spread.NetBps.Equal(decimal.NewFromInt(80)) || !spread.IsOpen() {
+		t.Fatalf("unexpected spread %+v", spread)
+	}
+	// 1 of ask 100 against bid 101, then 0.5 of ask 100.5 against bid 101, bid 100.1 pays no ask
+	if !spread.ExecutableSize.Equal(decimal.NewFromFloat(1.5)) {
+		t.Fatalf("unexpected executable size %s", spread.ExecutableSize)
+	}
+
+	reverse, err := ComputeSpread(MEXC, &DepthInfo{Asks: []*Ask{&bid1}}, Binance, &DepthInfo{Bids: []*Bid{&ask1}},
+		decimal.NewFromInt(10), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverse.IsOpen() || !reverse.ExecutableSize.IsZero() {
+		t.Fatalf("unexpected spread %+v", reverse)
+	}
+}
+
+func TestOpportunityTimer(t *testing.T) {
+	timer := NewOpportunityTimer()
+	key := SpreadKey{Symbol: NewSymbol(INJ), BuyExchange: Binance, SellExchange: MEXC}
+	start := time.Now()
+	if _, closed := timer.Observe(key, true, start); closed {
+		t.Fatal("opportunity should be open")
+	}
+	if _, closed := timer.Observe(key, true, start.Add(time.Second)); closed {
+		t.Fatal("opportunity should be still open")
+	}
+	lifetime, closed := timer.Observe(key, false, start.Add(3*time.Second))
+	if !closed || lifetime != 3*time.Second {
+		t.Fatalf("unexpected lifetime %s", lifetime)
+	}
+	if _, closed := timer.Observe(key, false, start.Add(4*time.Second)); closed {
+		t.Fatal("closed opportunity should be observed once")
+	}
+}
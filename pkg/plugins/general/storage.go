@@ -0,0 +1,43 @@
+package general
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Commands handled by StorageService with DispatchCtx, they join the transaction of bus.InTransaction in ctx
+
+// SaveOrderSubmittedCommand inserts the order as of its track time, the status of an order updated before is kept
+type SaveOrderSubmittedCommand struct {
+	Submitted *OrderSubmitted
+}
+
+// SaveOrderStateCommand upserts the order, an older state never overwrites a newer one
+type SaveOrderStateCommand struct {
+	State OrderState
+}
+
+// SaveFillsCommand inserts trades not stored yet, Inserted is set to the number of them
+type SaveFillsCommand struct {
+	Exchange Exchange
+	Symbol   Symbol
+	Trades   []*Trade
+
+	Inserted int
+}
+
+type SaveOpportunityCommand struct {
+	Opportunity *Opportunity
+}
+
+// SaveBalanceSnapshotCommand inserts one row for each asset of the exchange
+type SaveBalanceSnapshotCommand struct {
+	Exchange Exchange
+	Balances map[Asset]decimal.Decimal
+	Time     time.Time
+}
+
+// SaveTransferCommand upserts the transfer by ID
+type SaveTransferCommand struct {
+	Transfer *Transfer
+}
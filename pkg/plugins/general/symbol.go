@@ -0,0 +1,100 @@
+package general
+
+import (
+	"strings"
+	"sync"
+)
+
+// KnownQuoteAssets used as a fallback to split a symbol string when the exchange info is not loaded yet.
+// Longer assets go first, FDUSD must be matched before USD-like suffixes.
+var KnownQuoteAssets = []Asset{
+	FDUSD, USDT, USDC, BUSD, BTC, ETH, BNB,
+}
+
+func (s Symbol) String() string {
+	return string(s.BaseAsset) + "/" + string(s.QuoteAsset)
+}
+
+// SymbolMapper maps the symbol name of an exchange, like ETHUSDT, to Symbol and back.
+// It is fed with the base/quote metadata of exchangeInfo, so any quote asset is supported.
+type SymbolMapper struct {
+	rwM     sync.RWMutex
+	symbols map[string]Symbol
+	aliases map[Symbol]string
+}
+
+func NewSymbolMapper() *SymbolMapper {
+	return &SymbolMapper{
+		symbols: make(map[string]Symbol),
+		aliases: make(map[Symbol]string),
+	}
+}
+
+// Put registers the alias of exchange with its base and quote asset.
+func (m *SymbolMapper) Put(alias string, baseAsset string, quoteAsset string) Symbol {
+	symbol := Symbol{
+		BaseAsset:  ToAsset(baseAsset),
+		QuoteAsset: ToAsset(quoteAsset),
+	}
+	m.rwM.Lock()
+	m.symbols[strings.ToUpper(alias)] = symbol
+	m.aliases[symbol] = alias
+	m.rwM.Unlock()
+	return symbol
+}
+
+func (m *SymbolMapper) ToSymbol(alias string) (Symbol, bool) {
+	m.rwM.RLock()
+	defer m.rwM.RUnlock()
+	symbol, ok := m.symbols[strings.ToUpper(alias)]
+	return symbol, ok
+}
+
+func (m *SymbolMapper) ToAlias(symbol Symbol) (string, bool) {
+	m.rwM.RLock()
+	defer m.rwM.RUnlock()
+	alias, ok := m.aliases[symbol]
+	return alias, ok
+}
+
+func (m *SymbolMapper) Len() int {
+	m.rwM.RLock()
+	defer m.rwM.RUnlock()
+	return len(m.symbols)
+}
+
+// ParseSymbol split the concatenated symbol like ETHBTC by KnownQuoteAssets.
+// BaseAsset would be UnKnown if none of the quote assets is matched.
+func ParseSymbol(s string) Symbol {
+	upper := strings.ToUpper(s)
+	for _, quote := range KnownQuoteAssets {
+		if strings.HasSuffix(upper, string(quote)) && len(upper) > len(quote) {
+			return Symbol{
+				BaseAsset:  Asset(upper[0 : len(upper)-len(quote)]),
+				QuoteAsset: quote,
+			}
+		}
+	}
+	return Symbol{
+		BaseAsset:  UnKnown,
+		QuoteAsset: DefaultQuoteCoin,
+	}
+}
+
+// SameSymbols true if both contain the same symbols, in any order
+func SameSymbols(a, b []Symbol) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[Symbol]int, len(a))
+	for _, symbol := range a {
+		counts[symbol]++
+	}
+	for _, symbol := range b {
+		if counts[symbol] == 0 {
+			return false
+		}
+		counts[symbol]--
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package general
+
+import (
+	"testing"
+)
+
+func TestSymbolMapper(t *testing.T) {
+	mapper := NewSymbolMapper()
+	mapper.Put("ETHBTC", "ETH", "BTC")
+	mapper.Put("BTCFDUSD", "BTC", "FDUSD")
+
+	symbol, ok := mapper.ToSymbol("ethbtc")
+	if !ok || symbol != (Symbol{BaseAsset: ETH, QuoteAsset: BTC}) {
+		t.Fatalf("unexpected symbol: %v", symbol)
+	}
+	alias, ok := mapper.ToAlias(Symbol{BaseAsset: BTC, QuoteAsset: FDUSD})
+	if !ok || alias != "BTCFDUSD" {
+		t.Fatalf("unexpected alias: %s", alias)
+	}
+}
+
+func TestParseSymbol(t *testing.T) {
+	cases := map[string]Symbol{
+		"INJUSDT":  {BaseAsset: INJ, QuoteAsset: USDT},
+		"BTCFDUSD": {BaseAsset: BTC, QuoteAsset: FDUSD},
+		"ETHUSDC":  {BaseAsset: ETH, QuoteAsset: USDC},
+		"WOOBTC":   {BaseAsset: WOO, QuoteAsset: BTC},
+		"xyz":      {BaseAsset: UnKnown, QuoteAsset: DefaultQuoteCoin},
+	}
+	for s, expected := range cases {
+		if res := ParseSymbol(s); res != expected {
+			t.Fatalf("ParseSymbol(%s) = %v, expected: %v", s, res, expected)
+		}
+	}
+}
+
+func TestSameSymbols(t *testing.T) {
+	btc, eth := Symbol{BaseAsset: BTC, QuoteAsset: USDT}, Symbol{BaseAsset: ETH, QuoteAsset: USDT}
+	if !SameSymbols([]Symbol{btc, eth}, []Symbol{eth, btc}) || !SameSymbols(nil, []Symbol{}) {
+		t.Fatal("same symbols in another order")
+	}
+	if SameSymbols([]Symbol{btc, btc}, []Symbol{btc, eth}) || SameSymbols([]Symbol{btc}, []Symbol{btc, eth}) {
+		t.Fatal("different symbols")
+	}
+}
@@ -1,6 +1,9 @@
 package general
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
 
 type Trade struct {
 	Symbol          string          `json:"symbol"`
@@ -18,3 +21,68 @@ type Trade struct {
 	IsBestMatch     bool            `json:"isBestMatch"`
 	ClientOrderId   string          `json:"clientOrderId"`
 }
+
+// TradesSynced is published on bus with trades stored by TradeSyncService, some of them may be stored before
+type TradesSynced struct {
+	Exchange Exchange
+	Symbol   Symbol
+	Trades   []*Trade
+	Time     time.Time
+}
+
+// TradeQuery pagination of trade history, Limit is capped by each exchange.
+// FromID is inclusive and preferred by exchanges supporting it, StartTime is used by the others.
+type TradeQuery struct {
+	FromID    string
+	StartTime int64
+	EndTime   int64
+	Limit     int
+}
+
+type TradeInterface interface {
+	// ListTrades returns trades of the account in ascending order of time
+	ListTrades(symbol Symbol, query TradeQuery) ([]*Trade, error)
+}
+
+// TradeCursor position of the last synced trade, trades at the same time are kept to drop duplicates,
+// since both fromId and startTime are inclusive.
+type TradeCursor struct {
+	LastID string
+	Time   int64
+	ids    map[string]bool
+}
+
+// Query the next page from the cursor, an empty cursor starts from the first trade,
+// exchanges without fromId start from the oldest trade they keep
+func (c *TradeCursor) Query(limit int) TradeQuery {
+	if c.LastID == "" {
+		return TradeQuery{FromID: "0", Limit: limit}
+	}
+	return TradeQuery{FromID: c.LastID, StartTime: c.Time, Limit: limit}
+}
+
+// Seen tells if the trade is synced already
+func (c *TradeCursor) Seen(trade *Trade) bool {
+	return trade.Time < c.Time || (trade.Time == c.Time && c.ids[trade.Id])
+}
+
+// Advance drops trades synced already and moves the cursor to the last one
+func (c *TradeCursor) Advance(trades []*Trade) []*Trade {
+	var res []*Trade
+	for _, trade := range trades {
+		if c.Seen(trade) {
+			continue
+		}
+		if trade.Time > c.Time {
+			c.Time = trade.Time
+			c.ids = make(map[string]bool)
+		}
+		if c.ids == nil {
+			c.ids = make(map[string]bool)
+		}
+		c.ids[trade.Id] = true
+		c.LastID = trade.Id
+		res = append(res, trade)
+	}
+	return res
+}
@@ -0,0 +1,24 @@
+package general
+
+import "testing"
+
+func TestTradeCursor(t *testing.T) {
+	cursor := &TradeCursor{}
+	page := []*Trade{{Id: "1", Time: 100}, {Id: "2", Time: 200}, {Id: "3", Time: 200}}
+	if trades := cursor.Advance(page); len(trades) != 3 {
+		t.Fatalf("expected 3 new trades, got %d", len(trades))
+	}
+	if query := cursor.Query(100); query.FromID != "3" || query.StartTime != 200 {
+		t.Fatalf("unexpected query: %+v", query)
+	}
+
+	// both fromId and startTime are inclusive
+	page = []*Trade{{Id: "2", Time: 200}, {Id: "3", Time: 200}, {Id: "4", Time: 200}, {Id: "5", Time: 300}}
+	trades := cursor.Advance(page)
+	if len(trades) != 2 || trades[0].Id != "4" || trades[1].Id != "5" {
+		t.Fatalf("unexpected new trades: %v", trades)
+	}
+	if cursor.LastID != "5" || cursor.Time != 300 {
+		t.Fatalf("unexpected cursor: %+v", cursor)
+	}
+}
@@ -0,0 +1,75 @@
+package general
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrTradingPaused = errors.New("trading is paused")
+
+// TradingSwitch pauses creating new orders, canceling is always allowed
+type TradingSwitch struct {
+	rwM    sync.RWMutex
+	paused bool
+	reason string
+	since  time.Time
+}
+
+var DefaultTradingSwitch = &TradingSwitch{}
+
+// TradingState paused or not, since the last change
+type TradingState struct {
+	Paused bool      `json:"paused"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+// Pause returns false if it is paused already
+func (s *TradingSwitch) Pause(reason string) bool {
+	s.rwM.Lock()
+	defer s.rwM.Unlock()
+	if s.paused {
+		return false
+	}
+	s.paused, s.reason, s.since = true, reason, time.Now()
+	return true
+}
+
+// Resume returns false if it is not paused
+func (s *TradingSwitch) Resume() bool {
+	s.rwM.Lock()
+	defer s.rwM.Unlock()
+	if !s.paused {
+		return false
+	}
+	s.paused, s.reason, s.since = false, "", time.Now()
+	return true
+}
+
+func (s *TradingSwitch) State() TradingState {
+	s.rwM.RLock()
+	defer s.rwM.RUnlock()
+	return TradingState{Paused: s.paused, Reason: s.reason, Since: s.since}
+}
+
+// Check returns ErrTradingPaused with the reason if paused
+func (s *TradingSwitch) Check() error {
+	state := s.State()
+	if state.Paused {
+		return fmt.Errorf("%w: %s", ErrTradingPaused, state.Reason)
+	}
+	return nil
+}
+
+// TradingPaused is published on bus when trading is paused by operator
+type TradingPaused struct {
+	Reason string
+	Time   time.Time
+}
+
+// TradingResumed is published on bus when trading is resumed by operator
+type TradingResumed struct {
+	Time time.Time
+}
@@ -0,0 +1,42 @@
+package general
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTradingSwitch(t *testing.T) {
+	s := &TradingSwitch{}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Pause("maintenance") || s.Pause("again") {
+		t.Fatal("pause should take effect once")
+	}
+	if err := s.Check(); !errors.Is(err, ErrTradingPaused) {
+		t.Fatalf("expected ErrTradingPaused, got %v", err)
+	}
+	if state := s.State(); !state.Paused || state.Reason != "maintenance" {
+		t.Fatalf("unexpected state %+v", state)
+	}
+	if !s.Resume() || s.Resume() {
+		t.Fatal("resume should take effect once")
+	}
+	if err := s.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpportunityLog(t *testing.T) {
+	l := NewOpportunityLog(2)
+	for _, direction := range []string{"a", "b", "c"} {
+		l.Add(Opportunity{Direction: direction})
+	}
+	recent := l.Recent(0)
+	if len(recent) != 2 || recent[0].Direction != "c" || recent[1].Direction != "b" {
+		t.Fatalf("unexpected opportunities %+v", recent)
+	}
+	if recent := l.Recent(1); len(recent) != 1 || recent[0].Direction != "c" {
+		t.Fatalf("unexpected opportunities %+v", recent)
+	}
+}
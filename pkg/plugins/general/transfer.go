@@ -0,0 +1,96 @@
+package general
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// TransferState state machine of cross-exchange transfer:
+// CREATED → WITHDRAW_SUBMITTING → WITHDRAW_SUBMITTED → WITHDRAW_CONFIRMED → DEPOSIT_CREDITED, or FAILED at any step.
+type TransferState string
+
+const (
+	TransferStateCreated            TransferState = "CREATED"
+	TransferStateWithdrawSubmitting TransferState = "WITHDRAW_SUBMITTING" // withdrawal may or may not be accepted
+	TransferStateWithdrawSubmitted  TransferState = "WITHDRAW_SUBMITTED"
+	TransferStateWithdrawConfirmed  TransferState = "WITHDRAW_CONFIRMED" // on-chain, txId known
+	TransferStateDepositCredited    TransferState = "DEPOSIT_CREDITED"
+	TransferStateFailed             TransferState = "FAILED"
+)
+
+// Transfer moves Amount of Asset from exchange From to exchange To, persisted on every step
+type Transfer struct {
+	ID             string          `json:"id"`
+	Asset          Asset           `json:"asset"`
+	Amount         decimal.Decimal `json:"amount"`
+	From           Exchange        `json:"from"`
+	To             Exchange        `json:"to"`
+	AddressName    string          `json:"addressName"`
+	Network        string          `json:"network"`
+	WithdrawalID   string          `json:"withdrawalId"`
+	TxID           string          `json:"txId"`
+	Credited       decimal.Decimal `json:"credited"`
+	State          TransferState   `json:"state"`
+	Error          string          `json:"error,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	StateUpdatedAt time.Time       `json:"stateUpdatedAt"`
+	AlertedAt      time.Time       `json:"alertedAt,omitempty"`
+}
+
+func NewTransfer(asset Asset, amount decimal.Decimal, from, to Exchange) *Transfer {
+	now := time.Now()
+	return &Transfer{
+		ID:             genClientOrderID(),
+		Asset:          asset,
+		Amount:         amount,
+		From:           from,
+		To:             to,
+		State:          TransferStateCreated,
+		CreatedAt:      now,
+		StateUpdatedAt: now,
+	}
+}
+
+func (t *Transfer) IsDone() bool {
+	return t.State == TransferStateDepositCredited || t.State == TransferStateFailed
+}
+
+func (t *Transfer) SetState(state TransferState) {
+	t.State = state
+	t.StateUpdatedAt = time.Now()
+}
+
+func (t *Transfer) Fail(err error) {
+	t.Error = err.Error()
+	t.SetState(TransferStateFailed)
+}
+
+// IsStuck no progress for timeout
+func (t *Transfer) IsStuck(now time.Time, timeout time.Duration) bool {
+	return !t.IsDone() && now.Sub(t.StateUpdatedAt) > timeout
+}
+
+// TransferStarted is published on bus when the withdrawal is accepted by the exchange
+type TransferStarted struct {
+	Transfer Transfer
+	Time     time.Time
+}
+
+// TransferResumed is published on bus at startup for each transfer withdrawn before and not landed yet,
+// the balance of the exchange withdrawn from is already reduced
+type TransferResumed struct {
+	Transfer Transfer
+	Time     time.Time
+}
+
+// TransferLanded is published on bus when the deposit is credited
+type TransferLanded struct {
+	Transfer Transfer
+	Time     time.Time
+}
+
+// TransferFailed is published on bus when the transfer will never land
+type TransferFailed struct {
+	Transfer Transfer
+	Time     time.Time
+}
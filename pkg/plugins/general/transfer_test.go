@@ -0,0 +1,37 @@
+package general
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTransferState(t *testing.T) {
+	transfer := NewTransfer(USDT, decimal.NewFromInt(50), Binance, MEXC)
+	if transfer.State != TransferStateCreated || !IsOwnClientOrderID(transfer.ID) {
+		t.Fatalf("unexpected new transfer: %+v", transfer)
+	}
+
+	now := time.Now()
+	if transfer.IsStuck(now, time.Hour) {
+		t.Fatal("new transfer should not be stuck")
+	}
+	transfer.StateUpdatedAt = now.Add(-2 * time.Hour)
+	if !transfer.IsStuck(now, time.Hour) {
+		t.Fatal("transfer without progress should be stuck")
+	}
+
+	transfer.SetState(TransferStateWithdrawSubmitted)
+	if transfer.IsDone() || transfer.IsStuck(time.Now(), time.Hour) {
+		t.Fatal("transfer in progress should be neither done nor stuck")
+	}
+	transfer.Fail(errors.New("withdrawal canceled"))
+	if !transfer.IsDone() || transfer.Error != "withdrawal canceled" {
+		t.Fatalf("unexpected failed transfer: %+v", transfer)
+	}
+	if transfer.IsStuck(now.Add(2*time.Hour), time.Hour) {
+		t.Fatal("done transfer should never be stuck")
+	}
+}
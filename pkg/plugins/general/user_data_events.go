@@ -9,7 +9,7 @@ const (
 	UserDataEventTypeOutboundAccountPosition UserDataEventType = "outboundAccountPosition"
 	UserDataEventTypeBalanceUpdate           UserDataEventType = "balanceUpdate"
 	UserDataEventTypeExecutionReport         UserDataEventType = "executionReport"
-	UserDataEventTypeListStatus              UserDataEventType = "ListStatus"
+	UserDataEventTypeListStatus              UserDataEventType = "listStatus"
 )
 
 // UserDataEvent define user data event
@@ -85,6 +85,7 @@ type WsOCOUpdate struct {
 	ListOrderStatus string `json:"L"`
 	RejectReason    string `json:"r"`
 	ClientOrderId   string `json:"C"` // List Client Order ID
+	TransactionTime int64  `json:"T"`
 	Orders          WsOCOOrderList
 }
 
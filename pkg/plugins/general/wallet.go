@@ -0,0 +1,151 @@
+package general
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/setting"
+)
+
+// TransferStatus status of deposit and withdrawal, normalized from status codes of each exchange
+type TransferStatus string
+
+const (
+	TransferStatusPending    TransferStatus = "PENDING"
+	TransferStatusProcessing TransferStatus = "PROCESSING"
+	TransferStatusSuccess    TransferStatus = "SUCCESS"
+	TransferStatusFailed     TransferStatus = "FAILED"
+	TransferStatusCanceled   TransferStatus = "CANCELED"
+)
+
+func (s TransferStatus) IsDone() bool {
+	return s == TransferStatusSuccess || s == TransferStatusFailed || s == TransferStatusCanceled
+}
+
+// NetworkInfo deposit and withdrawal metadata of an asset on a network
+type NetworkInfo struct {
+	Asset                   Asset
+	Network                 string
+	Name                    string
+	IsDefault               bool
+	DepositEnable           bool
+	WithdrawEnable          bool
+	WithdrawFee             decimal.Decimal
+	WithdrawMin             decimal.Decimal
+	WithdrawMax             decimal.Decimal
+	WithdrawIntegerMultiple decimal.Decimal
+	MinConfirm              int
+	NeedMemo                bool
+}
+
+type DepositAddress struct {
+	Asset   Asset
+	Network string
+	Address string
+	Tag     string
+}
+
+type Deposit struct {
+	Asset      Asset
+	Network    string
+	Address    string
+	Tag        string
+	Amount     decimal.Decimal
+	TxID       string
+	Status     TransferStatus
+	InsertTime int64
+}
+
+type Withdrawal struct {
+	ID              string
+	WithdrawOrderID string
+	Asset           Asset
+	Network         string
+	Address         string
+	Tag             string
+	Amount          decimal.Decimal
+	Fee             decimal.Decimal
+	TxID            string
+	Status          TransferStatus
+	ApplyTime       int64
+}
+
+// WithdrawRequest withdraws to an address in the address book, raw addresses are never accepted
+type WithdrawRequest struct {
+	Asset           Asset
+	Amount          decimal.Decimal
+	AddressName     string
+	WithdrawOrderID string
+}
+
+type WalletInterface interface {
+	GetNetworks(asset Asset) ([]*NetworkInfo, error)
+	GetDepositAddress(asset Asset, network string) (*DepositAddress, error)
+	Withdraw(req WithdrawRequest) (*Withdrawal, error)
+	ListWithdrawals(asset Asset, startTime, endTime int64) ([]*Withdrawal, error)
+	ListDeposits(asset Asset, startTime, endTime int64) ([]*Deposit, error)
+}
+
+// LookupWithdrawAddress finds the whitelisted address of asset by name in the address book
+func LookupWithdrawAddress(name string, asset Asset) (*setting.WithdrawAddress, error) {
+	for i := range setting.SecretsConf.AddressBook {
+		address := &setting.SecretsConf.AddressBook[i]
+		if address.Name == name && Asset(address.Asset) == asset {
+			return address, nil
+		}
+	}
+	return nil, fmt.Errorf("address %s of %s is not in the address book", name, asset)
+}
+
+// FindWithdrawAddress finds the whitelisted address owned by exchange for asset
+func FindWithdrawAddress(exchange Exchange, asset Asset) (*setting.WithdrawAddress, error) {
+	for i := range setting.SecretsConf.AddressBook {
+		address := &setting.SecretsConf.AddressBook[i]
+		if Exchange(address.Exchange) == exchange && Asset(address.Asset) == asset {
+			return address, nil
+		}
+	}
+	return nil, fmt.Errorf("no address of %s owned by %s in the address book", asset, exchange)
+}
+
+// ValidateWithdraw checks amount against the metadata of the network
+func ValidateWithdraw(network *NetworkInfo, amount decimal.Decimal) error {
+	if !network.WithdrawEnable {
+		return fmt.Errorf("withdrawal of %s on %s is disabled", network.Asset, network.Network)
+	}
+	if amount.LessThan(network.WithdrawMin) {
+		return fmt.Errorf("amount %s is less than min withdrawal %s", amount, network.WithdrawMin)
+	}
+	if network.WithdrawMax.IsPositive() && amount.GreaterThan(network.WithdrawMax) {
+		return fmt.Errorf("amount %s is greater than max withdrawal %s", amount, network.WithdrawMax)
+	}
+	if network.WithdrawIntegerMultiple.IsPositive() && !amount.Mod(network.WithdrawIntegerMultiple).IsZero() {
+		return fmt.Errorf("amount %s is not multiple of %s", amount, network.WithdrawIntegerMultiple)
+	}
+	return nil
+}
+
+var ErrWalletNotSupported = errors.New("deposit and withdrawal are not supported by the exchange")
+
+// UnsupportedWallet for plugins without deposit and withdrawal, e.g. derivatives sharing the wallet of spot
+type UnsupportedWallet struct{}
+
+func (UnsupportedWallet) GetNetworks(Asset) ([]*NetworkInfo, error) {
+	return nil, ErrWalletNotSupported
+}
+
+func (UnsupportedWallet) GetDepositAddress(Asset, string) (*DepositAddress, error) {
+	return nil, ErrWalletNotSupported
+}
+
+func (UnsupportedWallet) Withdraw(WithdrawRequest) (*Withdrawal, error) {
+	return nil, ErrWalletNotSupported
+}
+
+func (UnsupportedWallet) ListWithdrawals(Asset, int64, int64) ([]*Withdrawal, error) {
+	return nil, ErrWalletNotSupported
+}
+
+func (UnsupportedWallet) ListDeposits(Asset, int64, int64) ([]*Deposit, error) {
+	return nil, ErrWalletNotSupported
+}
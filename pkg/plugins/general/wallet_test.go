@@ -0,0 +1,48 @@
+package general
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/setting"
+)
+
+func TestLookupWithdrawAddress(t *testing.T) {
+	book := setting.SecretsConf.AddressBook
+	defer func() { setting.SecretsConf.AddressBook = book }()
+	setting.SecretsConf.AddressBook = []setting.WithdrawAddress{
+		{Name: "mexc_usdt", Exchange: string(MEXC), Asset: string(USDT), Network: "TRX", Address: "T123"},
+	}
+
+	address, err := LookupWithdrawAddress("mexc_usdt", USDT)
+	if err != nil || address.Address != "T123" {
+		t.Fatalf("unexpected address: %v, %v", address, err)
+	}
+	if _, err := LookupWithdrawAddress("mexc_usdt", INJ); err == nil {
+		t.Fatal("address of another asset should not be found")
+	}
+	if address, err := FindWithdrawAddress(MEXC, USDT); err != nil || address.Name != "mexc_usdt" {
+		t.Fatalf("unexpected address: %v, %v", address, err)
+	}
+}
+
+func TestValidateWithdraw(t *testing.T) {
+	network := &NetworkInfo{
+		Asset:                   USDT,
+		Network:                 "TRX",
+		WithdrawEnable:          true,
+		WithdrawMin:             decimal.NewFromInt(10),
+		WithdrawMax:             decimal.NewFromInt(1000),
+		WithdrawIntegerMultiple: decimal.NewFromFloat(0.01),
+	}
+	cases := map[string]bool{"5": false, "10": true, "10.005": false, "1001": false, "999.99": true}
+	for amount, valid := range cases {
+		if err := ValidateWithdraw(network, decimal.RequireFromString(amount)); (err == nil) != valid {
+			t.Fatalf("amount %s should be valid: %v, err: %v", amount, valid, err)
+		}
+	}
+	network.WithdrawEnable = false
+	if err := ValidateWithdraw(network, decimal.NewFromInt(100)); err == nil {
+		t.Fatal("disabled network should be refused")
+	}
+}
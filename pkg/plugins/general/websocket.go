@@ -13,6 +13,9 @@ import (
 var (
 	// GWebsocketTimeout is an interval for sending ping/pong messages if WebsocketKeepalive is enabled
 	GWebsocketTimeout = time.Second * 10
+	// GWebsocketStableAfter a connection is up this long before its closed alert is resolved,
+	// a stream flapping right after connecting keeps its alert open
+	GWebsocketStableAfter = time.Minute
 )
 
 // WsHandler handle raw websocket message
@@ -21,6 +24,8 @@ type WsHandler func(message []byte)
 type ErrHandler func(err error)
 
 type WsServe struct {
+	// name of the stream in alerts and logs, the endpoint may carry a secret like a listenKey
+	name      string
 	endpoint  string
 	handler   WsHandler
 	connected bool
@@ -29,8 +34,9 @@ type WsServe struct {
 	closeRWM  sync.RWMutex
 }
 
-func NewWsServe(endpoint string, handler WsHandler) (*WsServe, error) {
+func NewWsServe(name string, endpoint string, handler WsHandler) (*WsServe, error) {
 	s := &WsServe{
+		name:      name,
 		endpoint:  endpoint,
 		handler:   handler,
 		connected: false,
@@ -45,6 +51,7 @@ func (s *WsServe) start() error {
 	if err != nil {
 		return err
 	}
+	s.resolveWhenStable()
 	s.keepalive()
 	s.runReader()
 	return nil
@@ -67,6 +74,22 @@ func (s *WsServe) initConn() error {
 	return nil
 }
 
+// alertKey closes of the stream are deduplicated, and resolved once connected again for GWebsocketStableAfter
+func (s *WsServe) alertKey() string {
+	return "websocket closed: " + s.name
+}
+
+func (s *WsServe) resolveWhenStable() {
+	doneC := s.doneC
+	go func() {
+		select {
+		case <-doneC:
+		case <-time.After(GWebsocketStableAfter):
+			alerting.Resolve(s.alertKey())
+		}
+	}()
+}
+
 func (s *WsServe) IsClosed() bool {
 	return !s.connected
 }
@@ -75,7 +98,12 @@ func (s *WsServe) Close(err error) {
 	s.closeRWM.Lock()
 	defer func() {
 		s.closeRWM.Unlock()
-		alerting.NotifyRightNow(err, "websocket closed")
+		if err != nil {
+			alerting.RaiseRightNow(alerting.SeverityError, s.alertKey(), "websocket closed", "stream", s.name,
+				"err", err)
+		} else {
+			alerting.Info("websocket closed", "stream", s.name)
+		}
 	}()
 
 	if s.IsClosed() {
@@ -0,0 +1,145 @@
+package plugins
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sort"
+	"strings"
+	"time"
+)
+
+const InventoryServiceName = "InventoryService"
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         InventoryServiceName,
+		Instance:     &InventoryService{},
+		InitPriority: registry.High,
+	})
+}
+
+// InventoryService keeps the tradable inventory of every exchange, refreshed from account info,
+// and moved by transfers between exchanges in the meantime.
+type InventoryService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	inventory *Inventory
+	interval  time.Duration
+}
+
+func (s *InventoryService) Init() error {
+	s.lg = log.New("service.inventory")
+	s.inventory = NewInventory()
+	s.interval = setting.InventoryRefreshInterval
+	s.Bus.AddEventListener(s.onTransferStarted)
+	s.Bus.AddEventListener(s.onTransferResumed)
+	s.Bus.AddEventListener(s.onTransferLanded)
+	s.Bus.AddEventListener(s.onTransferFailed)
+	s.Bus.AddHandler(s.onBalancesCommand)
+	return nil
+}
+
+func (s *InventoryService) Inventory() *Inventory {
+	return s.inventory
+}
+
+func (s *InventoryService) Run(ctx context.Context) error {
+	s.refresh()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.refresh()
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+func (s *InventoryService) refresh() {
+	for _, plugin := range GetExPlugins() {
+		account, err := plugin.Instance.GetAccountManager().GetAccountInfo()
+		if err != nil {
+			s.lg.Warn("failed to refresh inventory", "exchange", plugin.ExName, "err", err)
+			continue
+		}
+		s.inventory.Reset(plugin.ExName, account)
+	}
+}
+
+func (s *InventoryService) onTransferStarted(event *TransferStarted) error {
+	t := event.Transfer
+	s.inventory.Withdrawn(t.From, t.Asset, t.Amount)
+	return nil
+}
+
+func (s *InventoryService) onTransferResumed(event *TransferResumed) error {
+	t := event.Transfer
+	s.inventory.Resumed(t.Asset, t.Amount)
+	return nil
+}
+
+func (s *InventoryService) onTransferLanded(event *TransferLanded) error {
+	t := event.Transfer
+	s.inventory.Landed(t.To, t.Asset, t.Amount, t.Credited)
+	s.lg.Info("funds landed", "exchange", t.To, "asset", t.Asset, "credited", t.Credited,
+		"tradable", s.inventory.Tradable(t.To, t.Asset))
+	return nil
+}
+
+func (s *InventoryService) onTransferFailed(event *TransferFailed) error {
+	t := event.Transfer
+	s.inventory.Lost(t.Asset, t.Amount)
+	return nil
+}
+
+// onBalancesCommand one row per asset held anywhere, one column per exchange
+func (s *InventoryService) onBalancesCommand(cmd *alerting.BalancesCommand) error {
+	exchanges := s.inventory.Exchanges()
+	sort.Slice(exchanges, func(i, j int) bool { return exchanges[i] < exchanges[j] })
+	balances := make(map[Exchange]map[Asset]decimal.Decimal)
+	held := make(map[Asset]bool)
+	for _, exchange := range exchanges {
+		balances[exchange] = s.inventory.Balances(exchange)
+		for asset, amount := range balances[exchange] {
+			if !amount.IsZero() {
+				held[asset] = true
+			}
+		}
+	}
+	for asset, amount := range s.inventory.InTransitAll() {
+		if !amount.IsZero() {
+			held[asset] = true
+		}
+	}
+	assets := make([]Asset, 0, len(held))
+	for asset := range held {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i] < assets[j] })
+
+	cmd.Reply.Title = "Balances"
+	cmd.Reply.Header = []string{"ASSET"}
+	for _, exchange := range exchanges {
+		cmd.Reply.Header = append(cmd.Reply.Header, strings.ToUpper(string(exchange)))
+	}
+	cmd.Reply.Header = append(cmd.Reply.Header, "IN_TRANSIT", "TOTAL")
+	for _, asset := range assets {
+		row := []string{string(asset)}
+		for _, exchange := range exchanges {
+			row = append(row, balances[exchange][asset].String())
+		}
+		row = append(row, s.inventory.InTransit(asset).String(), s.inventory.Total(asset).String())
+		cmd.Reply.AddRow(row...)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package plugins
+
+import (
+	"context"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/journal"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	JournalServiceName = "JournalService"
+
+	// orders, balances and events wait for the queues of the journal when they are full, they are needed for replay.
+	// Opportunities and health reports drop the oldest instead.
+	journalQueue = 10000
+	// drops are alerted at most once in the interval
+	journalDropCheckInterval = time.Minute
+	journalDropAlertKey      = "journal.dropped"
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         JournalServiceName,
+		Instance:     &JournalService{},
+		InitPriority: registry.High,
+	})
+}
+
+// JournalService appends orders submitted, order and balance updates, opportunities, health reports
+// and events published on the bus to the journal in <data>/journal, for audit and replay.
+type JournalService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	journal       *journal.Journal
+	submissionC   *bus.Subscription[*OrderSubmitted]
+	orderUpdateC  *bus.Subscription[*OrderUpdated]
+	balanceC      *bus.Subscription[*BalanceUpdated]
+	opportunityC  *bus.Subscription[*Opportunity]
+	healthReportC *bus.Subscription[*HealthReport]
+
+	// events of the wildcard listener, appended by Run until closedC is closed
+	eventC  chan interface{}
+	closedC chan struct{}
+	// drops alerted already, owned by Run
+	dropped int64
+
+	failedM sync.Mutex
+	failed  bool
+}
+
+func (s *JournalService) Init() error {
+	s.lg = log.New("service.journal")
+	var err error
+	s.journal, err = journal.Open(filepath.Join(setting.DataPath, "journal"), journal.Options{
+		SegmentSize: int64(setting.JournalSegmentSizeMB) << 20,
+		Sync:        setting.JournalSync,
+	})
+	if err != nil {
+		return err
+	}
+	s.lg.Info("journal opened", "seq", s.journal.Seq())
+
+	s.submissionC = OrderSubmissions.Subscribe(JournalServiceName, journalQueue, bus.PolicyBlock)
+	s.orderUpdateC = OrderUpdates.Subscribe(JournalServiceName, journalQueue, bus.PolicyBlock)
+	s.balanceC = BalanceUpdates.Subscribe(JournalServiceName, journalQueue, bus.PolicyBlock)
+	s.opportunityC = Opportunities.Subscribe(JournalServiceName, journalQueue, bus.PolicyDrop)
+	s.healthReportC = HealthReports.Subscribe(JournalServiceName, journalQueue, bus.PolicyDrop)
+	s.eventC = make(chan interface{}, journalQueue)
+	s.closedC = make(chan struct{})
+	s.Bus.AddWildcardListener(s.onEvent)
+	return nil
+}
+
+func (s *JournalService) IsDisabled() bool {
+	return !setting.JournalEnabled
+}
+
+func (s *JournalService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(journalDropCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-s.eventC:
+			s.append(msg)
+		case <-ticker.C:
+			s.checkDropped()
+		case msg := <-s.submissionC.C():
+			s.append(msg)
+		case msg := <-s.orderUpdateC.C():
+			s.append(msg)
+		case msg := <-s.balanceC.C():
+			s.append(msg)
+		case msg := <-s.opportunityC.C():
+			s.append(msg)
+		case msg := <-s.healthReportC.C():
+			s.append(msg)
+		case <-ctx.Done():
+			s.close()
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+// close unsubscribes, appends what is still queued and closes the journal
+func (s *JournalService) close() {
+	s.submissionC.Close()
+	s.orderUpdateC.Close()
+	s.balanceC.Close()
+	s.opportunityC.Close()
+	s.healthReportC.Close()
+	close(s.closedC)
+	for msg := range s.submissionC.C() {
+		s.append(msg)
+	}
+	for msg := range s.orderUpdateC.C() {
+		s.append(msg)
+	}
+	for msg := range s.balanceC.C() {
+		s.append(msg)
+	}
+	for msg := range s.opportunityC.C() {
+		s.append(msg)
+	}
+	for msg := range s.healthReportC.C() {
+		s.append(msg)
+	}
+	// the wildcard listener can not be removed, the queue is never closed
+	for len(s.eventC) > 0 {
+		s.append(<-s.eventC)
+	}
+	s.checkDropped()
+	if err := s.journal.Close(); err != nil {
+		s.lg.Error("failed to close journal", "err", err)
+	}
+}
+
+// onEvent queues every event published on the bus, publishers wait when the queue is full,
+// events published after the journal is closed are not recorded
+func (s *JournalService) onEvent(msg interface{}) error {
+	select {
+	case s.eventC <- msg:
+	case <-s.closedC:
+	}
+	return nil
+}
+
+// checkDropped alerts opportunities and health reports lost since the last check
+func (s *JournalService) checkDropped() {
+	total := s.opportunityC.Dropped() + s.healthReportC.Dropped()
+	if total == s.dropped {
+		return
+	}
+	s.lg.Warn("journal queues are full, records are dropped", "dropped", total-s.dropped, "total", total)
+	alerting.Raise(alerting.SeverityError, journalDropAlertKey, "journal dropped records", "dropped", total-s.dropped,
+		"total", total)
+	s.dropped = total
+}
+
+// append records msg under the name of its type, e.g. OrderSubmitted
+func (s *JournalService) append(msg interface{}) {
+	t := reflect.TypeOf(msg)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	_, err := s.journal.Append(t.Name(), msg)
+	if err == journal.ErrClosed {
+		return
+	}
+
+	s.failedM.Lock()
+	defer s.failedM.Unlock()
+	if err != nil && !s.failed {
+		s.lg.Error("failed to append to journal", "type", t.Name(), "err", err)
+		alerting.Notify(err, "journal append failed, events are not recorded")
+	} else if err == nil && s.failed {
+		s.lg.Info("journal append recovered")
+	}
+	s.failed = err != nil
+}
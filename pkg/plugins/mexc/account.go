@@ -421,7 +421,7 @@ func WsUserDataServe(listenKey string, handler WsUserDataHandler, errHandler Err
 
 		handler(event)
 	}
-	wsServe, err = NewWsServe(endpoint, wsHandler)
+	wsServe, err = NewWsServe("MEXC user data", endpoint, wsHandler)
 	if err != nil {
 		return nil, err
 	}
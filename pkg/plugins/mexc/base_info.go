@@ -3,19 +3,22 @@ package mexc
 import (
 	"errors"
 	"fmt"
+	"github.com/bitly/go-simplejson"
 	"jasonzhu.com/coin_labor/core/util/http"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sync"
 )
 
 type BaseInfoManager struct {
 	SymbolsMap map[string]*SymbolBasicInfo
+	rwM        sync.RWMutex
 }
 
 func newBaseInfoManager() (BaseInterface, error) {
 	s := &BaseInfoManager{
 		SymbolsMap: make(map[string]*SymbolBasicInfo),
 	}
-	err := s.syncExchangeInfo()
+	_, err := s.syncExchangeInfo()
 	return s, err
 }
 
@@ -28,24 +31,28 @@ func (s *BaseInfoManager) ServerTime() (serverTime int64, err error) {
 	return data.Get("serverTime").MustInt64(), nil
 }
 
-func (s *BaseInfoManager) syncExchangeInfo() error {
+func (s *BaseInfoManager) syncExchangeInfo() ([]*SymbolInfoChange, error) {
 	j, err := httpGetData(exchangeInfoEndpoint, http.Params{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	symbolsMap := make(map[string]*SymbolBasicInfo)
 	symbolsLen := len(j.Get("symbols").MustArray())
 	for i := 0; i < symbolsLen; i++ {
 		item := j.Get("symbols").GetIndex(i)
 
 		symbol := item.Get("symbol").MustString()
+		baseAsset := item.Get("baseAsset").MustString()
+		quoteAsset := item.Get("quoteAsset").MustString()
 		baseAssetPrecision := int32(item.Get("baseAssetPrecision").MustInt())
 		quoteAssetPrecision := int32(item.Get("quoteAssetPrecision").MustInt())
-		s.SymbolsMap[symbol] = &SymbolBasicInfo{
+		symbolsMap[symbol] = &SymbolBasicInfo{
 			Symbol:              symbol,
-			BaseAsset:           item.Get("baseAsset").MustString(),
+			Status:              convertToSymbolStatus(item),
+			BaseAsset:           baseAsset,
 			BaseAssetPrecision:  baseAssetPrecision,
-			QuoteAsset:          item.Get("quoteAsset").MustString(),
+			QuoteAsset:          quoteAsset,
 			QuoteAssetPrecision: quoteAssetPrecision,
 
 			TickSize:          ConvertPrecisionFromIntToDecimal(quoteAssetPrecision),
@@ -55,32 +62,59 @@ func (s *BaseInfoManager) syncExchangeInfo() error {
 			StepSizePrecision: baseAssetPrecision,
 			MinNotional:       NewDecimalFromStringIgnoreErr(item.Get("quoteAmountPrecision").MustString()), //quoteAmountPrecision	string	最小下单金额
 		}
+		symbolMapper.Put(symbol, baseAsset, quoteAsset)
 	}
 
-	return nil
+	s.rwM.Lock()
+	defer s.rwM.Unlock()
+	changes := DiffSymbolsBasicInfo(MEXC, s.SymbolsMap, symbolsMap, newSymbolFromString, func(symbol Symbol) bool {
+		return isAssetSupported(symbol.BaseAsset)
+	})
+	s.SymbolsMap = symbolsMap
+	return changes, nil
+}
+
+// convertToSymbolStatus status of MEXC: ENABLED, or 1:online 2:pause 3:offline; isSpotTradingAllowed false means halted as well
+func convertToSymbolStatus(item *simplejson.Json) SymbolStatus {
+	if !item.Get("isSpotTradingAllowed").MustBool(true) {
+		return SymbolStatusHalt
+	}
+	switch status := item.Get("status").MustString(); status {
+	case "ENABLED", "1":
+		return SymbolStatusTrading
+	case "2":
+		return SymbolStatusHalt
+	case "3":
+		return SymbolStatusBreak
+	default:
+		return SymbolStatus(status)
+	}
+}
+
+func (s *BaseInfoManager) RefreshExchangeInfo() ([]*SymbolInfoChange, error) {
+	return s.syncExchangeInfo()
 }
 
 // GetSymbolBasicInfo TODO: Get from server
 func (s *BaseInfoManager) GetSymbolBasicInfo(symbol Symbol) (*SymbolBasicInfo, error) {
-	symbol2USDT := getSymbolAlias(symbol)
-	info := s.SymbolsMap[symbol2USDT]
+	alias := getSymbolAlias(symbol)
+	s.rwM.RLock()
+	info := s.SymbolsMap[alias]
+	s.rwM.RUnlock()
 	if info == nil {
-		return nil, errors.New(fmt.Sprintf("symbol[%s] not supported", symbol2USDT))
+		return nil, errors.New(fmt.Sprintf("symbol[%s] not supported", alias))
 	}
 	return info, nil
 }
 
-// GetSymbolsBasicInfo TODO: Get from server
+// GetSymbolsBasicInfo returns the symbols of supported assets quoted by the enabled quote assets, halted ones included.
 func (s *BaseInfoManager) GetSymbolsBasicInfo() map[Symbol]*SymbolBasicInfo {
+	s.rwM.RLock()
+	defer s.rwM.RUnlock()
 	var res = make(map[Symbol]*SymbolBasicInfo)
-	for _, asset := range supportedAssets {
-		symbol := Symbol{
-			BaseAsset:  asset,
-			QuoteAsset: DefaultQuoteCoin,
-		}
-		symbol2USDT := getSymbolAlias(symbol)
-		info := s.SymbolsMap[symbol2USDT]
-		if info != nil {
+	for alias, info := range s.SymbolsMap {
+		symbol := newSymbolFromString(alias)
+		if isAssetSupported(symbol.BaseAsset) && IsQuoteAssetEnabled(symbol.QuoteAsset) {
 			res[symbol] = info
 		}
 	}
@@ -6,7 +6,6 @@ import (
 	"jasonzhu.com/coin_labor/core/components/log"
 	"jasonzhu.com/coin_labor/core/util/http"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
-	"strings"
 )
 
 const (
@@ -35,6 +34,13 @@ const (
 	batchOrderEndpoint = "/api/v3/batchOrders" // POST create; GET query; DELETE cancel
 	myTradesEndpoint   = "/api/v3/myTrades"
 
+	// Wallet
+	capitalConfigEndpoint   = "/api/v3/capital/config/getall"
+	depositAddressEndpoint  = "/api/v3/capital/deposit/address"
+	depositHistoryEndpoint  = "/api/v3/capital/deposit/hisrec"
+	withdrawEndpoint        = "/api/v3/capital/withdraw/apply"
+	withdrawHistoryEndpoint = "/api/v3/capital/withdraw/history"
+
 	// WS
 	listenKeyEndpoint = "/api/v3/userDataStream" // POST create; PUT Keep-alive; DELETE close
 
@@ -55,26 +61,22 @@ func isAssetSupported(asset Asset) bool {
 	return false
 }
 
+// symbolMapper is filled by BaseInfoManager with the base/quote assets from exchangeInfo.
+var symbolMapper = NewSymbolMapper()
+
 func getSymbolAlias(symbol Symbol) string {
+	if alias, ok := symbolMapper.ToAlias(symbol); ok {
+		return alias
+	}
 	return string(symbol.BaseAsset) + string(symbol.QuoteAsset)
 }
 
+// Convert from string, like ETHUSDT, ETHBTC or BTCUSDC
 func newSymbolFromString(symbol string) Symbol {
-	if strings.HasSuffix(strings.ToUpper(symbol), string(DefaultQuoteCoin)) {
-		baseCoin := symbol[0 : len(symbol)-len(DefaultQuoteCoin)]
-		for _, s := range supportedAssets {
-			if baseCoin == string(s) {
-				return Symbol{
-					BaseAsset:  ToAsset(baseCoin),
-					QuoteAsset: DefaultQuoteCoin,
-				}
-			}
-		}
-	}
-	return Symbol{
-		BaseAsset:  UnKnown,
-		QuoteAsset: DefaultQuoteCoin,
+	if s, ok := symbolMapper.ToSymbol(symbol); ok {
+		return s
 	}
+	return ParseSymbol(symbol)
 }
 
 func httpGetData(endpoint string, params http.Params) (*simplejson.Json, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/adshao/go-binance/v2/common"
 	"github.com/bitly/go-simplejson"
 	"jasonzhu.com/coin_labor/core/components/log"
 	"jasonzhu.com/coin_labor/core/components/metrics"
@@ -181,32 +182,12 @@ func (s *OrderManager) CreateOrder(plan OrderPlan) (*CreateOrderResponse, error)
 		Endpoint: endpoint,
 		SecType:  SecTypeSigned,
 	}
-	symbol2USDT := getSymbolAlias(plan.Symbol)
-	r.SetParam("symbol", symbol2USDT)
-	r.SetParam("side", plan.Side)      //ENUM: BUY SELL
-	r.SetParam("type", plan.OrderType) // ENUM: same as Binance, some of what is not supported right now. https://www.MEXC.me/docs/v1/intro#enum-definitions
-
-	if plan.ClientOrderID != "" {
-		r.SetParam("newClientOrderId", plan.ClientOrderID)
-	}
-
-	if plan.Quantity != nil {
-		qty := plan.Quantity.String()
-		r.SetParam("quantity", qty)
-	} else {
-		return nil, errors.New("quantity can't be null")
+	params, err := buildOrderParams(plan)
+	if err != nil {
+		return nil, err
 	}
-
-	if plan.OrderType == OrderTypeLimit {
-		if plan.Price != nil {
-			r.SetParam("price", plan.Price.String())
-		} else {
-			return nil, errors.New("price can't be null")
-		}
-	} else if plan.OrderType == OrderTypeMarket {
-		//r.SetParam()
-	} else {
-		// not supported.
+	for k, v := range params {
+		r.SetParam(k, v)
 	}
 
 	// plan.QuoteOrderQty //quoteOrderQty param is not supported in MEXC
@@ -218,7 +199,7 @@ func (s *OrderManager) CreateOrder(plan OrderPlan) (*CreateOrderResponse, error)
 		s.lg.Error("createOrder failed", "clientOrderId", plan.ClientOrderID, "err", err)
 		fmt.Println("ERROR---------", err, r)
 		//alerting.Notify(err, "Create Order Failed in MEXC", "ClientOrderID", plan.ClientOrderID)
-		return nil, err
+		return nil, convertOrderErr(err)
 	}
 	j, err := simplejson.NewJson(data)
 	if err != nil {
@@ -233,6 +214,38 @@ func (s *OrderManager) CreateOrder(plan OrderPlan) (*CreateOrderResponse, error)
 	}, nil
 }
 
+// buildOrderParams params of creating order, also used as an item of batchOrders
+func buildOrderParams(plan OrderPlan) (map[string]string, error) {
+	params := map[string]string{
+		"symbol": getSymbolAlias(plan.Symbol),
+		"side":   string(plan.Side),      //ENUM: BUY SELL
+		"type":   string(plan.OrderType), // ENUM: same as Binance, some of what is not supported right now. https://www.MEXC.me/docs/v1/intro#enum-definitions
+	}
+
+	if plan.ClientOrderID != "" {
+		params["newClientOrderId"] = plan.ClientOrderID
+	}
+
+	if plan.Quantity != nil {
+		params["quantity"] = plan.Quantity.String()
+	} else {
+		return nil, errors.New("quantity can't be null")
+	}
+
+	if plan.OrderType == OrderTypeLimit {
+		if plan.Price != nil {
+			params["price"] = plan.Price.String()
+		} else {
+			return nil, errors.New("price can't be null")
+		}
+	} else if plan.OrderType == OrderTypeMarket {
+		//r.SetParam()
+	} else {
+		// not supported.
+	}
+	return params, nil
+}
+
 func (s *OrderManager) GetOrder(symbol Symbol, orderId string, clientOrderId string) (*Order, error) {
 	start := time.Now()
 	r := &Request{
@@ -251,7 +264,7 @@ func (s *OrderManager) GetOrder(symbol Symbol, orderId string, clientOrderId str
 	defer func() { uploadMetrics(symbol.BaseAsset, "GetOrder", err, start) }()
 
 	if err != nil {
-		return nil, err
+		return nil, convertOrderErr(err)
 	}
 	j, err := simplejson.NewJson(data)
 	if err != nil {
@@ -304,3 +317,19 @@ func uploadMetrics(asset Asset, typ string, err error, start time.Time) {
 		).Observe(float64(duration))
 	}()
 }
+
+// convertOrderErr wraps API errors after which the order may exist with ErrSendStatusUnknown,
+// responses of 5xx are not json and leave the code 0
+func convertOrderErr(err error) error {
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Code {
+	case 0:
+		return fmt.Errorf("%w: %v", ErrSendStatusUnknown, err)
+	case -2013: // Order does not exist
+		return fmt.Errorf("%w: %v", ErrOrderNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,158 @@
+package mexc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bitly/go-simplejson"
+	. "jasonzhu.com/coin_labor/core/util/http"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"net/http"
+	"time"
+)
+
+// maxBatchOrders orders in one batchOrders call, all of them must be of the same symbol.
+const maxBatchOrders = 20
+
+// BatchCreateOrders create up to maxBatchOrders orders of one symbol in each call, https://mxcdevelop.github.io/apidocs/spot_v3_cn/
+// If a call fails, the results of the calls sent before are returned with the error.
+/**
+Response Example, one item for each order in the same order as request:
+[
+  {
+    "symbol": "BTCUSDT",
+    "orderId": "1196315350023612316",
+    "orderListId": -1
+  },
+  {
+    "newClientOrderId": "123456",
+    "msg": "The minimum transaction volume cannot be less than：0.5USDT",
+    "code": 30002
+  }
+]
+*/
+func (s *OrderManager) BatchCreateOrders(plans []OrderPlan) ([]*BatchOrderResult, error) {
+	if !DefaultHealthChecker.IsAllFeaturesHealthy() {
+		plg.Warn("unhealthy, skip batch create orders in MEXC")
+		return nil, errors.New("unhealthy right now, unable to create order")
+	}
+	if len(plans) == 0 {
+		return nil, nil
+	}
+	symbol := plans[0].Symbol
+	items := make([]map[string]string, len(plans))
+	for i, plan := range plans {
+		if plan.Symbol != symbol {
+			return nil, errors.New("all orders in batch must be of the same symbol")
+		}
+		params, err := buildOrderParams(plan)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = params
+	}
+
+	var results []*BatchOrderResult
+	for from := 0; from < len(items); from += maxBatchOrders {
+		to := minInt(from+maxBatchOrders, len(items))
+		j, err := s.callBatchOrders(http.MethodPost, symbol, items[from:to], "BatchCreateOrders")
+		if err != nil {
+			s.lg.Error("batchCreateOrders failed", "symbol", symbol, "size", to-from, "placed", len(results), "err", err)
+			return results, err
+		}
+		for i := from; i < to; i++ {
+			item := j.GetIndex(i - from)
+			results = append(results, &BatchOrderResult{
+				OrderID:       item.Get("orderId").MustString(),
+				ClientOrderID: plans[i].ClientOrderID,
+				Err:           convertToBatchItemErr(item),
+			})
+		}
+	}
+	s.lg.Warn("batchCreateOrders succeed", "symbol", symbol, "size", len(results))
+	return results, nil
+}
+
+// BatchCancelOrders cancel up to maxBatchOrders orders of one symbol in each call, partial results are returned on error.
+func (s *OrderManager) BatchCancelOrders(symbol Symbol, refs []OrderRef) ([]*BatchOrderResult, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	items := make([]map[string]string, len(refs))
+	for i, ref := range refs {
+		item := map[string]string{}
+		if ref.OrderID != "" {
+			item["orderId"] = ref.OrderID
+		}
+		if ref.ClientOrderID != "" {
+			item["origClientOrderId"] = ref.ClientOrderID
+		}
+		items[i] = item
+	}
+
+	var results []*BatchOrderResult
+	for from := 0; from < len(items); from += maxBatchOrders {
+		to := minInt(from+maxBatchOrders, len(items))
+		j, err := s.callBatchOrders(http.MethodDelete, symbol, items[from:to], "BatchCancelOrders")
+		if err != nil {
+			s.lg.Error("batchCancelOrders failed", "symbol", symbol, "size", to-from, "canceled", len(results), "err", err)
+			return results, err
+		}
+		for i := from; i < to; i++ {
+			item := j.GetIndex(i - from)
+			results = append(results, &BatchOrderResult{
+				OrderID:       refs[i].OrderID,
+				ClientOrderID: refs[i].ClientOrderID,
+				Status:        OrderStatusType(item.Get("status").MustString()),
+				Err:           convertToBatchItemErr(item),
+			})
+		}
+	}
+	s.lg.Debug("batchCancelOrders succeed", "symbol", symbol, "size", len(results))
+	return results, nil
+}
+
+func (s *OrderManager) callBatchOrders(method string, symbol Symbol, items []map[string]string, typ string) (j *simplejson.Json, err error) {
+	start := time.Now()
+	defer func() { uploadMetrics(symbol.BaseAsset, typ, err, start) }()
+
+	batchOrders, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	r := &Request{
+		Method:   method,
+		Endpoint: batchOrderEndpoint,
+		SecType:  SecTypeSigned,
+	}
+	r.SetParam("symbol", getSymbolAlias(symbol))
+	r.SetParam("batchOrders", string(batchOrders))
+	data, err := s.client.CallAPI(context.Background(), r)
+	if err != nil {
+		return nil, err
+	}
+	j, err = simplejson.NewJson(data)
+	if err != nil {
+		return nil, err
+	}
+	if size := len(j.MustArray()); size != len(items) {
+		return nil, fmt.Errorf("unexpected size of batch response: %d, expected: %d", size, len(items))
+	}
+	return j, nil
+}
+
+func convertToBatchItemErr(item *simplejson.Json) error {
+	code := item.Get("code").MustInt()
+	if code == 0 || code == 200 {
+		return nil
+	}
+	return fmt.Errorf("code: %d, msg: %s", code, item.Get("msg").MustString())
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -6,13 +6,13 @@ import (
 )
 
 func init() {
-	if plugin, err := NewMEXCPlugin(); err == nil {
-		general.Register(&general.ExPlugin{
-			ExName:   general.MEXC,
-			Instance: plugin,
-			Ranking:  300,
-		})
-	}
+	general.RegisterFactory(&general.PluginFactory{
+		ExName:  general.MEXC,
+		Ranking: 300,
+		New: func() (general.ExManager, error) {
+			return NewMEXCPlugin()
+		},
+	})
 }
 
 type MEXCPlugin struct {
@@ -20,6 +20,8 @@ type MEXCPlugin struct {
 	marketManager   general.MarketInterface
 	accountManager  general.AccountInterface
 	orderManager    general.OrderInterface
+	tradeManager    general.TradeInterface
+	walletManager   general.WalletInterface
 }
 
 func NewMEXCPlugin() (*MEXCPlugin, error) {
@@ -31,11 +33,14 @@ func NewMEXCPlugin() (*MEXCPlugin, error) {
 	marketManager := newMarketInfoManager()
 	accountManager := newAccountManager()
 	orderManager := NewOrderManager()
+	tradeManager := NewTradeManager()
 	return &MEXCPlugin{
 		baseInfoManager: baseInfoManager,
 		marketManager:   marketManager,
 		accountManager:  accountManager,
 		orderManager:    orderManager,
+		tradeManager:    tradeManager,
+		walletManager:   newWalletManager(),
 	}, nil
 }
 
@@ -58,3 +63,11 @@ func (p *MEXCPlugin) GetAccountManager() general.AccountInterface {
 func (p *MEXCPlugin) GetOrderInterface() general.OrderInterface {
 	return p.orderManager
 }
+
+func (p *MEXCPlugin) GetTradeInterface() general.TradeInterface {
+	return p.tradeManager
+}
+
+func (p *MEXCPlugin) GetWalletInterface() general.WalletInterface {
+	return p.walletManager
+}
@@ -8,6 +8,8 @@ import (
 	. "jasonzhu.com/coin_labor/core/util/http"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
 	"net/http"
+	"sort"
+	"time"
 )
 
 type TradeManager struct {
@@ -26,14 +28,33 @@ func NewTradeManager() *TradeManager {
 }
 
 func (s *TradeManager) ListTradesOfSymbol(symbol Symbol, limit int) (res []*Trade, err error) {
+	return s.ListTrades(symbol, TradeQuery{Limit: limit})
+}
+
+// myTradesRetention only trades of the latest month are kept by MEXC
+const myTradesRetention = 30 * 24 * time.Hour
+
+// ListTrades myTrades of MEXC has no fromId, trades are paged forward by startTime, which is inclusive.
+// Without startTime only the latest page is returned, so a query from the first trade starts from
+// the oldest trade kept.
+func (s *TradeManager) ListTrades(symbol Symbol, query TradeQuery) (res []*Trade, err error) {
 	r := &Request{
 		Method:   http.MethodGet,
 		Endpoint: myTradesEndpoint,
 		SecType:  SecTypeSigned,
 	}
 	r.SetParam("symbol", getSymbolAlias(symbol))
-	if limit > 0 {
-		r.SetParam("limit", limit)
+	if query.StartTime == 0 && query.FromID != "" {
+		query.StartTime = time.Now().Add(-myTradesRetention).UnixMilli()
+	}
+	if query.StartTime > 0 {
+		r.SetParam("startTime", query.StartTime)
+	}
+	if query.EndTime > 0 {
+		r.SetParam("endTime", query.EndTime)
+	}
+	if query.Limit > 0 {
+		r.SetParam("limit", query.Limit)
 	}
 	data, err := s.client.CallAPI(context.Background(), r)
 	if err != nil {
@@ -49,6 +70,7 @@ func (s *TradeManager) ListTradesOfSymbol(symbol Symbol, limit int) (res []*Trad
 		item := j.GetIndex(i)
 		Trades[i] = convertToTrade(item)
 	}
+	sort.SliceStable(Trades, func(i, j int) bool { return Trades[i].Time < Trades[j].Time })
 	return Trades, nil
 }
 
@@ -0,0 +1,273 @@
+package mexc
+
+import (
+	"context"
+	"fmt"
+	"github.com/bitly/go-simplejson"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/core/util/http"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type WalletManager struct {
+	secret *setting.Secret
+	client *Client
+	lg     log.Logger
+}
+
+func newWalletManager() *WalletManager {
+	secret := GetSecretsForExchanger(MEXC)
+	return &WalletManager{
+		secret: secret,
+		lg:     plg.New("s", "Wallet"),
+		client: NewHMACClient(secret, baseAPIMainURL, apiKeyHeader),
+	}
+}
+
+func (s *WalletManager) call(method, endpoint string, params map[string]interface{}) (*simplejson.Json, error) {
+	r := &Request{
+		Method:   method,
+		Endpoint: endpoint,
+		SecType:  SecTypeSigned,
+	}
+	for k, v := range params {
+		r.SetParam(k, v)
+	}
+	data, err := s.client.CallAPI(context.Background(), r)
+	if err != nil {
+		return nil, err
+	}
+	return simplejson.NewJson(data)
+}
+
+// GetNetworks https://mxcdevelop.github.io/apidocs/spot_v3_cn/
+/**
+Response Example:
+[
+  {
+    "coin": "EOS",
+    "name": "EOS",
+    "networkList": [
+      {
+        "coin": "EOS",
+        "depositEnable": true,
+        "minConfirm": 0,
+        "name": "EOS",
+        "network": "EOS",
+        "withdrawEnable": false,
+        "withdrawFee": "0.000100000000000000",
+        "withdrawIntegerMultiple": null,
+        "withdrawMax": "10000.000000000000000000",
+        "withdrawMin": "0.001000000000000000",
+        "sameAddress": false
+      }
+    ]
+  }
+]
+*/
+func (s *WalletManager) GetNetworks(asset Asset) ([]*NetworkInfo, error) {
+	j, err := s.call(http.MethodGet, capitalConfigEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range j.MustArray() {
+		coin := j.GetIndex(i)
+		if Asset(coin.Get("coin").MustString()) != asset {
+			continue
+		}
+		list := coin.Get("networkList")
+		networks := make([]*NetworkInfo, len(list.MustArray()))
+		for k := range networks {
+			item := list.GetIndex(k)
+			networks[k] = &NetworkInfo{
+				Asset:                   asset,
+				Network:                 item.Get("network").MustString(),
+				Name:                    item.Get("name").MustString(),
+				DepositEnable:           item.Get("depositEnable").MustBool(),
+				WithdrawEnable:          item.Get("withdrawEnable").MustBool(),
+				WithdrawFee:             NewDecimalFromStringIgnoreErr(item.Get("withdrawFee").MustString()),
+				WithdrawMin:             NewDecimalFromStringIgnoreErr(item.Get("withdrawMin").MustString()),
+				WithdrawMax:             NewDecimalFromStringIgnoreErr(item.Get("withdrawMax").MustString()),
+				WithdrawIntegerMultiple: NewDecimalFromStringIgnoreErr(item.Get("withdrawIntegerMultiple").MustString()),
+				MinConfirm:              item.Get("minConfirm").MustInt(),
+				NeedMemo:                item.Get("sameAddress").MustBool(),
+			}
+		}
+		return networks, nil
+	}
+	return nil, fmt.Errorf("asset %s not found", asset)
+}
+
+func (s *WalletManager) GetDepositAddress(asset Asset, network string) (*DepositAddress, error) {
+	params := map[string]interface{}{"coin": asset}
+	if network != "" {
+		params["network"] = network
+	}
+	j, err := s.call(http.MethodGet, depositAddressEndpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(j.MustArray()) == 0 {
+		return nil, fmt.Errorf("no deposit address of %s on %s, generate it in MEXC first", asset, network)
+	}
+	item := j.GetIndex(0)
+	return &DepositAddress{
+		Asset:   asset,
+		Network: item.Get("network").MustString(),
+		Address: item.Get("address").MustString(),
+		Tag:     item.Get("memo").MustString(),
+	}, nil
+}
+
+// Withdraw to a whitelisted address in the address book only
+func (s *WalletManager) Withdraw(req WithdrawRequest) (*Withdrawal, error) {
+	address, err := LookupWithdrawAddress(req.AddressName, req.Asset)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := s.GetNetworks(req.Asset)
+	if err != nil {
+		return nil, err
+	}
+	var network *NetworkInfo
+	for _, n := range networks {
+		if n.Network == address.Network {
+			network = n
+		}
+	}
+	if network == nil {
+		return nil, fmt.Errorf("network %s of %s not found", address.Network, req.Asset)
+	}
+	if err := ValidateWithdraw(network, req.Amount); err != nil {
+		return nil, err
+	}
+
+	s.lg.Warn("Withdraw Start", "asset", req.Asset, "amount", req.Amount, "address", address.Name, "network", address.Network)
+	params := map[string]interface{}{
+		"coin":    req.Asset,
+		"network": address.Network,
+		"address": address.Address,
+		"amount":  req.Amount.String(),
+	}
+	if address.Tag != "" {
+		params["memo"] = address.Tag
+	}
+	if req.WithdrawOrderID != "" {
+		params["withdrawOrderId"] = req.WithdrawOrderID
+	}
+	j, err := s.call(http.MethodPost, withdrawEndpoint, params)
+	if err != nil {
+		s.lg.Error("Withdraw Failed", "asset", req.Asset, "amount", req.Amount, "address", address.Name, "err", err)
+		alerting.Notify(err, "Withdraw Failed in MEXC", "asset", req.Asset, "amount", req.Amount)
+		return nil, err
+	}
+	alerting.Info("Withdraw Submitted in MEXC", "asset", req.Asset, "amount", req.Amount, "address", address.Name)
+	return &Withdrawal{
+		ID:              j.Get("id").MustString(),
+		WithdrawOrderID: req.WithdrawOrderID,
+		Asset:           req.Asset,
+		Network:         address.Network,
+		Address:         address.Address,
+		Tag:             address.Tag,
+		Amount:          req.Amount,
+		Fee:             network.WithdrawFee,
+		Status:          TransferStatusPending,
+		ApplyTime:       time.Now().UnixMilli(),
+	}, nil
+}
+
+func (s *WalletManager) ListWithdrawals(asset Asset, startTime, endTime int64) ([]*Withdrawal, error) {
+	j, err := s.call(http.MethodGet, withdrawHistoryEndpoint, historyParams(asset, startTime, endTime))
+	if err != nil {
+		return nil, err
+	}
+	withdrawals := make([]*Withdrawal, len(j.MustArray()))
+	for i := range withdrawals {
+		item := j.GetIndex(i)
+		withdrawals[i] = &Withdrawal{
+			ID:              item.Get("id").MustString(),
+			WithdrawOrderID: item.Get("withdrawOrderId").MustString(),
+			Asset:           Asset(item.Get("coin").MustString()),
+			Network:         item.Get("network").MustString(),
+			Address:         item.Get("address").MustString(),
+			Tag:             item.Get("memo").MustString(),
+			Amount:          NewDecimalFromStringIgnoreErr(item.Get("amount").MustString()),
+			Fee:             NewDecimalFromStringIgnoreErr(item.Get("transactionFee").MustString()),
+			TxID:            item.Get("txId").MustString(),
+			Status:          convertToWithdrawStatus(item.Get("status").MustInt()),
+			ApplyTime:       item.Get("applyTime").MustInt64(),
+		}
+	}
+	return withdrawals, nil
+}
+
+func (s *WalletManager) ListDeposits(asset Asset, startTime, endTime int64) ([]*Deposit, error) {
+	j, err := s.call(http.MethodGet, depositHistoryEndpoint, historyParams(asset, startTime, endTime))
+	if err != nil {
+		return nil, err
+	}
+	deposits := make([]*Deposit, len(j.MustArray()))
+	for i := range deposits {
+		item := j.GetIndex(i)
+		deposits[i] = &Deposit{
+			Asset:      Asset(item.Get("coin").MustString()),
+			Network:    item.Get("network").MustString(),
+			Address:    item.Get("address").MustString(),
+			Tag:        item.Get("addressTag").MustString(),
+			Amount:     NewDecimalFromStringIgnoreErr(item.Get("amount").MustString()),
+			TxID:       item.Get("txId").MustString(),
+			Status:     convertToDepositStatus(item.Get("status").MustInt()),
+			InsertTime: item.Get("insertTime").MustInt64(),
+		}
+	}
+	return deposits, nil
+}
+
+func historyParams(asset Asset, startTime, endTime int64) map[string]interface{} {
+	params := map[string]interface{}{}
+	if asset != "" {
+		params["coin"] = asset
+	}
+	if startTime > 0 {
+		params["startTime"] = startTime
+	}
+	if endTime > 0 {
+		params["endTime"] = endTime
+	}
+	return params
+}
+
+// convertToWithdrawStatus 1:APPLY, 2:AUDITING, 3:WAIT, 4:PROCESSING, 5:WAIT_PACKAGING, 6:WAIT_CONFIRM, 7:SUCCESS, 8:FAILED, 9:CANCEL, 10:MANUAL
+func convertToWithdrawStatus(status int) TransferStatus {
+	switch status {
+	case 1, 2, 3, 10:
+		return TransferStatusPending
+	case 4, 5, 6:
+		return TransferStatusProcessing
+	case 7:
+		return TransferStatusSuccess
+	case 8:
+		return TransferStatusFailed
+	case 9:
+		return TransferStatusCanceled
+	}
+	return TransferStatus(strconv.Itoa(status))
+}
+
+// convertToDepositStatus 1:SMALL, 2:TIME_DELAY, 3:LARGE_DELAY, 4:PENDING, 5:SUCCESS, 6:AUDITING, 7:REJECTED
+func convertToDepositStatus(status int) TransferStatus {
+	switch status {
+	case 2, 3, 4, 6:
+		return TransferStatusPending
+	case 5:
+		return TransferStatusSuccess
+	case 1, 7:
+		return TransferStatusFailed
+	}
+	return TransferStatus(strconv.Itoa(status))
+}
@@ -5,7 +5,6 @@ import (
 	"golang.org/x/sync/errgroup"
 	"jasonzhu.com/coin_labor/core/components/bus"
 	"jasonzhu.com/coin_labor/core/components/log"
-	_ "jasonzhu.com/coin_labor/pkg/plugins/binance"
 	. "jasonzhu.com/coin_labor/pkg/plugins/general"
 	"time"
 )
@@ -27,6 +26,9 @@ type MonitorService struct {
 	Bus bus.Bus `inject:""`
 
 	stopped bool
+
+	// symbols halted or delisted on either exchange are left out until they resume trading
+	halted *HaltedSymbols
 }
 
 var watchingAssets = []Asset{
@@ -51,6 +53,8 @@ var watchingAssets = []Asset{
 func (s *MonitorService) Init() error {
 	s.lg = log.New("service.monitor")
 	s.stopped = false
+	s.halted = NewHaltedSymbols()
+	s.halted.Listen(s.Bus)
 	return nil
 }
 
@@ -76,6 +80,9 @@ func (s *MonitorService) Run(ctx context.Context) (err error) {
 					BaseAsset:  asset,
 					QuoteAsset: DefaultQuoteCoin,
 				}
+				if s.halted.IsHalted(symbol, MEXC, Binance) {
+					continue
+				}
 				group.Go(func() error {
 					depth, err := mexcMarket.FetchDepth(symbol, 5)
 					if err != nil {
@@ -0,0 +1,117 @@
+package plugins
+
+import (
+	"fmt"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sort"
+	"strings"
+	"time"
+)
+
+const OperatorServiceName = "OperatorService"
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         OperatorServiceName,
+		Instance:     &OperatorService{},
+		InitPriority: registry.High,
+	})
+}
+
+// OperatorService pauses and resumes trading for operators, and serves the status command
+type OperatorService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+}
+
+func (s *OperatorService) Init() error {
+	s.lg = log.New("service.operator")
+	s.Bus.AddHandler(s.onStatusCommand)
+	s.Bus.AddHandler(s.onPauseCommand)
+	s.Bus.AddHandler(s.onResumeCommand)
+	return nil
+}
+
+// PauseTrading returns false if paused already, TradingPaused is published otherwise
+func (s *OperatorService) PauseTrading(reason string) bool {
+	if !DefaultTradingSwitch.Pause(reason) {
+		return false
+	}
+	s.lg.Warn("trading paused", "reason", reason)
+	alerting.NotifyRightNow(nil, "trading paused", "reason", reason)
+	if err := s.Bus.Publish(&TradingPaused{Reason: reason, Time: time.Now()}); err != nil {
+		s.lg.Warn("failed to publish trading paused", "err", err)
+	}
+	return true
+}
+
+// ResumeTrading returns false if not paused, TradingResumed is published otherwise
+func (s *OperatorService) ResumeTrading() bool {
+	if !DefaultTradingSwitch.Resume() {
+		return false
+	}
+	s.lg.Warn("trading resumed")
+	alerting.NotifyRightNow(nil, "trading resumed")
+	if err := s.Bus.Publish(&TradingResumed{Time: time.Now()}); err != nil {
+		s.lg.Warn("failed to publish trading resumed", "err", err)
+	}
+	return true
+}
+
+func tradingStateNote(state TradingState) string {
+	if !state.Paused {
+		return "trading: running"
+	}
+	return fmt.Sprintf("trading: PAUSED since %s (%s)", state.Since.Format(time.RFC3339), state.Reason)
+}
+
+func (s *OperatorService) onStatusCommand(cmd *alerting.StatusCommand) error {
+	cmd.Reply.Title = "Status"
+	cmd.Reply.Header = []string{"FEATURE", "STATE"}
+	states := DefaultHealthChecker.States()
+	features := make([]ExchangeFeature, 0, len(states))
+	for feature := range states {
+		features = append(features, feature)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i] < features[j] })
+	for _, feature := range features {
+		state := "unhealthy"
+		if states[feature] == HealthStateHealthy {
+			state = "healthy"
+		}
+		cmd.Reply.AddRow(feature.String(), state)
+	}
+
+	var disabled []string
+	for _, descriptor := range registry.GetServices() {
+		if registry.IsDisabled(descriptor.Instance) {
+			disabled = append(disabled, descriptor.Name)
+		}
+	}
+	sort.Strings(disabled)
+	cmd.Reply.Note = tradingStateNote(DefaultTradingSwitch.State()) +
+		fmt.Sprintf("\nservices: %d, disabled: %s", len(registry.GetServices()), strings.Join(disabled, ", "))
+	return nil
+}
+
+func (s *OperatorService) onPauseCommand(cmd *alerting.PauseCommand) error {
+	cmd.Reply.Title = "Pause"
+	if !s.PauseTrading(cmd.Reason) {
+		cmd.Reply.Title = "Pause: paused already"
+	}
+	cmd.Reply.Note = tradingStateNote(DefaultTradingSwitch.State())
+	return nil
+}
+
+func (s *OperatorService) onResumeCommand(cmd *alerting.ResumeCommand) error {
+	cmd.Reply.Title = "Resume"
+	if !s.ResumeTrading() {
+		cmd.Reply.Title = "Resume: not paused"
+	}
+	cmd.Reply.Note = tradingStateNote(DefaultTradingSwitch.State())
+	return nil
+}
@@ -0,0 +1,386 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sort"
+	"time"
+)
+
+const (
+	OrderTrackerServiceName = "OrderTrackerService"
+
+	userDataRetryInterval = 5 * time.Second
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         OrderTrackerServiceName,
+		Instance:     &OrderTrackerService{},
+		InitPriority: registry.High,
+	})
+}
+
+// OrderTrackerService tracks orders submitted through it by ClientOrderID,
+// status is advanced by user data events of every plugin, and polled by REST API when events are late.
+// Strategies inject it and use Submit and WaitFor.
+type OrderTrackerService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	tracker      *OrderTracker
+	ocoTracker   *OCOTracker
+	pollInterval time.Duration
+	retention    time.Duration
+}
+
+func (s *OrderTrackerService) Init() error {
+	s.lg = log.New("service.order_tracker")
+	s.tracker = NewOrderTracker()
+	s.ocoTracker = NewOCOTracker()
+	s.pollInterval = setting.OrderTrackerPollInterval
+	s.retention = setting.OrderTrackerRetention
+	s.Bus.AddEventListener(s.onOpenOrderAdopted)
+	s.Bus.AddHandler(s.onOrdersCommand)
+	s.Bus.AddHandler(s.onCancelAllCommand)
+	return nil
+}
+
+// Submit tracks the plan and creates the order, it is refused when trading is paused
+func (s *OrderTrackerService) Submit(exchange Exchange, plan OrderPlan) (*TrackedOrder, error) {
+	if err := DefaultTradingSwitch.Check(); err != nil {
+		return nil, err
+	}
+	plugin := GetExPluginByExchange(exchange)
+	if plugin == nil {
+		return nil, fmt.Errorf("exchange %s is not supported", exchange)
+	}
+	order := s.tracker.Track(exchange, plan)
+	res, err := plugin.GetOrderInterface().CreateOrder(plan)
+	OrderSubmissions.Publish(NewOrderSubmitted(exchange, plan, order.TrackTime(), res, err))
+	if err != nil {
+		s.tracker.OnCreateFailed(plan.ClientOrderID, err)
+		return order, err
+	}
+	s.tracker.OnCreated(plan.ClientOrderID, res)
+	return order, nil
+}
+
+// SubmitFutures tracks the plan and creates the order with position side and reduce-only
+func (s *OrderTrackerService) SubmitFutures(exchange Exchange, plan FuturesOrderPlan) (*TrackedOrder, error) {
+	if err := DefaultTradingSwitch.Check(); err != nil {
+		return nil, err
+	}
+	plugin := GetExPluginByExchange(exchange)
+	if plugin == nil {
+		return nil, fmt.Errorf("exchange %s is not supported", exchange)
+	}
+	derivatives, err := GetDerivativesInterface(plugin)
+	if err != nil {
+		return nil, err
+	}
+	order := s.tracker.Track(exchange, plan.OrderPlan)
+	res, err := derivatives.CreateFuturesOrder(plan)
+	submitted := NewOrderSubmitted(exchange, plan.OrderPlan, order.TrackTime(), res, err)
+	submitted.PositionSide, submitted.ReduceOnly = plan.PositionSide, plan.ReduceOnly
+	OrderSubmissions.Publish(submitted)
+	if err != nil {
+		s.tracker.OnCreateFailed(plan.ClientOrderID, err)
+		return order, err
+	}
+	s.tracker.OnCreated(plan.ClientOrderID, res)
+	return order, nil
+}
+
+// SubmitOCO tracks both legs and creates the order list, e.g. take-profit and stop-loss bracket of a position
+func (s *OrderTrackerService) SubmitOCO(exchange Exchange, plan OCOOrderPlan) (*OrderList, error) {
+	if err := DefaultTradingSwitch.Check(); err != nil {
+		return nil, err
+	}
+	plugin := GetExPluginByExchange(exchange)
+	if plugin == nil {
+		return nil, fmt.Errorf("exchange %s is not supported", exchange)
+	}
+	oco, err := GetOCOOrderInterface(plugin.GetOrderInterface())
+	if err != nil {
+		return nil, err
+	}
+	legs := plan.Legs()
+	trackTimes := make(map[string]time.Time)
+	for _, leg := range legs {
+		trackTimes[leg.ClientOrderID] = s.tracker.Track(exchange, leg).TrackTime()
+	}
+	list, err := oco.CreateOCOOrder(plan)
+	if err != nil {
+		for _, leg := range legs {
+			submitted := NewOrderSubmitted(exchange, leg, trackTimes[leg.ClientOrderID], nil, err)
+			submitted.ListClientOrderID = plan.ListClientOrderID
+			OrderSubmissions.Publish(submitted)
+			s.tracker.OnCreateFailed(leg.ClientOrderID, err)
+		}
+		return nil, err
+	}
+	orderIDs := make(map[string]string)
+	for _, order := range list.Orders {
+		orderIDs[order.ClientOrderID] = order.OrderID
+		s.tracker.OnCreated(order.ClientOrderID, &CreateOrderResponse{OrderID: order.OrderID, ClientOrderID: order.ClientOrderID})
+	}
+	for _, leg := range legs {
+		submitted := NewOrderSubmitted(exchange, leg, trackTimes[leg.ClientOrderID],
+			&CreateOrderResponse{OrderID: orderIDs[leg.ClientOrderID]}, nil)
+		submitted.ListClientOrderID = list.ListClientOrderID
+		OrderSubmissions.Publish(submitted)
+	}
+	s.ocoTracker.Update(list)
+	return list, nil
+}
+
+// Cancel cancels the tracked order and polls it, the quantity filled is final once it returns without error
+func (s *OrderTrackerService) Cancel(clientOrderID string) (OrderState, error) {
+	order, ok := s.tracker.Get(clientOrderID)
+	if !ok {
+		return OrderState{}, fmt.Errorf("%w: %s", ErrOrderNotTracked, clientOrderID)
+	}
+	state := order.State()
+	plugin := GetExPluginByExchange(state.Exchange)
+	if plugin == nil {
+		return state, fmt.Errorf("exchange %s is not supported", state.Exchange)
+	}
+	orderInterface := plugin.GetOrderInterface()
+	if !IsFinalStatus(state.Status) {
+		// it may be filled in the meantime, which is polled below
+		if _, err := orderInterface.CancelOrder(state.Plan.Symbol, state.OrderID, clientOrderID); err != nil {
+			s.lg.Warn("failed to cancel order", "exchange", state.Exchange, "clientOrderID", clientOrderID, "err", err)
+		}
+	}
+	res, err := orderInterface.GetOrder(state.Plan.Symbol, state.OrderID, clientOrderID)
+	if err != nil {
+		return order.State(), err
+	}
+	if s.tracker.UpdateFromOrder(res) {
+		s.publishUpdate(clientOrderID, OrderUpdateSourcePoll)
+	}
+	return order.State(), nil
+}
+
+// CancelOCO cancels the whole order list
+func (s *OrderTrackerService) CancelOCO(exchange Exchange, symbol Symbol, orderListId int64) (*OrderList, error) {
+	plugin := GetExPluginByExchange(exchange)
+	if plugin == nil {
+		return nil, fmt.Errorf("exchange %s is not supported", exchange)
+	}
+	oco, err := GetOCOOrderInterface(plugin.GetOrderInterface())
+	if err != nil {
+		return nil, err
+	}
+	list, err := oco.CancelOCOOrder(symbol, orderListId, "")
+	if err != nil {
+		return nil, err
+	}
+	s.ocoTracker.Update(list)
+	return list, nil
+}
+
+// GetOCO returns the latest status of the order list
+func (s *OrderTrackerService) GetOCO(orderListId int64) (OrderList, bool) {
+	return s.ocoTracker.Get(orderListId)
+}
+
+// Track records a plan created by caller itself
+func (s *OrderTrackerService) Track(exchange Exchange, plan OrderPlan) *TrackedOrder {
+	return s.tracker.Track(exchange, plan)
+}
+
+func (s *OrderTrackerService) Get(clientOrderID string) (*TrackedOrder, bool) {
+	return s.tracker.Get(clientOrderID)
+}
+
+// WaitFor blocks until the order reaches status
+func (s *OrderTrackerService) WaitFor(clientOrderID string, status OrderStatusType, timeout time.Duration) error {
+	order, ok := s.tracker.Get(clientOrderID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrOrderNotTracked, clientOrderID)
+	}
+	return order.WaitFor(status, timeout)
+}
+
+func (s *OrderTrackerService) onOpenOrderAdopted(event *OpenOrderAdopted) error {
+	s.tracker.Adopt(event.Exchange, event.Symbol, event.Order)
+	s.lg.Info("open order adopted", "exchange", event.Exchange, "symbol", event.Symbol, "clientOrderID", event.Order.ClientOrderID)
+	return nil
+}
+
+func (s *OrderTrackerService) Run(ctx context.Context) error {
+	for _, plugin := range GetExPlugins() {
+		go s.watchUserData(ctx, plugin)
+	}
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.poll()
+			before := time.Now().Add(-s.retention)
+			if pruned := s.tracker.Prune(before) + s.ocoTracker.Prune(before); pruned > 0 {
+				s.lg.Debug("finished orders pruned", "size", pruned)
+			}
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+func (s *OrderTrackerService) watchUserData(ctx context.Context, plugin *ExPlugin) {
+	eventC := make(chan *UserDataEvent, 100)
+	go func() {
+		for {
+			if err := plugin.Instance.GetAccountManager().WsWatchUserDataChanges(ctx, eventC); err != nil {
+				s.lg.Error("failed to watch user data", "exchange", plugin.ExName, "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(userDataRetryInterval):
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event := <-eventC:
+			switch event.Event {
+			case UserDataEventTypeExecutionReport:
+				if s.tracker.UpdateFromWs(event.OrderUpdate) {
+					s.lg.Debug("order updated", "exchange", plugin.ExName, "clientOrderID", event.OrderUpdate.ClientOrderId,
+						"status", event.OrderUpdate.Status)
+					s.publishUpdate(event.OrderUpdate.ClientOrderId, OrderUpdateSourceWs)
+				}
+			case UserDataEventTypeListStatus:
+				if s.ocoTracker.UpdateFromWs(event.OCOUpdate) {
+					s.lg.Info("order list updated", "exchange", plugin.ExName, "orderListId", event.OCOUpdate.OrderListId,
+						"listStatus", event.OCOUpdate.ListStatusType, "listOrderStatus", event.OCOUpdate.ListOrderStatus)
+				}
+			case UserDataEventTypeOutboundAccountPosition, UserDataEventTypeBalanceUpdate:
+				BalanceUpdates.Publish(newBalanceUpdated(plugin.ExName, event))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll orders without user data events for pollInterval by REST API,
+// orders whose CreateOrder failed with unknown send status are resolved here by ClientOrderID
+func (s *OrderTrackerService) poll() {
+	for _, order := range s.tracker.Stale(time.Now().Add(-s.pollInterval)) {
+		state := order.State()
+		plugin := GetExPluginByExchange(state.Exchange)
+		if plugin == nil {
+			continue
+		}
+		order.MarkChecked()
+		res, err := plugin.GetOrderInterface().GetOrder(state.Plan.Symbol, state.OrderID, state.Plan.ClientOrderID)
+		if errors.Is(err, ErrOrderNotFound) && s.tracker.OnNotFound(state.Plan.ClientOrderID) {
+			s.lg.Warn("order of unknown send status not found, rejected", "exchange", state.Exchange,
+				"clientOrderID", state.Plan.ClientOrderID)
+			s.publishUpdate(state.Plan.ClientOrderID, OrderUpdateSourcePoll)
+			continue
+		}
+		if err != nil {
+			s.lg.Warn("failed to poll order", "exchange", state.Exchange, "clientOrderID", state.Plan.ClientOrderID, "err", err)
+			continue
+		}
+		if s.tracker.UpdateFromOrder(res) {
+			s.lg.Info("order updated by polling", "exchange", state.Exchange, "clientOrderID", state.Plan.ClientOrderID,
+				"status", res.Status)
+			s.publishUpdate(state.Plan.ClientOrderID, OrderUpdateSourcePoll)
+		}
+	}
+}
+
+func (s *OrderTrackerService) publishUpdate(clientOrderID string, source string) {
+	if order, ok := s.tracker.Get(clientOrderID); ok {
+		OrderUpdates.Publish(&OrderUpdated{State: order.State(), Source: source})
+	}
+}
+
+func newBalanceUpdated(exchange Exchange, event *UserDataEvent) *BalanceUpdated {
+	updated := &BalanceUpdated{Exchange: exchange, Event: event.Event, Time: time.Now()}
+	if event.Time > 0 {
+		updated.Time = time.UnixMilli(int64(event.Time))
+	}
+	if event.Event == UserDataEventTypeBalanceUpdate {
+		delta := event.BalanceUpdate
+		updated.Delta = &delta
+	} else {
+		updated.Balances = event.AccountUpdate.WsAccountUpdates
+	}
+	return updated
+}
+
+func (s *OrderTrackerService) onOrdersCommand(cmd *alerting.OrdersCommand) error {
+	var states []OrderState
+	for _, order := range s.tracker.Open() {
+		states = append(states, order.State())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].UpdateTime.Before(states[j].UpdateTime) })
+
+	cmd.Reply.Title = fmt.Sprintf("Open orders: %d", len(states))
+	cmd.Reply.Header = []string{"EXCHANGE", "SYMBOL", "SIDE", "STATUS", "PRICE", "QTY", "FILLED"}
+	for _, state := range states {
+		price, quantity := "-", "-"
+		if state.Plan.Price != nil {
+			price = state.Plan.Price.String()
+		}
+		if state.Plan.Quantity != nil {
+			quantity = state.Plan.Quantity.String()
+		}
+		cmd.Reply.AddRow(string(state.Exchange), state.Plan.Symbol.String(), string(state.Plan.Side),
+			string(state.Status), price, quantity, state.FilledQuantity.String())
+	}
+	return nil
+}
+
+// onCancelAllCommand cancels open orders of every watched symbol of the exchange, including those not tracked
+func (s *OrderTrackerService) onCancelAllCommand(cmd *alerting.CancelAllCommand) error {
+	plugin := GetExPluginByExchange(Exchange(cmd.Exchange))
+	if plugin == nil {
+		return fmt.Errorf("exchange %s is not supported", cmd.Exchange)
+	}
+	cmd.Reply.Header = []string{"SYMBOL", "ORDER", "RESULT"}
+	canceled, failed := 0, 0
+	for symbol := range plugin.GetBaseInfoManager().GetSymbolsBasicInfo() {
+		orders, err := plugin.GetOrderInterface().ListOpenOrdersOfSymbol(symbol)
+		if err != nil {
+			cmd.Reply.AddRow(symbol.String(), "-", err.Error())
+			failed++
+			continue
+		}
+		for _, order := range orders {
+			status, err := plugin.GetOrderInterface().CancelOrder(symbol, order.OrderID, order.ClientOrderID)
+			if err != nil {
+				cmd.Reply.AddRow(symbol.String(), order.ClientOrderID, err.Error())
+				failed++
+				continue
+			}
+			order.Status = status
+			if s.tracker.UpdateFromOrder(order) {
+				s.publishUpdate(order.ClientOrderID, OrderUpdateSourceOperator)
+			}
+			cmd.Reply.AddRow(symbol.String(), order.ClientOrderID, string(status))
+			canceled++
+		}
+	}
+	s.lg.Warn("open orders canceled by operator", "exchange", cmd.Exchange, "canceled", canceled, "failed", failed)
+	cmd.Reply.Title = fmt.Sprintf("Cancel all on %s: %d canceled, %d failed", cmd.Exchange, canceled, failed)
+	return nil
+}
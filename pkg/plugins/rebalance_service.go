@@ -0,0 +1,360 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sort"
+	"time"
+)
+
+const (
+	RebalanceServiceName = "RebalanceService"
+
+	rebalanceDepthLimit     = 5
+	rebalanceOrderTimeout   = 30 * time.Second
+	rebalanceConvertPoll    = time.Second
+	rebalanceConvertTimeout = 30 * time.Second
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         RebalanceServiceName,
+		Instance:     &RebalanceService{},
+		InitPriority: registry.Low,
+	})
+}
+
+// RebalanceService compares balances of every exchange with the target allocation of each asset,
+// and fixes the drift by the cheapest of transfer, trade and convert, within limits of cost and frequency.
+type RebalanceService struct {
+	lg        log.Logger
+	Bus       bus.Bus              `inject:""`
+	Inventory *InventoryService    `inject:""`
+	Transfers *TransferService     `inject:""`
+	Orders    *OrderTrackerService `inject:""`
+
+	// owned by the Run loop, replaced by configC on reload
+	rebalanceConfig
+	configC     chan *rebalanceConfig
+	dryRun      bool
+	actionTimes []time.Time
+}
+
+// rebalanceConfig settings applied without restart
+type rebalanceConfig struct {
+	targets          []*RebalanceTarget
+	tolerance        decimal.Decimal
+	maxCostRatio     decimal.Decimal
+	takerFee         decimal.Decimal
+	tradeQuote       Asset
+	maxActionsPerDay int
+	interval         time.Duration
+}
+
+func newRebalanceConfig() (*rebalanceConfig, error) {
+	cfg := &rebalanceConfig{
+		tolerance:        decimal.NewFromFloat(setting.RebalanceTolerance),
+		maxCostRatio:     decimal.NewFromFloat(setting.RebalanceMaxCostRatio),
+		takerFee:         decimal.NewFromFloat(setting.RebalanceTakerFee),
+		tradeQuote:       Asset(setting.RebalanceTradeQuote),
+		maxActionsPerDay: setting.RebalanceMaxActionsPerDay,
+		interval:         setting.RebalanceInterval,
+	}
+	for asset, spec := range setting.RebalanceTargets {
+		target, err := ParseRebalanceTarget(Asset(asset), spec)
+		if err != nil {
+			return nil, err
+		}
+		for exchange := range target.Weights {
+			if GetExPluginByExchange(exchange) == nil {
+				return nil, fmt.Errorf("exchange %s in target of %s is not supported", exchange, asset)
+			}
+		}
+		cfg.targets = append(cfg.targets, target)
+	}
+	sort.Slice(cfg.targets, func(i, j int) bool { return cfg.targets[i].Asset < cfg.targets[j].Asset })
+	return cfg, nil
+}
+
+func (s *RebalanceService) Init() error {
+	s.lg = log.New("service.rebalance")
+	cfg, err := newRebalanceConfig()
+	if err != nil {
+		return err
+	}
+	s.rebalanceConfig = *cfg
+	s.configC = make(chan *rebalanceConfig, 1)
+	s.dryRun = setting.RebalanceDryRun
+	s.Bus.AddEventListener(s.onConfigChanged)
+	s.lg.Info("rebalance targets loaded", "size", len(s.targets), "dryRun", s.dryRun)
+	return nil
+}
+
+func (s *RebalanceService) IsDisabled() bool {
+	return !setting.RebalanceEnabled
+}
+
+func (s *RebalanceService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.rebalanceAll()
+		case cfg := <-s.configC:
+			s.rebalanceConfig = *cfg
+			ticker.Reset(s.interval)
+			s.lg.Info("rebalance config reloaded", "targets", len(s.targets), "interval", s.interval)
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+// onConfigChanged an invalid config is rejected, the loaded one is kept
+func (s *RebalanceService) onConfigChanged(event *setting.ConfigChanged) error {
+	if !event.SectionChanged("rebalancer") {
+		return nil
+	}
+	cfg, err := newRebalanceConfig()
+	if err != nil {
+		s.lg.Error("rebalance config rejected", "err", err)
+		alerting.Notify(err, "rebalance config rejected, the loaded one is kept")
+		return nil
+	}
+	// a config not applied yet is replaced
+	select {
+	case <-s.configC:
+	default:
+	}
+	s.configC <- cfg
+	return nil
+}
+
+func (s *RebalanceService) rebalanceAll() {
+	inventory := s.Inventory.Inventory()
+	for _, target := range s.targets {
+		// balances are inaccurate until funds in transit land
+		if inventory.InTransit(target.Asset).IsPositive() {
+			s.lg.Debug("skip asset in transit", "asset", target.Asset)
+			continue
+		}
+		balances := make(map[Exchange]decimal.Decimal)
+		for exchange := range target.Weights {
+			balances[exchange] = inventory.Tradable(exchange, target.Asset)
+		}
+		for _, imbalance := range FindImbalances(target, balances, s.tolerance) {
+			if !s.allowAction(time.Now()) {
+				s.lg.Warn("max rebalance actions per day reached", "asset", imbalance.Asset)
+				return
+			}
+			s.rebalance(imbalance)
+		}
+	}
+}
+
+// allowAction limits actions in the last 24 hours, dry runs included
+func (s *RebalanceService) allowAction(now time.Time) bool {
+	recent := s.actionTimes[:0]
+	for _, t := range s.actionTimes {
+		if now.Sub(t) < 24*time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	s.actionTimes = recent
+	return len(s.actionTimes) < s.maxActionsPerDay
+}
+
+func (s *RebalanceService) rebalance(imbalance Imbalance) {
+	lg := s.lg.New("asset", imbalance.Asset, "from", imbalance.From, "to", imbalance.To, "amount", imbalance.Amount)
+	action, err := CheapestRebalanceAction(s.candidates(lg, imbalance), s.maxCostRatio)
+	if err != nil {
+		lg.Warn("imbalance can not be fixed", "err", err)
+		return
+	}
+	s.actionTimes = append(s.actionTimes, time.Now())
+	lg.Info("rebalance action planned", "type", action.Type, "symbol", action.Symbol, "cost", action.Cost,
+		"costRatio", action.CostRatio())
+	if s.dryRun {
+		return
+	}
+
+	switch action.Type {
+	case RebalanceActionTransfer:
+		_, err = s.Transfers.Start(imbalance.Asset, imbalance.Amount, imbalance.From, imbalance.To)
+	case RebalanceActionTrade:
+		err = s.trade(action)
+	case RebalanceActionConvert:
+		err = s.convert(action)
+	}
+	if err != nil {
+		alerting.Notify(err, "rebalance action failed", "type", action.Type, "asset", imbalance.Asset,
+			"from", imbalance.From, "to", imbalance.To, "amount", imbalance.Amount)
+		return
+	}
+	lg.Info("rebalance action done", "type", action.Type)
+}
+
+// candidates every action possible for the imbalance, with cost estimated
+func (s *RebalanceService) candidates(lg log.Logger, imbalance Imbalance) []*RebalanceAction {
+	var actions []*RebalanceAction
+	if action, err := s.transferAction(imbalance); err != nil {
+		lg.Debug("transfer is not possible", "err", err)
+	} else {
+		actions = append(actions, action)
+	}
+
+	for _, symbol := range s.tradeSymbols(imbalance.Asset) {
+		from := GetExPluginByExchange(imbalance.From).GetMarketInfoManager()
+		to := GetExPluginByExchange(imbalance.To).GetMarketInfoManager()
+		fromDepth, err := from.FetchDepth(symbol, rebalanceDepthLimit)
+		if err != nil {
+			lg.Debug("failed to fetch depth", "exchange", imbalance.From, "symbol", symbol, "err", err)
+			continue
+		}
+		toDepth, err := to.FetchDepth(symbol, rebalanceDepthLimit)
+		if err != nil {
+			lg.Debug("failed to fetch depth", "exchange", imbalance.To, "symbol", symbol, "err", err)
+			continue
+		}
+		if action, err := TradeAction(imbalance, fromDepth, toDepth, s.takerFee); err == nil {
+			actions = append(actions, action)
+		}
+
+		convertInterface, err := GetConvertInterface(GetExPluginByExchange(imbalance.To))
+		if err != nil {
+			continue
+		}
+		quote, err := convertInterface.GetQuote(counterAsset(symbol, imbalance.Asset), imbalance.Asset, decimal.Zero, imbalance.Amount)
+		if err != nil {
+			lg.Debug("failed to get convert quote", "symbol", symbol, "err", err)
+			continue
+		}
+		if action, err := ConvertAction(imbalance, fromDepth, toDepth, quote, s.takerFee); err == nil {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+func (s *RebalanceService) transferAction(imbalance Imbalance) (*RebalanceAction, error) {
+	address, err := FindWithdrawAddress(imbalance.To, imbalance.Asset)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := GetExPluginByExchange(imbalance.From).GetWalletInterface().GetNetworks(imbalance.Asset)
+	if err != nil {
+		return nil, err
+	}
+	for _, network := range networks {
+		if network.Network == address.Network {
+			return TransferAction(imbalance, network)
+		}
+	}
+	return nil, fmt.Errorf("network %s of %s is not supported by %s", address.Network, imbalance.Asset, imbalance.From)
+}
+
+// tradeSymbols the asset is traded against the trade quote, the quote itself is traded against every other target
+func (s *RebalanceService) tradeSymbols(asset Asset) []Symbol {
+	if asset != s.tradeQuote {
+		return []Symbol{{BaseAsset: asset, QuoteAsset: s.tradeQuote}}
+	}
+	var symbols []Symbol
+	for _, target := range s.targets {
+		if target.Asset != s.tradeQuote {
+			symbols = append(symbols, Symbol{BaseAsset: target.Asset, QuoteAsset: s.tradeQuote})
+		}
+	}
+	return symbols
+}
+
+func counterAsset(symbol Symbol, asset Asset) Asset {
+	if symbol.BaseAsset == asset {
+		return symbol.QuoteAsset
+	}
+	return symbol.BaseAsset
+}
+
+// trade releases the asset on the exchange with surplus, then acquires it on the one with deficit
+func (s *RebalanceService) trade(action *RebalanceAction) error {
+	imbalance := action.Imbalance
+	if err := s.marketOrder(imbalance.From, action.Symbol, imbalance.Asset, imbalance.Amount, false); err != nil {
+		return err
+	}
+	return s.marketOrder(imbalance.To, action.Symbol, imbalance.Asset, imbalance.Amount, true)
+}
+
+// convert releases the asset on the exchange with surplus, then converts it back on the one with deficit
+func (s *RebalanceService) convert(action *RebalanceAction) error {
+	imbalance := action.Imbalance
+	if err := s.marketOrder(imbalance.From, action.Symbol, imbalance.Asset, imbalance.Amount, false); err != nil {
+		return err
+	}
+	convertInterface, err := GetConvertInterface(GetExPluginByExchange(imbalance.To))
+	if err != nil {
+		return err
+	}
+	quote, err := convertInterface.GetQuote(counterAsset(action.Symbol, imbalance.Asset), imbalance.Asset, decimal.Zero, imbalance.Amount)
+	if err != nil {
+		return err
+	}
+	order, err := Convert(convertInterface, quote, rebalanceConvertPoll, rebalanceConvertTimeout)
+	if err != nil {
+		return err
+	}
+	if order.Status != ConvertOrderStatusSuccess {
+		return fmt.Errorf("convert order %s is %s", order.OrderID, order.Status)
+	}
+	return nil
+}
+
+// marketOrder acquires or releases amount of asset on the exchange, and waits until filled
+func (s *RebalanceService) marketOrder(exchange Exchange, symbol Symbol, asset Asset, amount decimal.Decimal, acquire bool) error {
+	plugin := GetExPluginByExchange(exchange)
+	info, err := plugin.GetBaseInfoManager().GetSymbolBasicInfo(symbol)
+	if err != nil {
+		return err
+	}
+	depth, err := plugin.GetMarketInfoManager().FetchDepth(symbol, rebalanceDepthLimit)
+	if err != nil {
+		return err
+	}
+	mid, _, err := MidPrice(depth)
+	if err != nil {
+		return err
+	}
+
+	var plan *OrderPlan
+	if asset == symbol.BaseAsset {
+		side := SideTypeSell
+		if acquire {
+			side = SideTypeBuy
+		}
+		plan = NewMarketOrder(symbol, side, mid, amount.Truncate(info.StepSizePrecision))
+	} else {
+		// the quote asset is acquired by selling base, and released by buying base
+		side := SideTypeBuy
+		if acquire {
+			side = SideTypeSell
+		}
+		if SupportsQuoteOrderQty(plugin.GetOrderInterface()) {
+			plan = NewMarketOrderWithQuoteQty(symbol, side, mid, amount.Truncate(info.QuoteAssetPrecision))
+		} else {
+			// sized in base asset by the mid price
+			plan = NewMarketOrder(symbol, side, mid, amount.Div(mid).Truncate(info.StepSizePrecision))
+		}
+	}
+
+	if _, err := s.Orders.Submit(exchange, *plan); err != nil {
+		return err
+	}
+	return s.Orders.WaitFor(plan.ClientOrderID, OrderStatusTypeFilled, rebalanceOrderTimeout)
+}
@@ -0,0 +1,141 @@
+package plugins
+
+import (
+	"fmt"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"time"
+)
+
+const (
+	ReconcileServiceName = "ReconcileService"
+)
+
+// ReconcilePolicy is what to do with open orders found at startup
+type ReconcilePolicy string
+
+const (
+	ReconcilePolicyCancel ReconcilePolicy = "cancel"
+	ReconcilePolicyAdopt  ReconcilePolicy = "adopt"  // own orders only, published as OpenOrderAdopted
+	ReconcilePolicyRefuse ReconcilePolicy = "refuse" // refuse to start until an operator intervenes
+	ReconcilePolicyIgnore ReconcilePolicy = "ignore"
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         ReconcileServiceName,
+		Instance:     &ReconcileService{},
+		InitPriority: registry.Middle,
+	})
+}
+
+// ReconcileService checks open orders left by the last run on every plugin for every watched symbol,
+// orders are told ours or foreign by the prefix of ClientOrderID.
+type ReconcileService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	ownPolicy     ReconcilePolicy
+	foreignPolicy ReconcilePolicy
+}
+
+func (s *ReconcileService) IsDisabled() bool {
+	return !setting.ReconcileEnabled
+}
+
+func (s *ReconcileService) Init() error {
+	s.lg = log.New("service.reconcile")
+	s.ownPolicy = ReconcilePolicy(setting.ReconcileOwnOrders)
+	s.foreignPolicy = ReconcilePolicy(setting.ReconcileForeignOrders)
+	switch s.ownPolicy {
+	case ReconcilePolicyCancel, ReconcilePolicyAdopt, ReconcilePolicyRefuse, ReconcilePolicyIgnore:
+	default:
+		return fmt.Errorf("invalid reconciliation own_orders: %s", s.ownPolicy)
+	}
+	switch s.foreignPolicy {
+	case ReconcilePolicyCancel, ReconcilePolicyRefuse, ReconcilePolicyIgnore:
+	default:
+		return fmt.Errorf("invalid reconciliation foreign_orders: %s", s.foreignPolicy)
+	}
+
+	var refused []*Order
+	for _, plugin := range GetExPlugins() {
+		orders, err := s.reconcile(plugin)
+		if err != nil {
+			return err
+		}
+		refused = append(refused, orders...)
+	}
+	if len(refused) > 0 {
+		err := fmt.Errorf("%d open orders left, cancel them manually or change reconciliation policy", len(refused))
+		alerting.NotifyRightNow(err, "refuse to start")
+		return err
+	}
+	return nil
+}
+
+// reconcile returns open orders with refuse policy
+func (s *ReconcileService) reconcile(plugin *ExPlugin) (refused []*Order, err error) {
+	orderManager := plugin.Instance.GetOrderInterface()
+	for symbol, info := range plugin.Instance.GetBaseInfoManager().GetSymbolsBasicInfo() {
+		if !info.IsTrading() {
+			continue
+		}
+		orders, err := orderManager.ListOpenOrdersOfSymbol(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list open orders of %s in %s: %w", symbol, plugin.ExName, err)
+		}
+
+		var toCancel []OrderRef
+		for _, order := range orders {
+			own := IsOwnClientOrderID(order.ClientOrderID)
+			policy := s.foreignPolicy
+			if own {
+				policy = s.ownPolicy
+			}
+			s.lg.Warn("open order found", "exchange", plugin.ExName, "symbol", symbol, "orderID", order.OrderID,
+				"clientOrderID", order.ClientOrderID, "own", own, "policy", policy)
+
+			switch policy {
+			case ReconcilePolicyCancel:
+				toCancel = append(toCancel, OrderRef{OrderID: order.OrderID, ClientOrderID: order.ClientOrderID})
+			case ReconcilePolicyAdopt:
+				if err := s.Bus.Publish(&OpenOrderAdopted{
+					Exchange: plugin.ExName,
+					Symbol:   symbol,
+					Order:    order,
+					Time:     time.Now(),
+				}); err != nil {
+					return nil, err
+				}
+			case ReconcilePolicyRefuse:
+				refused = append(refused, order)
+			}
+		}
+
+		if len(toCancel) == 0 {
+			continue
+		}
+		results, err := BatchCancelOrders(orderManager, symbol, toCancel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel open orders of %s in %s, %d of %d sent: %w", symbol, plugin.ExName, len(results), len(toCancel), err)
+		}
+		failed := 0
+		for _, result := range results {
+			if !result.OK() {
+				failed++
+				s.lg.Error("failed to cancel open order", "exchange", plugin.ExName, "symbol", symbol,
+					"orderID", result.OrderID, "clientOrderID", result.ClientOrderID, "err", result.Err)
+			}
+		}
+		if failed > 0 {
+			return nil, fmt.Errorf("failed to cancel %d open orders of %s in %s", failed, symbol, plugin.ExName)
+		}
+		s.lg.Info("open orders canceled", "exchange", plugin.ExName, "symbol", symbol, "size", len(results))
+	}
+	return refused, nil
+}
@@ -0,0 +1,356 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/metrics"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SpreadServiceName = "SpreadService"
+
+	spreadWsRetryInterval = 5 * time.Second
+	// books are checked for staleness at least this often, when no depth arrives
+	spreadStaleCheckInterval = time.Second
+	spreadDepthQueue         = 1000
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         SpreadServiceName,
+		Instance:     &SpreadService{},
+		InitPriority: registry.Low,
+	})
+}
+
+type receivedDepth struct {
+	depth      *DepthInfo
+	receivedAt time.Time
+}
+
+// SpreadService exports gross and net spreads, executable size and lifetime of opportunities
+// for both directions of every pair of exchanges, computed from depth websockets of the plugins.
+type SpreadService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	exchanges []Exchange
+
+	// owned by the Run loop, replaced by paramsC on reload
+	spreadParams
+	paramsC chan *spreadParams
+	depthC  *bus.Subscription[ExchangeDepth]
+	books   map[Exchange]map[Symbol]receivedDepth
+	timer   *OpportunityTimer
+	halted  *HaltedSymbols
+
+	// latest spread of each direction with fresh books, read by the spread command
+	latestM sync.RWMutex
+	latest  map[SpreadKey]*Spread
+}
+
+// spreadParams fees, depth and watchlist applied without restart
+type spreadParams struct {
+	symbols    []Symbol
+	feeBps     decimal.Decimal
+	levels     int
+	staleAfter time.Duration
+}
+
+func newSpreadParams() *spreadParams {
+	params := &spreadParams{
+		feeBps:     decimal.NewFromFloat(setting.SpreadFeeBps),
+		levels:     setting.SpreadLevels,
+		staleAfter: setting.SpreadStaleAfter,
+	}
+	for _, asset := range setting.SpreadAssets {
+		if asset = strings.TrimSpace(asset); asset != "" {
+			params.symbols = append(params.symbols, NewSymbol(ToAsset(asset)))
+		}
+	}
+	return params
+}
+
+func (s *SpreadService) Init() error {
+	s.lg = log.New("service.spread")
+	s.spreadParams = *newSpreadParams()
+	s.paramsC = make(chan *spreadParams, 1)
+
+	s.exchanges = nil
+	for _, name := range setting.SpreadExchanges {
+		exchange := Exchange(strings.TrimSpace(name))
+		if GetExPluginByExchange(exchange) == nil {
+			return fmt.Errorf("exchange %s of spread is not supported", exchange)
+		}
+		s.exchanges = append(s.exchanges, exchange)
+	}
+	if len(s.exchanges) < 2 {
+		return fmt.Errorf("spread requires at least 2 exchanges, got %d", len(s.exchanges))
+	}
+
+	s.depthC = DepthUpdates.Subscribe(SpreadServiceName, spreadDepthQueue, bus.PolicyDrop)
+	s.books = make(map[Exchange]map[Symbol]receivedDepth)
+	s.timer = NewOpportunityTimer()
+	s.latest = make(map[SpreadKey]*Spread)
+	s.halted = NewHaltedSymbols()
+	s.halted.Listen(s.Bus)
+	s.Bus.AddHandler(s.onSpreadCommand)
+	s.Bus.AddEventListener(s.onConfigChanged)
+	return nil
+}
+
+func (s *SpreadService) IsDisabled() bool {
+	return !setting.SpreadEnabled
+}
+
+func (s *SpreadService) Run(ctx context.Context) error {
+	defer s.depthC.Close()
+	stopWatch := s.startWatchDepth(ctx)
+	defer func() { stopWatch() }()
+	staleTicker := time.NewTicker(spreadStaleCheckInterval)
+	defer staleTicker.Stop()
+	for {
+		select {
+		case update := <-s.depthC.C():
+			s.onDepth(update.Exchange, update.Depth, time.Now())
+		case now := <-staleTicker.C:
+			s.closeStale(now)
+		case params := <-s.paramsC:
+			resubscribe := !SameSymbols(s.symbols, params.symbols)
+			s.spreadParams = *params
+			if resubscribe {
+				stopWatch()
+				s.dropUnwatched()
+				stopWatch = s.startWatchDepth(ctx)
+			}
+			s.lg.Info("spread params reloaded", "symbols", len(s.symbols), "resubscribed", resubscribe)
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+// onConfigChanged new params are applied by the Run loop, exchanges need a restart
+func (s *SpreadService) onConfigChanged(event *setting.ConfigChanged) error {
+	if !event.SectionChanged("spread") {
+		return nil
+	}
+	// params not applied yet are replaced
+	select {
+	case <-s.paramsC:
+	default:
+	}
+	s.paramsC <- newSpreadParams()
+	return nil
+}
+
+// startWatchDepth of the watchlist on every exchange, until the returned func is called
+func (s *SpreadService) startWatchDepth(ctx context.Context) context.CancelFunc {
+	watchCtx, cancel := context.WithCancel(ctx)
+	for _, exchange := range s.exchanges {
+		go s.watchDepth(watchCtx, exchange, s.symbols)
+	}
+	return cancel
+}
+
+// dropUnwatched forgets books and closes spreads of symbols removed from the watchlist
+func (s *SpreadService) dropUnwatched() {
+	for _, books := range s.books {
+		for symbol := range books {
+			if !s.isWatched(symbol) {
+				delete(books, symbol)
+			}
+		}
+	}
+	now := time.Now()
+	for _, key := range s.latestKeys() {
+		if !s.isWatched(key.Symbol) {
+			s.observe(key.Symbol, key.BuyExchange, nil, key.SellExchange, nil, false, now)
+		}
+	}
+}
+
+// closeStale closes spreads whose books stopped updating, e.g. when a websocket is down, or whose symbol stopped trading
+func (s *SpreadService) closeStale(now time.Time) {
+	for _, key := range s.latestKeys() {
+		buyBook, buyOk := s.books[key.BuyExchange][key.Symbol]
+		sellBook, sellOk := s.books[key.SellExchange][key.Symbol]
+		if buyOk && sellOk && now.Sub(buyBook.receivedAt) <= s.staleAfter && now.Sub(sellBook.receivedAt) <= s.staleAfter &&
+			!s.halted.IsHalted(key.Symbol, key.BuyExchange, key.SellExchange) {
+			continue
+		}
+		s.observe(key.Symbol, key.BuyExchange, nil, key.SellExchange, nil, false, now)
+	}
+}
+
+func (s *SpreadService) latestKeys() []SpreadKey {
+	s.latestM.RLock()
+	defer s.latestM.RUnlock()
+	keys := make([]SpreadKey, 0, len(s.latest))
+	for key := range s.latest {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *SpreadService) isWatched(symbol Symbol) bool {
+	for _, watched := range s.symbols {
+		if watched == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SpreadService) isSpreadExchange(exchange Exchange) bool {
+	for _, e := range s.exchanges {
+		if e == exchange {
+			return true
+		}
+	}
+	return false
+}
+
+// watchDepth publishes depth of the exchange to DepthUpdates, the websocket is reconnected until ctx is done
+func (s *SpreadService) watchDepth(ctx context.Context, exchange Exchange, symbols []Symbol) {
+	infoC := make(chan *DepthInfo, 100)
+	go func() {
+		for {
+			select {
+			case depth := <-infoC:
+				DepthUpdates.Publish(ExchangeDepth{Exchange: exchange, Depth: depth})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	market := GetExPluginByExchange(exchange).GetMarketInfoManager()
+	for {
+		if err := market.WsWatchMarketDepth(ctx, infoC, symbols...); err != nil {
+			s.lg.Error("failed to watch depth", "exchange", exchange, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(spreadWsRetryInterval):
+		}
+	}
+}
+
+func (s *SpreadService) onDepth(exchange Exchange, depth *DepthInfo, now time.Time) {
+	// updates of a stopped websocket may be queued after a reload, or published by other services
+	if !s.isWatched(depth.Symbol) || !s.isSpreadExchange(exchange) {
+		return
+	}
+	if _, ok := s.books[exchange]; !ok {
+		s.books[exchange] = make(map[Symbol]receivedDepth)
+	}
+	s.books[exchange][depth.Symbol] = receivedDepth{depth: depth, receivedAt: now}
+
+	for _, other := range s.exchanges {
+		if other == exchange {
+			continue
+		}
+		otherBook, ok := s.books[other][depth.Symbol]
+		// a halted symbol can't be traded on both sides, its spread is closed
+		fresh := ok && now.Sub(otherBook.receivedAt) <= s.staleAfter && !s.halted.IsHalted(depth.Symbol, exchange, other)
+		s.observe(depth.Symbol, exchange, depth, other, otherBook.depth, fresh, now)
+		s.observe(depth.Symbol, other, otherBook.depth, exchange, depth, fresh, now)
+	}
+}
+
+// observe one direction, buying on buyExchange and selling on sellExchange, spread is closed and its gauges are deleted
+// unless both books are fresh
+func (s *SpreadService) observe(symbol Symbol, buyExchange Exchange, buyDepth *DepthInfo, sellExchange Exchange,
+	sellDepth *DepthInfo, fresh bool, now time.Time) {
+	key := SpreadKey{Symbol: symbol, BuyExchange: buyExchange, SellExchange: sellExchange}
+	labels := []string{symbol.String(), string(buyExchange), string(sellExchange)}
+
+	var spread *Spread
+	if fresh {
+		var err error
+		spread, err = ComputeSpread(buyExchange, buyDepth, sellExchange, sellDepth, s.feeBps, s.levels)
+		if err != nil {
+			s.lg.Debug("failed to compute spread", "symbol", symbol, "buy", buyExchange, "sell", sellExchange, "err", err)
+		}
+	}
+	if spread != nil {
+		gross, _ := spread.GrossBps.Float64()
+		net, _ := spread.NetBps.Float64()
+		size, _ := spread.ExecutableSize.Float64()
+		metrics.M_Coin_Spread_Gross_Bps.WithLabelValues(labels...).Set(gross)
+		metrics.M_Coin_Spread_Net_Bps.WithLabelValues(labels...).Set(net)
+		metrics.M_Coin_Spread_Net_Bps_Histogram.WithLabelValues(labels...).Observe(net)
+		metrics.M_Coin_Spread_Executable_Size.WithLabelValues(labels...).Set(size)
+	} else {
+		// a closed spread has no value, the last one would be reported until the books are fresh again
+		metrics.M_Coin_Spread_Gross_Bps.DeleteLabelValues(labels...)
+		metrics.M_Coin_Spread_Net_Bps.DeleteLabelValues(labels...)
+		metrics.M_Coin_Spread_Executable_Size.DeleteLabelValues(labels...)
+	}
+
+	s.latestM.Lock()
+	if spread != nil {
+		s.latest[key] = spread
+	} else {
+		delete(s.latest, key)
+	}
+	s.latestM.Unlock()
+
+	open := spread != nil && spread.IsOpen()
+	if open && !s.timer.IsOpen(key) {
+		direction := string(buyExchange) + "->" + string(sellExchange)
+		metrics.M_Coin_Opp_pipeline_Counter.WithLabelValues(symbol.String(), "spread_open", direction).Inc()
+		Opportunities.Publish(&Opportunity{
+			Strategy:  SpreadServiceName,
+			Symbol:    symbol,
+			Exchanges: []Exchange{buyExchange, sellExchange},
+			Direction: direction,
+			Edge:      spread.NetBps,
+			Time:      now,
+		})
+	}
+	if lifetime, closed := s.timer.Observe(key, open, now); closed {
+		metrics.M_Coin_Opportunity_Duration_Histogram.WithLabelValues(labels...).Observe(lifetime.Seconds())
+	}
+}
+
+func (s *SpreadService) onSpreadCommand(cmd *alerting.SpreadCommand) error {
+	symbol := ParseSymbol(cmd.Symbol)
+	if symbol.BaseAsset == UnKnown {
+		return fmt.Errorf("symbol %q is invalid", cmd.Symbol)
+	}
+	s.latestM.RLock()
+	var spreads []*Spread
+	for key, spread := range s.latest {
+		if key.Symbol == symbol {
+			spreads = append(spreads, spread)
+		}
+	}
+	s.latestM.RUnlock()
+	sort.Slice(spreads, func(i, j int) bool { return spreads[i].NetBps.GreaterThan(spreads[j].NetBps) })
+
+	cmd.Reply.Title = "Spread " + symbol.String()
+	cmd.Reply.Header = []string{"BUY", "SELL", "ASK", "BID", "GROSS_BPS", "NET_BPS", "SIZE"}
+	for _, spread := range spreads {
+		cmd.Reply.AddRow(string(spread.BuyExchange), string(spread.SellExchange), spread.BuyPrice.String(),
+			spread.SellPrice.String(), spread.GrossBps.StringFixed(2), spread.NetBps.StringFixed(2),
+			spread.ExecutableSize.String())
+	}
+	if len(spreads) == 0 {
+		cmd.Reply.Note = "no fresh books of the symbol"
+	}
+	return nil
+}
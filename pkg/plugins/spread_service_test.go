@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"testing"
+	"time"
+)
+
+func newTestSpreadService(b bus.Bus) *SpreadService {
+	s := &SpreadService{
+		lg:        log.New("service.spread"),
+		Bus:       b,
+		exchanges: []Exchange{Binance, MEXC},
+		spreadParams: spreadParams{
+			symbols:    []Symbol{NewSymbol(INJ)},
+			levels:     5,
+			staleAfter: time.Minute,
+		},
+		books:  make(map[Exchange]map[Symbol]receivedDepth),
+		timer:  NewOpportunityTimer(),
+		latest: make(map[SpreadKey]*Spread),
+		halted: NewHaltedSymbols(),
+	}
+	s.halted.Listen(b)
+	return s
+}
+
+func TestSpreadSkipsHaltedSymbol(t *testing.T) {
+	b := bus.New()
+	s := newTestSpreadService(b)
+	level := func(price float64) *PriceLevel {
+		return &PriceLevel{Price: decimal.NewFromFloat(price), Quantity: decimal.NewFromInt(1)}
+	}
+	symbol := NewSymbol(INJ)
+	key := SpreadKey{Symbol: symbol, BuyExchange: Binance, SellExchange: MEXC}
+	binanceDepth := &DepthInfo{Symbol: symbol, Asks: []*Ask{level(100)}, Bids: []*Bid{level(99)}}
+	mexcDepth := &DepthInfo{Symbol: symbol, Asks: []*Ask{level(102)}, Bids: []*Bid{level(101)}}
+
+	now := time.Now()
+	s.onDepth(Binance, binanceDepth, now)
+	s.onDepth(MEXC, mexcDepth, now)
+	if spread := s.latest[key]; spread == nil || !spread.IsOpen() {
+		t.Fatalf("spread should be open, got %+v", spread)
+	}
+
+	if err := b.Publish(&SymbolStoppedTrading{Exchange: MEXC, Symbol: symbol, Status: SymbolStatusHalt, Time: now}); err != nil {
+		t.Fatal(err)
+	}
+	s.closeStale(now)
+	if len(s.latest) != 0 {
+		t.Fatalf("spreads of a halted symbol should be closed, got %d", len(s.latest))
+	}
+	s.onDepth(Binance, binanceDepth, now)
+	if len(s.latest) != 0 {
+		t.Fatalf("spreads of a halted symbol should stay closed, got %d", len(s.latest))
+	}
+
+	if err := b.Publish(&SymbolResumedTrading{Exchange: MEXC, Symbol: symbol, Time: now}); err != nil {
+		t.Fatal(err)
+	}
+	s.onDepth(Binance, binanceDepth, now)
+	if spread := s.latest[key]; spread == nil || !spread.IsOpen() {
+		t.Fatalf("spread should be open after resume, got %+v", spread)
+	}
+}
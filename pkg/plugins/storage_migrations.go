@@ -0,0 +1,95 @@
+package plugins
+
+import "jasonzhu.com/coin_labor/core/components/sqlstore"
+
+// storageMigrations of StorageService, decimals are stored as text to keep their precision
+var storageMigrations = []sqlstore.Migration{
+	{
+		ID: "create orders table",
+		SQL: []string{`CREATE TABLE orders (
+			exchange            TEXT NOT NULL,
+			client_order_id     TEXT NOT NULL,
+			symbol              TEXT NOT NULL,
+			side                TEXT NOT NULL,
+			type                TEXT NOT NULL,
+			price               TEXT NOT NULL DEFAULT '',
+			quantity            TEXT NOT NULL DEFAULT '',
+			order_id            TEXT NOT NULL DEFAULT '',
+			status              TEXT NOT NULL,
+			filled_quantity     TEXT NOT NULL DEFAULT '0',
+			filled_quote_volume TEXT NOT NULL DEFAULT '0',
+			reject_reason       TEXT NOT NULL DEFAULT '',
+			created_at          DATETIME NOT NULL,
+			updated_at          DATETIME NOT NULL,
+			PRIMARY KEY (exchange, client_order_id)
+		)`,
+			"CREATE INDEX orders_symbol_created_at ON orders (symbol, created_at)",
+		},
+	},
+	{
+		ID: "create fills table",
+		SQL: []string{`CREATE TABLE fills (
+			exchange         TEXT NOT NULL,
+			symbol           TEXT NOT NULL,
+			id               TEXT NOT NULL,
+			order_id         TEXT NOT NULL,
+			client_order_id  TEXT NOT NULL,
+			price            TEXT NOT NULL,
+			qty              TEXT NOT NULL,
+			quote_qty        TEXT NOT NULL,
+			commission       TEXT NOT NULL,
+			commission_asset TEXT NOT NULL,
+			is_buyer         BOOLEAN NOT NULL,
+			is_maker         BOOLEAN NOT NULL,
+			time             DATETIME NOT NULL,
+			PRIMARY KEY (exchange, symbol, id)
+		)`,
+			"CREATE INDEX fills_client_order_id ON fills (client_order_id)",
+		},
+	},
+	{
+		ID: "create opportunities table",
+		SQL: []string{`CREATE TABLE opportunities (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			strategy  TEXT NOT NULL,
+			symbol    TEXT NOT NULL,
+			exchanges TEXT NOT NULL,
+			direction TEXT NOT NULL,
+			edge      TEXT NOT NULL,
+			taken     BOOLEAN NOT NULL,
+			time      DATETIME NOT NULL
+		)`,
+			"CREATE INDEX opportunities_strategy_time ON opportunities (strategy, time)",
+		},
+	},
+	{
+		ID: "create balance_snapshots table",
+		SQL: []string{`CREATE TABLE balance_snapshots (
+			id       INTEGER PRIMARY KEY AUTOINCREMENT,
+			exchange TEXT NOT NULL,
+			asset    TEXT NOT NULL,
+			amount   TEXT NOT NULL,
+			time     DATETIME NOT NULL
+		)`,
+			"CREATE INDEX balance_snapshots_exchange_asset_time ON balance_snapshots (exchange, asset, time)",
+		},
+	},
+	{
+		ID: "create transfers table",
+		SQL: []string{`CREATE TABLE transfers (
+			id               TEXT PRIMARY KEY,
+			asset            TEXT NOT NULL,
+			amount           TEXT NOT NULL,
+			from_exchange    TEXT NOT NULL,
+			to_exchange      TEXT NOT NULL,
+			network          TEXT NOT NULL,
+			withdrawal_id    TEXT NOT NULL,
+			tx_id            TEXT NOT NULL,
+			credited         TEXT NOT NULL,
+			state            TEXT NOT NULL,
+			error            TEXT NOT NULL,
+			created_at       DATETIME NOT NULL,
+			state_updated_at DATETIME NOT NULL
+		)`},
+	},
+}
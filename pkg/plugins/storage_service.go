@@ -0,0 +1,347 @@
+package plugins
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/metrics"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/components/sqlstore"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StorageServiceName = "StorageService"
+
+	// publishers of orders wait for the queues of the storage when they are full, other writes are dropped
+	storageQueue = 10000
+	// drops are alerted at most once in the interval
+	storageDropCheckInterval = time.Minute
+	storageDropAlertKey      = "storage.dropped"
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         StorageServiceName,
+		Instance:     &StorageService{},
+		InitPriority: registry.High,
+	})
+}
+
+// StorageService stores orders, fills, opportunities, balance snapshots and transfers in sqlite.
+// It replaces the noop transaction manager of the bus, Save*Command handlers called by DispatchCtx
+// within bus.InTransaction write in the same transaction. Events are written by Run, never on the publisher.
+type StorageService struct {
+	lg        log.Logger
+	Bus       bus.Bus           `inject:""`
+	Inventory *InventoryService `inject:""`
+
+	store        *sqlstore.SQLStore
+	submissionC  *bus.Subscription[*OrderSubmitted]
+	orderUpdateC *bus.Subscription[*OrderUpdated]
+	opportunityC *bus.Subscription[*Opportunity]
+
+	// commands of the bus event listeners, saved by Run in order
+	writeC        chan bus.Msg
+	writesDropped int64
+	// drops alerted already, owned by Run
+	dropped int64
+}
+
+func (s *StorageService) Init() error {
+	s.lg = log.New("service.storage")
+	var err error
+	if s.store, err = sqlstore.OpenSQLite(setting.DatabasePath); err != nil {
+		return err
+	}
+	if err := s.store.Migrate(storageMigrations); err != nil {
+		s.store.Close()
+		return err
+	}
+	s.lg.Info("database opened", "path", setting.DatabasePath)
+	s.Bus.SetTransactionManager(s.store)
+
+	s.Bus.AddHandlerCtx(s.onSaveOrderSubmitted)
+	s.Bus.AddHandlerCtx(s.onSaveOrderState)
+	s.Bus.AddHandlerCtx(s.onSaveFills)
+	s.Bus.AddHandlerCtx(s.onSaveOpportunity)
+	s.Bus.AddHandlerCtx(s.onSaveBalanceSnapshot)
+	s.Bus.AddHandlerCtx(s.onSaveTransfer)
+
+	s.Bus.AddEventListener(s.onTradesSynced)
+	s.Bus.AddEventListener(s.onTransferStarted)
+	s.Bus.AddEventListener(s.onTransferLanded)
+	s.Bus.AddEventListener(s.onTransferFailed)
+
+	s.submissionC = OrderSubmissions.Subscribe(StorageServiceName, storageQueue, bus.PolicyBlock)
+	s.orderUpdateC = OrderUpdates.Subscribe(StorageServiceName, storageQueue, bus.PolicyBlock)
+	s.opportunityC = Opportunities.Subscribe(StorageServiceName, storageQueue, bus.PolicyDrop)
+	s.writeC = make(chan bus.Msg, storageQueue)
+	return nil
+}
+
+func (s *StorageService) IsDisabled() bool {
+	return !setting.DatabaseEnabled
+}
+
+func (s *StorageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(setting.DatabaseBalanceSnapshotInterval)
+	defer ticker.Stop()
+	dropTicker := time.NewTicker(storageDropCheckInterval)
+	defer dropTicker.Stop()
+	for {
+		select {
+		case cmd := <-s.writeC:
+			s.save(cmd)
+		case msg := <-s.submissionC.C():
+			s.save(&SaveOrderSubmittedCommand{Submitted: msg})
+		case msg := <-s.orderUpdateC.C():
+			s.save(&SaveOrderStateCommand{State: msg.State})
+		case msg := <-s.opportunityC.C():
+			s.save(&SaveOpportunityCommand{Opportunity: msg})
+		case <-ticker.C:
+			s.snapshotBalances()
+		case <-dropTicker.C:
+			s.checkDropped()
+		case <-ctx.Done():
+			s.close()
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+// close unsubscribes, saves what is still queued and closes the database
+func (s *StorageService) close() {
+	s.submissionC.Close()
+	s.orderUpdateC.Close()
+	s.opportunityC.Close()
+	for msg := range s.submissionC.C() {
+		s.save(&SaveOrderSubmittedCommand{Submitted: msg})
+	}
+	for msg := range s.orderUpdateC.C() {
+		s.save(&SaveOrderStateCommand{State: msg.State})
+	}
+	for msg := range s.opportunityC.C() {
+		s.save(&SaveOpportunityCommand{Opportunity: msg})
+	}
+	// listeners can not be removed, the queue is never closed
+	for len(s.writeC) > 0 {
+		s.save(<-s.writeC)
+	}
+	s.checkDropped()
+	if err := s.store.Close(); err != nil {
+		s.lg.Error("failed to close database", "err", err)
+	}
+}
+
+// save in a transaction, a command of several rows is never saved partially
+func (s *StorageService) save(cmd bus.Msg) {
+	err := s.Bus.InTransaction(context.Background(), func(ctx context.Context) error {
+		return s.Bus.DispatchCtx(ctx, cmd)
+	})
+	if err != nil {
+		s.lg.Error("failed to save", "cmd", cmd, "err", err)
+	}
+}
+
+// queue the command for Run, it is dropped if the queue is full
+func (s *StorageService) queue(cmd bus.Msg) {
+	select {
+	case s.writeC <- cmd:
+	default:
+		atomic.AddInt64(&s.writesDropped, 1)
+		metrics.M_Coin_Bus_Dropped_Total.WithLabelValues("event", StorageServiceName).Inc()
+	}
+}
+
+// checkDropped alerts writes lost since the last check, the database is not complete then
+func (s *StorageService) checkDropped() {
+	total := atomic.LoadInt64(&s.writesDropped) + s.opportunityC.Dropped()
+	if total == s.dropped {
+		return
+	}
+	s.lg.Warn("storage queues are full, writes are dropped", "dropped", total-s.dropped, "total", total)
+	alerting.Raise(alerting.SeverityError, storageDropAlertKey, "storage dropped writes", "dropped", total-s.dropped,
+		"total", total)
+	s.dropped = total
+}
+
+// snapshotBalances of every exchange in one transaction
+func (s *StorageService) snapshotBalances() {
+	now := time.Now()
+	inventory := s.Inventory.Inventory()
+	err := s.Bus.InTransaction(context.Background(), func(ctx context.Context) error {
+		for _, exchange := range inventory.Exchanges() {
+			cmd := &SaveBalanceSnapshotCommand{Exchange: exchange, Balances: inventory.Balances(exchange), Time: now}
+			if err := s.Bus.DispatchCtx(ctx, cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.lg.Error("failed to snapshot balances", "err", err)
+	}
+}
+
+// listeners queue the writes, they never fail or block the publisher
+
+func (s *StorageService) onTradesSynced(event *TradesSynced) error {
+	s.queue(&SaveFillsCommand{Exchange: event.Exchange, Symbol: event.Symbol, Trades: event.Trades})
+	return nil
+}
+
+func (s *StorageService) onTransferStarted(event *TransferStarted) error {
+	transfer := event.Transfer
+	s.queue(&SaveTransferCommand{Transfer: &transfer})
+	return nil
+}
+
+func (s *StorageService) onTransferLanded(event *TransferLanded) error {
+	transfer := event.Transfer
+	s.queue(&SaveTransferCommand{Transfer: &transfer})
+	return nil
+}
+
+func (s *StorageService) onTransferFailed(event *TransferFailed) error {
+	transfer := event.Transfer
+	s.queue(&SaveTransferCommand{Transfer: &transfer})
+	return nil
+}
+
+// onSaveOrderSubmitted updated_at of a new row is the track time, updates of the order are never older than it
+func (s *StorageService) onSaveOrderSubmitted(ctx context.Context, cmd *SaveOrderSubmittedCommand) error {
+	submitted := cmd.Submitted
+	status := OrderStatusTypeNew
+	if submitted.SendStatusUnknown {
+		status = OrderStatusTypePreNew
+	} else if submitted.Err != "" {
+		status = OrderStatusTypeRejected
+	}
+	trackTime := submitted.TrackTime
+	if trackTime.IsZero() {
+		trackTime = submitted.Time
+	}
+	plan := submitted.Plan
+	return s.store.WithDbSession(ctx, func(sess sqlstore.Session) error {
+		_, err := sess.ExecContext(ctx, `INSERT INTO orders
+			(exchange, client_order_id, symbol, side, type, price, quantity, order_id, status, reject_reason, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (exchange, client_order_id) DO UPDATE SET
+				order_id = CASE WHEN orders.order_id = '' THEN excluded.order_id ELSE orders.order_id END,
+				created_at = excluded.created_at`,
+			submitted.Exchange, plan.ClientOrderID, plan.Symbol.String(), plan.Side, plan.OrderType,
+			decimalOrEmpty(plan.Price), decimalOrEmpty(plan.Quantity), submitted.OrderID, status, submitted.Err,
+			submitted.Time.UTC(), trackTime.UTC())
+		return err
+	})
+}
+
+func (s *StorageService) onSaveOrderState(ctx context.Context, cmd *SaveOrderStateCommand) error {
+	state := cmd.State
+	plan := state.Plan
+	return s.store.WithDbSession(ctx, func(sess sqlstore.Session) error {
+		_, err := sess.ExecContext(ctx, `INSERT INTO orders
+			(exchange, client_order_id, symbol, side, type, price, quantity, order_id, status,
+			 filled_quantity, filled_quote_volume, reject_reason, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (exchange, client_order_id) DO UPDATE SET
+				order_id = excluded.order_id,
+				status = excluded.status,
+				filled_quantity = excluded.filled_quantity,
+				filled_quote_volume = excluded.filled_quote_volume,
+				reject_reason = excluded.reject_reason,
+				updated_at = excluded.updated_at
+			WHERE excluded.updated_at >= orders.updated_at`,
+			state.Exchange, plan.ClientOrderID, plan.Symbol.String(), plan.Side, plan.OrderType,
+			decimalOrEmpty(plan.Price), decimalOrEmpty(plan.Quantity), state.OrderID, state.Status,
+			state.FilledQuantity, state.FilledQuoteVolume, state.RejectReason, state.UpdateTime.UTC(), state.UpdateTime.UTC())
+		return err
+	})
+}
+
+func (s *StorageService) onSaveFills(ctx context.Context, cmd *SaveFillsCommand) error {
+	return s.store.WithDbSession(ctx, func(sess sqlstore.Session) error {
+		cmd.Inserted = 0
+		for _, trade := range cmd.Trades {
+			res, err := sess.ExecContext(ctx, `INSERT OR IGNORE INTO fills
+				(exchange, symbol, id, order_id, client_order_id, price, qty, quote_qty, commission, commission_asset,
+				 is_buyer, is_maker, time)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				cmd.Exchange, cmd.Symbol.String(), trade.Id, trade.OrderId, trade.ClientOrderId, trade.Price, trade.Qty,
+				trade.QuoteQty, trade.Commission, trade.CommissionAsset, trade.IsBuyer, trade.IsMaker,
+				time.UnixMilli(trade.Time).UTC())
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err == nil {
+				cmd.Inserted += int(n)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *StorageService) onSaveOpportunity(ctx context.Context, cmd *SaveOpportunityCommand) error {
+	o := cmd.Opportunity
+	exchanges := make([]string, 0, len(o.Exchanges))
+	for _, exchange := range o.Exchanges {
+		exchanges = append(exchanges, string(exchange))
+	}
+	return s.store.WithDbSession(ctx, func(sess sqlstore.Session) error {
+		_, err := sess.ExecContext(ctx, `INSERT INTO opportunities (strategy, symbol, exchanges, direction, edge, taken, time)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			o.Strategy, o.Symbol.String(), strings.Join(exchanges, ","), o.Direction, o.Edge, o.Taken, o.Time.UTC())
+		return err
+	})
+}
+
+// onSaveBalanceSnapshot assets with zero balance are left out
+func (s *StorageService) onSaveBalanceSnapshot(ctx context.Context, cmd *SaveBalanceSnapshotCommand) error {
+	return s.store.WithDbSession(ctx, func(sess sqlstore.Session) error {
+		for asset, amount := range cmd.Balances {
+			if amount.IsZero() {
+				continue
+			}
+			if _, err := sess.ExecContext(ctx, "INSERT INTO balance_snapshots (exchange, asset, amount, time) VALUES (?, ?, ?, ?)",
+				cmd.Exchange, asset, amount, cmd.Time.UTC()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *StorageService) onSaveTransfer(ctx context.Context, cmd *SaveTransferCommand) error {
+	t := cmd.Transfer
+	return s.store.WithDbSession(ctx, func(sess sqlstore.Session) error {
+		_, err := sess.ExecContext(ctx, `INSERT INTO transfers
+			(id, asset, amount, from_exchange, to_exchange, network, withdrawal_id, tx_id, credited, state, error,
+			 created_at, state_updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				withdrawal_id = excluded.withdrawal_id,
+				tx_id = excluded.tx_id,
+				credited = excluded.credited,
+				state = excluded.state,
+				error = excluded.error,
+				state_updated_at = excluded.state_updated_at`,
+			t.ID, t.Asset, t.Amount, t.From, t.To, t.Network, t.WithdrawalID, t.TxID, t.Credited, t.State, t.Error,
+			t.CreatedAt.UTC(), t.StateUpdatedAt.UTC())
+		return err
+	})
+}
+
+func decimalOrEmpty(d *decimal.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
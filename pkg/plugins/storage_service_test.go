@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/sqlstore"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStorageService(t *testing.T) *StorageService {
+	setting.DatabasePath = filepath.Join(t.TempDir(), "coin_labor.db")
+	s := &StorageService{Bus: bus.New()}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.submissionC.Close()
+		s.orderUpdateC.Close()
+		s.opportunityC.Close()
+		s.store.Close()
+	})
+	return s
+}
+
+func (s *StorageService) orderStatus(t *testing.T, exchange Exchange, clientOrderID string) (OrderStatusType, string) {
+	var status, orderID string
+	err := s.store.WithDbSession(context.Background(), func(sess sqlstore.Session) error {
+		return sess.QueryRowContext(context.Background(), "SELECT status, order_id FROM orders WHERE exchange = ? AND client_order_id = ?",
+			exchange, clientOrderID).Scan(&status, &orderID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return OrderStatusType(status), orderID
+}
+
+// the update of a fill may arrive while CreateOrder returns, it is older than the time of the submission
+func TestStorageOrderSubmittedAndFilled(t *testing.T) {
+	trackTime := time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC)
+	plan := OrderPlan{ClientOrderID: "a", Symbol: NewSymbol(ETH), Side: SideTypeBuy, OrderType: OrderTypeMarket}
+	submitted := NewOrderSubmitted(Binance, plan, trackTime, &CreateOrderResponse{OrderID: "1"}, nil)
+	filled := OrderState{Exchange: Binance, Plan: plan, OrderID: "1", Status: OrderStatusTypeFilled,
+		FilledQuantity: decimal.NewFromInt(1), UpdateTime: trackTime.Add(10 * time.Millisecond)}
+
+	for name, cmds := range map[string][]bus.Msg{
+		"submitted first": {&SaveOrderSubmittedCommand{Submitted: submitted}, &SaveOrderStateCommand{State: filled}},
+		"filled first":    {&SaveOrderStateCommand{State: filled}, &SaveOrderSubmittedCommand{Submitted: submitted}},
+	} {
+		s := newTestStorageService(t)
+		for _, cmd := range cmds {
+			s.save(cmd)
+		}
+		if status, orderID := s.orderStatus(t, Binance, "a"); status != OrderStatusTypeFilled || orderID != "1" {
+			t.Fatalf("%s: unexpected order %s, %s", name, status, orderID)
+		}
+	}
+}
+
+func TestStorageOlderStateIgnored(t *testing.T) {
+	s := newTestStorageService(t)
+	now := time.Now()
+	plan := OrderPlan{ClientOrderID: "a", Symbol: NewSymbol(ETH), Side: SideTypeBuy, OrderType: OrderTypeLimit}
+	s.save(&SaveOrderStateCommand{State: OrderState{Exchange: Binance, Plan: plan, OrderID: "1",
+		Status: OrderStatusTypeFilled, UpdateTime: now}})
+	s.save(&SaveOrderStateCommand{State: OrderState{Exchange: Binance, Plan: plan, OrderID: "1",
+		Status: OrderStatusTypeNew, UpdateTime: now.Add(-time.Second)}})
+	if status, _ := s.orderStatus(t, Binance, "a"); status != OrderStatusTypeFilled {
+		t.Fatalf("unexpected status %s", status)
+	}
+}
+
+func TestStorageQueueDropsWhenFull(t *testing.T) {
+	s := newTestStorageService(t)
+	for i := 0; i < storageQueue+1; i++ {
+		s.queue(&SaveTransferCommand{Transfer: &Transfer{}})
+	}
+	if len(s.writeC) != storageQueue || s.writesDropped != 1 {
+		t.Fatalf("unexpected queue %d, dropped %d", len(s.writeC), s.writesDropped)
+	}
+}
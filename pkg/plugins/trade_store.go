@@ -0,0 +1,123 @@
+package plugins
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TradeStore stores every fill locally for PnL and accounting
+type TradeStore interface {
+	// Cursor returns the position of the last stored trade of the symbol, it must not be modified
+	Cursor(exchange Exchange, symbol Symbol) (*TradeCursor, error)
+	// Save stores trades not seen by the cursor and advances it, returns the number of trades stored
+	Save(exchange Exchange, symbol Symbol, trades []*Trade) (int, error)
+}
+
+// fileTradeStore appends trades as json lines, one file for each symbol: <dir>/<exchange>/<BASE>_<QUOTE>.jsonl
+type fileTradeStore struct {
+	dir     string
+	lock    sync.Mutex
+	cursors map[string]*TradeCursor
+}
+
+func newFileTradeStore(dir string) *fileTradeStore {
+	return &fileTradeStore{
+		dir:     dir,
+		cursors: make(map[string]*TradeCursor),
+	}
+}
+
+func (s *fileTradeStore) path(exchange Exchange, symbol Symbol) string {
+	return filepath.Join(s.dir, string(exchange), fmt.Sprintf("%s_%s.jsonl", symbol.BaseAsset, symbol.QuoteAsset))
+}
+
+func (s *fileTradeStore) Cursor(exchange Exchange, symbol Symbol) (*TradeCursor, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.cursor(s.path(exchange, symbol))
+}
+
+func (s *fileTradeStore) cursor(path string) (*TradeCursor, error) {
+	if cursor, ok := s.cursors[path]; ok {
+		return cursor, nil
+	}
+
+	// replay the file to rebuild the cursor after restart
+	cursor := &TradeCursor{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		s.cursors[path] = cursor
+		return cursor, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var trade Trade
+		if err := json.Unmarshal(scanner.Bytes(), &trade); err != nil {
+			return nil, fmt.Errorf("corrupted trade in %s: %w", path, err)
+		}
+		cursor.Advance([]*Trade{&trade})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	s.cursors[path] = cursor
+	return cursor, nil
+}
+
+func (s *fileTradeStore) Save(exchange Exchange, symbol Symbol, trades []*Trade) (int, error) {
+	path := s.path(exchange, symbol)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	cursor, err := s.cursor(path)
+	if err != nil {
+		return 0, err
+	}
+	var fresh []*Trade
+	for _, trade := range trades {
+		if !cursor.Seen(trade) {
+			fresh = append(fresh, trade)
+		}
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+
+	// cursor advances only after trades are written
+	if err := s.append(path, fresh); err != nil {
+		return 0, err
+	}
+	return len(cursor.Advance(fresh)), nil
+}
+
+func (s *fileTradeStore) append(path string, trades []*Trade) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, trade := range trades {
+		line, err := json.Marshal(trade)
+		if err != nil {
+			return err
+		}
+		_, _ = w.Write(line)
+		_ = w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
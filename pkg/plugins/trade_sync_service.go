@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"context"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"path/filepath"
+	"time"
+)
+
+const (
+	TradeSyncServiceName = "TradeSyncService"
+
+	// tradeSyncPageLimit supported by all exchanges, MEXC allows 100 at most
+	tradeSyncPageLimit = 100
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         TradeSyncServiceName,
+		Instance:     &TradeSyncService{},
+		InitPriority: registry.Low,
+	})
+}
+
+// TradeSyncService syncs trades of every watched symbol incrementally and stores every fill locally.
+type TradeSyncService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	Store    TradeStore
+	interval time.Duration
+}
+
+func (s *TradeSyncService) Init() error {
+	s.lg = log.New("service.trade_sync")
+	s.interval = setting.TradeSyncInterval
+	if s.Store == nil {
+		s.Store = newFileTradeStore(filepath.Join(setting.DataPath, "trades"))
+	}
+	return nil
+}
+
+func (s *TradeSyncService) IsDisabled() bool {
+	return setting.TradeSyncInterval <= 0
+}
+
+func (s *TradeSyncService) Run(ctx context.Context) error {
+	s.syncAll(ctx)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.syncAll(ctx)
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+func (s *TradeSyncService) syncAll(ctx context.Context) {
+	for _, plugin := range GetExPlugins() {
+		for symbol, info := range plugin.Instance.GetBaseInfoManager().GetSymbolsBasicInfo() {
+			if ctx.Err() != nil {
+				return
+			}
+			// halted symbols have no new trades, they are synced from the cursor once they resume
+			if !info.IsTrading() {
+				continue
+			}
+			size, err := s.Sync(plugin.ExName, plugin.Instance.GetTradeInterface(), symbol)
+			if err != nil {
+				s.lg.Error("failed to sync trades", "exchange", plugin.ExName, "symbol", symbol, "err", err)
+				continue
+			}
+			if size > 0 {
+				s.lg.Info("trades synced", "exchange", plugin.ExName, "symbol", symbol, "size", size)
+			}
+		}
+	}
+}
+
+// Sync pages trades of the symbol from the stored cursor until no more new trades
+func (s *TradeSyncService) Sync(exchange Exchange, tradeInterface TradeInterface, symbol Symbol) (int, error) {
+	total := 0
+	for {
+		cursor, err := s.Store.Cursor(exchange, symbol)
+		if err != nil {
+			return total, err
+		}
+		trades, err := tradeInterface.ListTrades(symbol, cursor.Query(tradeSyncPageLimit))
+		if err != nil {
+			return total, err
+		}
+		size, err := s.Store.Save(exchange, symbol, trades)
+		if err != nil {
+			return total, err
+		}
+		total += size
+		if size > 0 {
+			if err := s.Bus.Publish(&TradesSynced{Exchange: exchange, Symbol: symbol, Trades: trades, Time: time.Now()}); err != nil {
+				s.lg.Error("failed to publish trades synced", "exchange", exchange, "symbol", symbol, "err", err)
+			}
+		}
+		if size == 0 || len(trades) < tradeSyncPageLimit {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"jasonzhu.com/coin_labor/core/components/alerting"
+	"jasonzhu.com/coin_labor/core/components/bus"
+	"jasonzhu.com/coin_labor/core/components/log"
+	"jasonzhu.com/coin_labor/core/components/registry"
+	"jasonzhu.com/coin_labor/core/setting"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	TransferServiceName = "TransferService"
+
+	// transferSubmitGrace time for a withdrawal to show up in the history after it is submitted
+	transferSubmitGrace = 5 * time.Minute
+	// transferHistoryLookBack history before the transfer created is also searched, for clock drift
+	transferHistoryLookBack = 10 * time.Minute
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         TransferServiceName,
+		Instance:     &TransferService{},
+		InitPriority: registry.Middle,
+	})
+}
+
+// TransferService moves funds between exchanges: withdraws from one exchange to the whitelisted address of
+// the other, tracks the withdrawal until it is on-chain and the deposit is credited.
+// Every step is persisted before the next one, a restart resumes transfers in progress,
+// and publishes TransferResumed for the ones withdrawn so funds in transit are counted.
+type TransferService struct {
+	lg  log.Logger
+	Bus bus.Bus `inject:""`
+
+	Store        TransferStore
+	lock         sync.Mutex
+	transfers    map[string]*Transfer
+	stepping     map[string]bool // transfers being stepped outside of lock
+	pollInterval time.Duration
+	stuckTimeout time.Duration
+}
+
+func (s *TransferService) Init() error {
+	s.lg = log.New("service.transfer")
+	s.pollInterval = setting.TransferPollInterval
+	s.stuckTimeout = setting.TransferStuckTimeout
+	s.transfers = make(map[string]*Transfer)
+	s.stepping = make(map[string]bool)
+	if s.Store == nil {
+		s.Store = newFileTransferStore(filepath.Join(setting.DataPath, "transfers"))
+	}
+
+	transfers, err := s.Store.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load transfers: %w", err)
+	}
+	for _, transfer := range transfers {
+		if transfer.IsDone() {
+			continue
+		}
+		s.transfers[transfer.ID] = transfer
+		s.lg.Info("transfer resumed", "id", transfer.ID, "asset", transfer.Asset, "amount", transfer.Amount,
+			"from", transfer.From, "to", transfer.To, "state", transfer.State)
+	}
+	return nil
+}
+
+// Start withdraws amount of asset from exchange from to the address of exchange to in the address book
+func (s *TransferService) Start(asset Asset, amount decimal.Decimal, from, to Exchange) (*Transfer, error) {
+	if from == to {
+		return nil, fmt.Errorf("transfer from %s to itself", from)
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("invalid amount of transfer: %s", amount)
+	}
+	for _, exchange := range []Exchange{from, to} {
+		if GetExPluginByExchange(exchange) == nil {
+			return nil, fmt.Errorf("exchange %s is not supported", exchange)
+		}
+	}
+	address, err := FindWithdrawAddress(to, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := NewTransfer(asset, amount, from, to)
+	transfer.AddressName = address.Name
+	transfer.Network = address.Network
+	if err := s.Store.Save(transfer); err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	s.transfers[transfer.ID] = transfer
+	s.lock.Unlock()
+	s.lg.Info("transfer created", "id", transfer.ID, "asset", asset, "amount", amount, "from", from, "to", to,
+		"address", address.Name)
+
+	s.step(transfer)
+	s.lock.Lock()
+	copied := *transfer
+	s.lock.Unlock()
+	return &copied, nil
+}
+
+// Get returns a copy of the transfer in progress
+func (s *TransferService) Get(id string) (Transfer, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	transfer, ok := s.transfers[id]
+	if !ok {
+		return Transfer{}, false
+	}
+	return *transfer, true
+}
+
+// InProgress transfers not done yet
+func (s *TransferService) InProgress() []Transfer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := make([]Transfer, 0, len(s.transfers))
+	for _, transfer := range s.transfers {
+		res = append(res, *transfer)
+	}
+	return res
+}
+
+func (s *TransferService) Run(ctx context.Context) error {
+	s.resumeInTransit()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.poll()
+		case <-ctx.Done():
+			s.lg.Info("Stopped")
+			return nil
+		}
+	}
+}
+
+// resumeInTransit publishes TransferResumed for resumed transfers whose withdrawal is accepted,
+// funds in transit are only known from events
+func (s *TransferService) resumeInTransit() {
+	for _, t := range s.InProgress() {
+		if t.State != TransferStateWithdrawSubmitted && t.State != TransferStateWithdrawConfirmed {
+			continue
+		}
+		if err := s.Bus.Publish(&TransferResumed{Transfer: t, Time: time.Now()}); err != nil {
+			s.lg.Error("failed to publish transfer in transit", "id", t.ID, "err", err)
+		}
+	}
+}
+
+func (s *TransferService) poll() {
+	s.lock.Lock()
+	transfers := make([]*Transfer, 0, len(s.transfers))
+	for _, transfer := range s.transfers {
+		transfers = append(transfers, transfer)
+	}
+	s.lock.Unlock()
+
+	for _, transfer := range transfers {
+		s.step(transfer)
+	}
+}
+
+// step advances the transfer by one state at most, the transfer is only modified here.
+// REST calls and the store work on a copy out of lock, a transfer is stepped by one caller at a time.
+func (s *TransferService) step(transfer *Transfer) {
+	s.lock.Lock()
+	if s.stepping[transfer.ID] {
+		s.lock.Unlock()
+		return
+	}
+	s.stepping[transfer.ID] = true
+	work := *transfer
+	s.lock.Unlock()
+
+	t := &work
+	state := t.State
+	var err error
+	switch t.State {
+	case TransferStateCreated:
+		err = s.submit(t)
+	case TransferStateWithdrawSubmitting:
+		err = s.checkSubmitted(t)
+	case TransferStateWithdrawSubmitted:
+		err = s.checkWithdrawal(t)
+	case TransferStateWithdrawConfirmed:
+		err = s.checkDeposit(t)
+	}
+	if err != nil {
+		s.lg.Warn("failed to check transfer", "id", t.ID, "state", t.State, "err", err)
+	}
+
+	if t.State != state {
+		s.lg.Info("transfer state changed", "id", t.ID, "from", state, "to", t.State, "withdrawalID", t.WithdrawalID,
+			"txID", t.TxID)
+		s.persist(t)
+		s.publish(t, state)
+	} else if now := time.Now(); t.IsStuck(now, s.stuckTimeout) && now.Sub(t.AlertedAt) > s.stuckTimeout {
+		t.AlertedAt = now
+		alerting.Notify(errors.New("transfer is stuck"), "transfer made no progress",
+			"id", t.ID, "asset", t.Asset, "amount", t.Amount, "from", t.From, "to", t.To, "state", t.State,
+			"since", t.StateUpdatedAt.Format(time.RFC3339))
+		s.persist(t)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	*transfer = work
+	delete(s.stepping, t.ID)
+	if t.IsDone() {
+		delete(s.transfers, t.ID)
+	}
+}
+
+func (s *TransferService) persist(t *Transfer) {
+	if err := s.Store.Save(t); err != nil {
+		alerting.NotifyRightNow(err, "failed to persist transfer", "id", t.ID, "state", t.State)
+	}
+}
+
+func (s *TransferService) publish(t *Transfer, previous TransferState) {
+	var event bus.Msg
+	switch {
+	case t.State == TransferStateWithdrawSubmitted:
+		event = &TransferStarted{Transfer: *t, Time: time.Now()}
+	case t.State == TransferStateDepositCredited:
+		event = &TransferLanded{Transfer: *t, Time: time.Now()}
+		alerting.Info("transfer landed", "id", t.ID, "asset", t.Asset, "amount", t.Amount, "credited", t.Credited,
+			"from", t.From, "to", t.To)
+	case t.State == TransferStateFailed:
+		alerting.Notify(errors.New(t.Error), "transfer failed", "id", t.ID, "asset", t.Asset, "amount", t.Amount,
+			"from", t.From, "to", t.To, "previous", previous)
+		// nothing left the exchange if the withdrawal was never accepted
+		if t.WithdrawalID == "" {
+			return
+		}
+		event = &TransferFailed{Transfer: *t, Time: time.Now()}
+	default:
+		return
+	}
+	if err := s.Bus.Publish(event); err != nil {
+		s.lg.Error("failed to publish transfer event", "id", t.ID, "state", t.State, "err", err)
+	}
+}
+
+// submit persists WITHDRAW_SUBMITTING before withdrawing, so a restart never withdraws twice
+func (s *TransferService) submit(t *Transfer) error {
+	wallet := GetExPluginByExchange(t.From).GetWalletInterface()
+	t.SetState(TransferStateWithdrawSubmitting)
+	if err := s.Store.Save(t); err != nil {
+		t.SetState(TransferStateCreated)
+		return err
+	}
+
+	withdrawal, err := wallet.Withdraw(WithdrawRequest{
+		Asset:           t.Asset,
+		Amount:          t.Amount,
+		AddressName:     t.AddressName,
+		WithdrawOrderID: t.ID,
+	})
+	if err != nil {
+		// the withdrawal may be accepted even though the response is lost, checkSubmitted decides
+		t.Error = err.Error()
+		return err
+	}
+	t.Error = ""
+	t.WithdrawalID = withdrawal.ID
+	t.SetState(TransferStateWithdrawSubmitted)
+	return nil
+}
+
+// checkSubmitted looks for the withdrawal whose response is lost, it fails after transferSubmitGrace
+func (s *TransferService) checkSubmitted(t *Transfer) error {
+	withdrawal, err := s.findWithdrawal(t)
+	if err != nil {
+		return err
+	}
+	if withdrawal != nil {
+		t.Error = ""
+		t.WithdrawalID = withdrawal.ID
+		t.SetState(TransferStateWithdrawSubmitted)
+		return nil
+	}
+	if time.Since(t.StateUpdatedAt) > transferSubmitGrace {
+		if t.Error == "" {
+			t.Error = "withdrawal not found in history"
+		}
+		t.SetState(TransferStateFailed)
+	}
+	return nil
+}
+
+func (s *TransferService) checkWithdrawal(t *Transfer) error {
+	withdrawal, err := s.findWithdrawal(t)
+	if err != nil {
+		return err
+	}
+	if withdrawal == nil {
+		return fmt.Errorf("withdrawal %s not found in history", t.WithdrawalID)
+	}
+	switch withdrawal.Status {
+	case TransferStatusSuccess:
+		if withdrawal.TxID == "" {
+			return nil
+		}
+		t.TxID = withdrawal.TxID
+		t.SetState(TransferStateWithdrawConfirmed)
+	case TransferStatusFailed, TransferStatusCanceled:
+		t.Fail(fmt.Errorf("withdrawal %s is %s", withdrawal.ID, withdrawal.Status))
+	}
+	return nil
+}
+
+func (s *TransferService) checkDeposit(t *Transfer) error {
+	wallet := GetExPluginByExchange(t.To).GetWalletInterface()
+	deposits, err := wallet.ListDeposits(t.Asset, t.CreatedAt.Add(-transferHistoryLookBack).UnixMilli(), 0)
+	if err != nil {
+		return err
+	}
+	for _, deposit := range deposits {
+		if deposit.TxID != t.TxID {
+			continue
+		}
+		switch deposit.Status {
+		case TransferStatusSuccess:
+			t.Credited = deposit.Amount
+			t.SetState(TransferStateDepositCredited)
+		case TransferStatusFailed, TransferStatusCanceled:
+			t.Fail(fmt.Errorf("deposit of %s is %s", t.TxID, deposit.Status))
+		}
+		return nil
+	}
+	return nil
+}
+
+func (s *TransferService) findWithdrawal(t *Transfer) (*Withdrawal, error) {
+	wallet := GetExPluginByExchange(t.From).GetWalletInterface()
+	withdrawals, err := wallet.ListWithdrawals(t.Asset, t.CreatedAt.Add(-transferHistoryLookBack).UnixMilli(), 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, withdrawal := range withdrawals {
+		if (t.WithdrawalID != "" && withdrawal.ID == t.WithdrawalID) || withdrawal.WithdrawOrderID == t.ID {
+			return withdrawal, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	. "jasonzhu.com/coin_labor/pkg/plugins/general"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TransferStore persists every step of transfers, so a restart resumes them
+type TransferStore interface {
+	Save(transfer *Transfer) error
+	// LoadAll returns every transfer stored, including the ones done
+	LoadAll() ([]*Transfer, error)
+}
+
+// fileTransferStore one json file for each transfer: <dir>/<id>.json
+type fileTransferStore struct {
+	dir string
+}
+
+func newFileTransferStore(dir string) *fileTransferStore {
+	return &fileTransferStore{dir: dir}
+}
+
+// Save writes a temp file and renames it, a crash never leaves a half written transfer
+func (s *fileTransferStore) Save(transfer *Transfer) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(transfer, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, transfer.ID+".json")
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *fileTransferStore) LoadAll() ([]*Transfer, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var transfers []*Transfer
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var transfer Transfer
+		if err := json.Unmarshal(data, &transfer); err != nil {
+			return nil, fmt.Errorf("corrupted transfer in %s: %w", path, err)
+		}
+		transfers = append(transfers, &transfer)
+	}
+	return transfers, nil
+}